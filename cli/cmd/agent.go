@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/shipyard/cli/pkg/monitoring"
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Continuously collect metrics, health checks and alerts",
+	Long: `Run the monitoring agent in the foreground.
+
+The agent collects metrics, performs health checks and evaluates alerts for
+every app at its health check interval (see 'shipyard alerts config'), and
+stores Kubernetes events as they happen. Apps deployed while the agent runs
+are picked up automatically.
+
+The agent can run in the cluster: it then uses the pod's service account
+instead of a kubeconfig. Mount a volume at $HOME/.shipyard to keep the
+database.
+
+With --listen, the agent also serves the collected data as a Prometheus
+metrics endpoint (see 'shipyard exporter').
+
+Examples:
+  shipyard agent                 # Collect until Ctrl+C
+  shipyard agent --events=false  # Do not store Kubernetes events
+  shipyard agent --listen :9100  # Also serve /metrics for Prometheus`,
+	Run: func(cmd *cobra.Command, args []string) {
+		watchEvents, _ := cmd.Flags().GetBool("events")
+		listen, _ := cmd.Flags().GetString("listen")
+
+		if err := runAgent(watchEvents, listen); err != nil {
+			log.Fatalf("Agent failed: %v", err)
+		}
+	},
+}
+
+func init() {
+	agentCmd.Flags().Bool("events", true, "Store Kubernetes events")
+	agentCmd.Flags().String("listen", "", "Address to serve Prometheus metrics on, e.g. :9100")
+}
+
+func runAgent(watchEvents bool, listen string) error {
+	// Initialize monitoring collector
+	collector, err := monitoring.NewCollector()
+	if err != nil {
+		return fmt.Errorf("failed to initialize monitoring: %w", err)
+	}
+	defer collector.Close()
+
+	if listen != "" {
+		server, err := serveMetrics(listen, collector.Exporter())
+		if err != nil {
+			return err
+		}
+		defer shutdownMetrics(server)
+	}
+
+	// Handle Ctrl+C and pod termination
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		<-sigChan
+		fmt.Println("\n👋 Stopping agent...")
+		collector.Stop()
+	}()
+
+	fmt.Println("🤖 Starting Shipyard agent (press Ctrl+C to stop)")
+	return collector.Run(watchEvents)
+}
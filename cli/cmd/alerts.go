@@ -17,17 +17,33 @@ var alertsCmd = &cobra.Command{
 	Long: `Manage alerts for your deployed applications.
 
 Available commands:
-  list      List active alerts
+  list      List open alerts
   history   Show alert history
   resolve   Resolve an alert
-  config    Configure alert thresholds
+  ack       Acknowledge an alert
+  silence   Silence an alert type of an app: cpu_high, memory_high,
+            error_rate_high or response_time_high
+  config    Show or change alert thresholds and health checks
+  channels  List, add or remove notification channels
+  test      Send a sample notification
 
 Examples:
   shipyard alerts list                # List all active alerts
   shipyard alerts list my-app         # List alerts for specific app
   shipyard alerts history --period 1d # Show alerts from last day
   shipyard alerts resolve 123         # Resolve alert with ID 123
-  shipyard alerts config my-app       # Configure alerts for app`,
+  shipyard alerts ack 123             # Acknowledge alert with ID 123
+  shipyard alerts silence my-app cpu_high --for 2h  # Silence CPU alerts during maintenance
+  shipyard alerts config my-app       # Show alert configuration for app
+  shipyard alerts config my-app --cpu 75 --memory 90 --response-time 800 --health-path /healthz --interval 15s
+
+  shipyard alerts channels add ops --type slack --url https://hooks.slack.com/services/...
+  shipyard alerts channels add oncall --type email --to ops@example.com --smtp-host smtp.example.com --from shipyard@example.com --min-severity critical
+  shipyard alerts channels remove ops
+  shipyard alerts test ops            # Send a sample notification to ops
+
+Thresholds can also be set in the monitoring block of paas.yaml, which is
+synced on every deploy.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) == 0 {
 			// Default to list command
@@ -71,14 +87,67 @@ Examples:
 			if err := runAlertsResolve(alertID); err != nil {
 				log.Fatalf("Resolve alert failed: %v", err)
 			}
+		case "ack":
+			if len(args) < 2 {
+				fmt.Println("Error: Alert ID required")
+				cmd.Help()
+				return
+			}
+			alertID, err := strconv.ParseInt(args[1], 10, 64)
+			if err != nil {
+				fmt.Printf("Error: Invalid alert ID: %v\n", err)
+				return
+			}
+			if err := runAlertsAck(alertID); err != nil {
+				log.Fatalf("Acknowledge alert failed: %v", err)
+			}
+		case "silence":
+			if len(args) < 3 {
+				fmt.Println("Error: App name and alert type required")
+				cmd.Help()
+				return
+			}
+			duration, _ := cmd.Flags().GetDuration("for")
+			if duration <= 0 {
+				fmt.Println("Error: --for must be a positive duration")
+				return
+			}
+			if err := runAlertsSilence(args[1], args[2], duration); err != nil {
+				log.Fatalf("Silence alerts failed: %v", err)
+			}
 		case "config":
 			appName := ""
 			if len(args) > 1 {
 				appName = args[1]
 			}
-			if err := runAlertsConfig(appName); err != nil {
+			update, err := alertConfigUpdateFromFlags(cmd)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			if err := runAlertsConfig(appName, update); err != nil {
 				log.Fatalf("Configure alerts failed: %v", err)
 			}
+		case "channels":
+			action := "list"
+			if len(args) > 1 {
+				action = args[1]
+			}
+			channelName := ""
+			if len(args) > 2 {
+				channelName = args[2]
+			}
+			if err := runAlertsChannels(cmd, action, channelName); err != nil {
+				log.Fatalf("Notification channels failed: %v", err)
+			}
+		case "test":
+			channelName := ""
+			if len(args) > 1 {
+				channelName = args[1]
+			}
+			if err := runAlertsTest(channelName); err != nil {
+				log.Fatalf("Test notification failed: %v", err)
+			}
 		default:
 			fmt.Printf("Unknown command: %s\n", subCommand)
 			cmd.Help()
@@ -89,6 +158,26 @@ Examples:
 func init() {
 	alertsCmd.Flags().BoolP("active", "a", false, "Show only active alerts")
 	alertsCmd.Flags().DurationP("period", "p", 24*time.Hour, "Time period for history")
+	alertsCmd.Flags().Duration("for", time.Hour, "How long to silence alerts")
+	alertsCmd.Flags().Float64("cpu", 0, "CPU usage threshold in percent (config)")
+	alertsCmd.Flags().Float64("memory", 0, "Memory usage threshold in percent (config)")
+	alertsCmd.Flags().Float64("error-rate", 0, "Error rate threshold in percent (config)")
+	alertsCmd.Flags().Int("response-time", 0, "Response time threshold in milliseconds (config)")
+	alertsCmd.Flags().String("health-path", "", "Health check path (config)")
+	alertsCmd.Flags().Duration("interval", 0, "Health check interval (config)")
+	alertsCmd.Flags().Bool("metrics", true, "Scrape Prometheus metrics from the pods (config)")
+	alertsCmd.Flags().String("metrics-path", "", "Prometheus metrics path (config)")
+	alertsCmd.Flags().Int("metrics-port", 0, "Prometheus metrics port (config)")
+	alertsCmd.Flags().String("type", "", "Channel type: webhook, slack or email (channels add)")
+	alertsCmd.Flags().String("url", "", "Webhook URL (channels add)")
+	alertsCmd.Flags().String("to", "", "Comma-separated email recipients (channels add)")
+	alertsCmd.Flags().String("min-severity", "warning", "Minimum severity to notify: info, warning or critical (channels add)")
+	alertsCmd.Flags().String("app", "", "Only notify alerts of this app (channels add)")
+	alertsCmd.Flags().String("smtp-host", "", "SMTP server host (channels add)")
+	alertsCmd.Flags().Int("smtp-port", 587, "SMTP server port (channels add)")
+	alertsCmd.Flags().String("smtp-user", "", "SMTP username (channels add)")
+	alertsCmd.Flags().String("smtp-password", "", "SMTP password (channels add)")
+	alertsCmd.Flags().String("from", "", "Sender address for email (channels add)")
 }
 
 // AlertInfo represents alert information for display
@@ -104,6 +193,9 @@ type AlertInfo struct {
 	CreatedAt    time.Time
 	ResolvedAt   *time.Time
 	Duration     time.Duration
+
+	AcknowledgedAt  *time.Time
+	SuppressedUntil *time.Time
 }
 
 func runAlertsList(appName string, activeOnly bool) error {
@@ -156,7 +248,40 @@ func runAlertsResolve(alertID int64) error {
 	return nil
 }
 
-func runAlertsConfig(appName string) error {
+func runAlertsAck(alertID int64) error {
+	// Initialize monitoring collector
+	collector, err := monitoring.NewCollector()
+	if err != nil {
+		return fmt.Errorf("failed to initialize monitoring: %w", err)
+	}
+	defer collector.Close()
+
+	if err := collector.AcknowledgeAlert(alertID); err != nil {
+		return fmt.Errorf("failed to acknowledge alert: %w", err)
+	}
+
+	fmt.Printf("👀 Alert %d acknowledged\n", alertID)
+	return nil
+}
+
+func runAlertsSilence(appName, alertType string, duration time.Duration) error {
+	// Initialize monitoring collector
+	collector, err := monitoring.NewCollector()
+	if err != nil {
+		return fmt.Errorf("failed to initialize monitoring: %w", err)
+	}
+	defer collector.Close()
+
+	until, err := collector.SilenceAlerts(appName, alertType, duration)
+	if err != nil {
+		return fmt.Errorf("failed to silence alerts: %w", err)
+	}
+
+	fmt.Printf("🔇 %s alerts for %s silenced until %s\n", alertType, appName, until.Format("2006-01-02 15:04"))
+	return nil
+}
+
+func runAlertsConfig(appName string, update monitoring.MonitoringConfigUpdate) error {
 	if appName == "" {
 		return fmt.Errorf("app name required for configuration")
 	}
@@ -168,154 +293,257 @@ func runAlertsConfig(appName string) error {
 	}
 	defer collector.Close()
 
-	config, err := getAlertConfig(collector, appName)
+	if update == (monitoring.MonitoringConfigUpdate{}) {
+		config, err := collector.GetMonitoringConfig(appName)
+		if err != nil {
+			return fmt.Errorf("failed to get alert configuration: %w", err)
+		}
+
+		displayAlertConfig(config, appName)
+		return nil
+	}
+
+	config, err := collector.UpdateMonitoringConfig(appName, update)
 	if err != nil {
-		return fmt.Errorf("failed to get alert configuration: %w", err)
+		return fmt.Errorf("failed to update alert configuration: %w", err)
 	}
 
+	fmt.Printf("✅ Alert configuration updated for %s\n\n", appName)
 	displayAlertConfig(config, appName)
 	return nil
 }
 
-func getAlerts(collector *monitoring.Collector, appName string, activeOnly bool) ([]AlertInfo, error) {
-	// This would normally query the database for alerts
-	// For now, return sample data
-	
+// alertConfigUpdateFromFlags returns the configuration changes requested with flags
+func alertConfigUpdateFromFlags(cmd *cobra.Command) (monitoring.MonitoringConfigUpdate, error) {
+	var update monitoring.MonitoringConfigUpdate
+	flags := cmd.Flags()
+
+	if flags.Changed("cpu") {
+		value, _ := flags.GetFloat64("cpu")
+		update.CPUThreshold = &value
+	}
+	if flags.Changed("memory") {
+		value, _ := flags.GetFloat64("memory")
+		update.MemoryThreshold = &value
+	}
+	if flags.Changed("error-rate") {
+		value, _ := flags.GetFloat64("error-rate")
+		update.ErrorRateThreshold = &value
+	}
+	if flags.Changed("response-time") {
+		value, _ := flags.GetInt("response-time")
+		update.ResponseTimeThreshold = &value
+	}
+	if flags.Changed("health-path") {
+		value, _ := flags.GetString("health-path")
+		update.HealthCheckPath = &value
+	}
+	if flags.Changed("interval") {
+		interval, _ := flags.GetDuration("interval")
+		if interval < time.Second {
+			return update, fmt.Errorf("--interval must be at least 1s")
+		}
+		seconds := int(interval / time.Second)
+		update.HealthCheckInterval = &seconds
+	}
+	if flags.Changed("metrics") {
+		value, _ := flags.GetBool("metrics")
+		update.MetricsEnabled = &value
+	}
+	if flags.Changed("metrics-path") {
+		value, _ := flags.GetString("metrics-path")
+		update.MetricsPath = &value
+	}
+	if flags.Changed("metrics-port") {
+		value, _ := flags.GetInt("metrics-port")
+		update.MetricsPort = &value
+	}
+
+	return update, nil
+}
+
+func runAlertsChannels(cmd *cobra.Command, action, channelName string) error {
+	// Initialize monitoring collector
+	collector, err := monitoring.NewCollector()
+	if err != nil {
+		return fmt.Errorf("failed to initialize monitoring: %w", err)
+	}
+	defer collector.Close()
+
+	notifier := collector.Notifier()
+
+	switch action {
+	case "list":
+		channels, err := notifier.ListChannels()
+		if err != nil {
+			return err
+		}
+		displayNotificationChannels(channels)
+	case "add":
+		if channelName == "" {
+			return fmt.Errorf("channel name required")
+		}
+		channel := notificationChannelFromFlags(cmd, channelName)
+		if err := notifier.AddChannel(channel); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Notification channel %s added\n", channelName)
+		fmt.Printf("💡 Use 'shipyard alerts test %s' to send a sample notification\n", channelName)
+	case "remove":
+		if channelName == "" {
+			return fmt.Errorf("channel name required")
+		}
+		if err := notifier.RemoveChannel(channelName); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Notification channel %s removed\n", channelName)
+	default:
+		return fmt.Errorf("unknown channels command: %s (use list, add or remove)", action)
+	}
+
+	return nil
+}
+
+// notificationChannelFromFlags builds a notification channel from the channels add flags
+func notificationChannelFromFlags(cmd *cobra.Command, name string) monitoring.NotificationChannel {
+	flags := cmd.Flags()
+	channelType, _ := flags.GetString("type")
+	minSeverity, _ := flags.GetString("min-severity")
+	appName, _ := flags.GetString("app")
+
+	channel := monitoring.NotificationChannel{
+		Name:        name,
+		Type:        monitoring.ChannelType(channelType),
+		AppName:     appName,
+		MinSeverity: monitoring.AlertSeverity(minSeverity),
+	}
+
+	if channel.Type == monitoring.ChannelTypeEmail {
+		channel.Target, _ = flags.GetString("to")
+		channel.SMTPHost, _ = flags.GetString("smtp-host")
+		channel.SMTPPort, _ = flags.GetInt("smtp-port")
+		channel.SMTPUsername, _ = flags.GetString("smtp-user")
+		channel.SMTPPassword, _ = flags.GetString("smtp-password")
+		channel.SMTPFrom, _ = flags.GetString("from")
+	} else {
+		channel.Target, _ = flags.GetString("url")
+	}
+
+	return channel
+}
+
+func runAlertsTest(channelName string) error {
+	// Initialize monitoring collector
+	collector, err := monitoring.NewCollector()
+	if err != nil {
+		return fmt.Errorf("failed to initialize monitoring: %w", err)
+	}
+	defer collector.Close()
+
+	notifier := collector.Notifier()
+
+	var channels []monitoring.NotificationChannel
+	if channelName != "" {
+		channel, err := notifier.GetChannel(channelName)
+		if err != nil {
+			return err
+		}
+		channels = append(channels, *channel)
+	} else {
+		channels, err = notifier.ListChannels()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(channels) == 0 {
+		fmt.Println("🔔 No notification channels configured")
+		fmt.Println("💡 Use 'shipyard alerts channels add <name> --type webhook --url <url>' to add one")
+		return nil
+	}
+
+	// Test notifications ignore the severity filters
 	now := time.Now()
-	alerts := []AlertInfo{
-		{
-			ID:           1,
-			AppName:      "web-app",
-			Type:         "cpu_high",
-			Severity:     "warning",
-			Status:       "active",
-			Message:      "CPU usage 85.2% exceeds threshold 80.0%",
+	notification := monitoring.Notification{
+		Event:   monitoring.NotificationTest,
+		AppName: "shipyard",
+		Alert: monitoring.Alert{
+			Type:         "test",
 			Threshold:    80.0,
-			CurrentValue: 85.2,
-			CreatedAt:    now.Add(-2 * time.Hour),
-			Duration:     2 * time.Hour,
-		},
-		{
-			ID:           2,
-			AppName:      "api-service",
-			Type:         "memory_high",
-			Severity:     "critical",
-			Status:       "active",
-			Message:      "Memory usage 612MB exceeds threshold 500MB",
-			Threshold:    500.0,
-			CurrentValue: 612.0,
-			CreatedAt:    now.Add(-45 * time.Minute),
-			Duration:     45 * time.Minute,
-		},
-		{
-			ID:           3,
-			AppName:      "api-service",
-			Type:         "response_time_high",
-			Severity:     "warning",
-			Status:       "active",
-			Message:      "Average response time 1250ms exceeds threshold 1000ms",
-			Threshold:    1000.0,
-			CurrentValue: 1250.0,
-			CreatedAt:    now.Add(-30 * time.Minute),
-			Duration:     30 * time.Minute,
-		},
-		{
-			ID:           4,
-			AppName:      "worker",
-			Type:         "pod_restart",
-			Severity:     "warning",
-			Status:       "resolved",
-			Message:      "Pod restarted 3 times in the last hour",
-			Threshold:    2.0,
-			CurrentValue: 3.0,
-			CreatedAt:    now.Add(-3 * time.Hour),
-			ResolvedAt:   &[]time.Time{now.Add(-1 * time.Hour)}[0],
-			Duration:     2 * time.Hour,
+			CurrentValue: 85.0,
+			Severity:     monitoring.AlertSeverityWarning,
+			Status:       monitoring.AlertStatusActive,
+			Message:      "This is a test notification from Shipyard",
+			CreatedAt:    now,
 		},
+		SentAt: now,
 	}
 
-	// Filter by app name if specified
-	if appName != "" {
-		var filtered []AlertInfo
-		for _, alert := range alerts {
-			if alert.AppName == appName {
-				filtered = append(filtered, alert)
-			}
+	failed := 0
+	for _, channel := range channels {
+		if err := notifier.Send(channel, notification); err != nil {
+			fmt.Printf("❌ %s (%s): %v\n", channel.Name, channel.Type, err)
+			failed++
+			continue
 		}
-		alerts = filtered
+		fmt.Printf("✅ %s (%s): test notification sent\n", channel.Name, channel.Type)
 	}
 
-	// Filter by status if activeOnly
-	if activeOnly {
-		var filtered []AlertInfo
-		for _, alert := range alerts {
-			if alert.Status == "active" {
-				filtered = append(filtered, alert)
-			}
-		}
-		alerts = filtered
+	if failed > 0 {
+		return fmt.Errorf("%d of %d channels failed", failed, len(channels))
 	}
-
-	return alerts, nil
+	return nil
 }
 
-func getAlertsHistory(collector *monitoring.Collector, appName string, period time.Duration) ([]AlertInfo, error) {
-	// This would normally query the database for historical alerts
-	// For now, return sample data by calling getAlerts and filtering by time
-	
-	alerts, err := getAlerts(collector, appName, false)
+func getAlerts(collector *monitoring.Collector, appName string, activeOnly bool) ([]AlertInfo, error) {
+	alerts, err := collector.ListAlerts(appName, activeOnly)
 	if err != nil {
 		return nil, err
 	}
 
-	// Filter by period
-	cutoff := time.Now().Add(-period)
-	var filtered []AlertInfo
-	for _, alert := range alerts {
-		if alert.CreatedAt.After(cutoff) {
-			filtered = append(filtered, alert)
-		}
+	return toAlertInfos(alerts), nil
+}
+
+func getAlertsHistory(collector *monitoring.Collector, appName string, period time.Duration) ([]AlertInfo, error) {
+	alerts, err := collector.GetAlertsHistory(appName, period)
+	if err != nil {
+		return nil, err
 	}
 
-	return filtered, nil
+	return toAlertInfos(alerts), nil
 }
 
 func resolveAlert(collector *monitoring.Collector, alertID int64) error {
-	// This would normally update the database to resolve the alert
-	// For now, just simulate success
-	fmt.Printf("Resolving alert ID %d...\n", alertID)
-	time.Sleep(500 * time.Millisecond) // Simulate processing
-	return nil
+	return collector.ResolveAlertByID(alertID)
 }
 
-// AlertConfig represents alert configuration
-type AlertConfig struct {
-	AppName                string
-	CPUThreshold           float64
-	MemoryThreshold        float64
-	ResponseTimeThreshold  int
-	ErrorRateThreshold     float64
-	HealthCheckEnabled     bool
-	HealthCheckInterval    int
-	NotificationEnabled    bool
-	NotificationChannels   []string
-}
+// toAlertInfos converts stored alerts to AlertInfo for display
+func toAlertInfos(alerts []monitoring.AppAlert) []AlertInfo {
+	now := time.Now()
+	infos := make([]AlertInfo, 0, len(alerts))
+	for _, alert := range alerts {
+		end := now
+		if alert.ResolvedAt != nil {
+			end = *alert.ResolvedAt
+		}
 
-func getAlertConfig(collector *monitoring.Collector, appName string) (*AlertConfig, error) {
-	// This would normally query the database for alert configuration
-	// For now, return sample data
-	
-	return &AlertConfig{
-		AppName:                appName,
-		CPUThreshold:           80.0,
-		MemoryThreshold:        85.0,
-		ResponseTimeThreshold:  1000,
-		ErrorRateThreshold:     5.0,
-		HealthCheckEnabled:     true,
-		HealthCheckInterval:    30,
-		NotificationEnabled:    false,
-		NotificationChannels:   []string{"email", "slack"},
-	}, nil
+		infos = append(infos, AlertInfo{
+			ID:              alert.ID,
+			AppName:         alert.AppName,
+			Type:            alert.Type,
+			Severity:        string(alert.Severity),
+			Status:          string(alert.Status),
+			Message:         alert.Message,
+			Threshold:       alert.Threshold,
+			CurrentValue:    alert.CurrentValue,
+			CreatedAt:       alert.CreatedAt,
+			ResolvedAt:      alert.ResolvedAt,
+			AcknowledgedAt:  alert.AcknowledgedAt,
+			SuppressedUntil: alert.SuppressedUntil,
+			Duration:        end.Sub(alert.CreatedAt),
+		})
+	}
+	return infos
 }
 
 func displayAlertsTable(alerts []AlertInfo, appName string, activeOnly bool) {
@@ -361,10 +589,7 @@ func displayAlertsTable(alerts []AlertInfo, appName string, activeOnly bool) {
 			severityIcon = "🔵"
 		}
 
-		statusIcon := "🟡"
-		if alert.Status == "resolved" {
-			statusIcon = "✅"
-		}
+		statusIcon := alertStatusIcon(alert)
 
 		durationStr := formatDuration(alert.Duration)
 
@@ -387,6 +612,7 @@ func displayAlertsTable(alerts []AlertInfo, appName string, activeOnly bool) {
 	// Summary
 	activeCount := 0
 	criticalCount := 0
+	silencedCount := 0
 	for _, alert := range alerts {
 		if alert.Status == "active" {
 			activeCount++
@@ -394,6 +620,9 @@ func displayAlertsTable(alerts []AlertInfo, appName string, activeOnly bool) {
 				criticalCount++
 			}
 		}
+		if alert.Status == "suppressed" {
+			silencedCount++
+		}
 	}
 
 	fmt.Printf("\n📊 Summary: %d total alerts", len(alerts))
@@ -403,10 +632,27 @@ func displayAlertsTable(alerts []AlertInfo, appName string, activeOnly bool) {
 			fmt.Printf(" (%d critical)", criticalCount)
 		}
 	}
+	if silencedCount > 0 {
+		fmt.Printf(", %d silenced", silencedCount)
+	}
 	fmt.Println()
 	fmt.Printf("💡 Tip: Use 'shipyard alerts resolve <id>' to resolve alerts\n")
 }
 
+// alertStatusIcon returns the icon for the status of an alert
+func alertStatusIcon(alert AlertInfo) string {
+	switch {
+	case alert.Status == "resolved":
+		return "✅"
+	case alert.Status == "suppressed":
+		return "🔇"
+	case alert.AcknowledgedAt != nil:
+		return "👀"
+	default:
+		return "🟡"
+	}
+}
+
 func displayAlertsHistory(alerts []AlertInfo, appName string, period time.Duration) {
 	if len(alerts) == 0 {
 		fmt.Printf("🚨 No alerts found in the last %v", period)
@@ -439,10 +685,7 @@ func displayAlertsHistory(alerts []AlertInfo, appName string, period time.Durati
 		fmt.Println(strings.Repeat("─", 50))
 		
 		for _, alert := range dayAlerts {
-			statusIcon := "🟡"
-			if alert.Status == "resolved" {
-				statusIcon = "✅"
-			}
+			statusIcon := alertStatusIcon(alert)
 			
 			severityIcon := "⚠️"
 			if alert.Severity == "critical" {
@@ -481,7 +724,7 @@ func displayAlertsHistory(alerts []AlertInfo, appName string, period time.Durati
 		totalAlerts, resolvedAlerts, float64(resolvedAlerts)/float64(totalAlerts)*100, criticalAlerts)
 }
 
-func displayAlertConfig(config *AlertConfig, appName string) {
+func displayAlertConfig(config *monitoring.MonitoringConfig, appName string) {
 	fmt.Printf("⚙️ Alert Configuration for %s\n", appName)
 	fmt.Println("=" + fmt.Sprintf("%*s", len(appName)+23, ""))
 	fmt.Println()
@@ -494,16 +737,46 @@ func displayAlertConfig(config *AlertConfig, appName string) {
 	fmt.Println()
 
 	fmt.Printf("Health Checks:\n")
-	fmt.Printf("  Enabled:         %t\n", config.HealthCheckEnabled)
+	fmt.Printf("  Enabled:         %t\n", config.Enabled)
+	fmt.Printf("  Path:            %s\n", config.HealthCheckPath)
 	fmt.Printf("  Interval:        %ds\n", config.HealthCheckInterval)
+	fmt.Printf("  Timeout:         %ds\n", config.HealthCheckTimeout)
 	fmt.Println()
 
-	fmt.Printf("Notifications:\n")
-	fmt.Printf("  Enabled:         %t\n", config.NotificationEnabled)
-	fmt.Printf("  Channels:        %s\n", strings.Join(config.NotificationChannels, ", "))
+	fmt.Printf("Request Metrics:\n")
+	fmt.Printf("  Enabled:         %t\n", config.MetricsEnabled)
+	fmt.Printf("  Endpoint:        :%d%s\n", config.MetricsPort, config.MetricsPath)
 	fmt.Println()
 
-	fmt.Printf("💡 Use 'shipyard config edit %s' to modify these settings\n", appName)
+	fmt.Printf("💡 Use 'shipyard alerts config %s --cpu 75 --interval 15s' or the monitoring block of paas.yaml to modify these settings\n", appName)
+}
+
+func displayNotificationChannels(channels []monitoring.NotificationChannel) {
+	if len(channels) == 0 {
+		fmt.Println("🔔 No notification channels configured")
+		fmt.Println("💡 Use 'shipyard alerts channels add <name> --type webhook --url <url>' to add one")
+		return
+	}
+
+	fmt.Println("🔔 Notification Channels")
+	fmt.Println("========================")
+	fmt.Println()
+
+	for _, channel := range channels {
+		scope := "all apps"
+		if channel.AppName != "" {
+			scope = channel.AppName
+		}
+		fmt.Printf("  %-16s %-8s %-10s %-12s %s\n",
+			truncateString(channel.Name, 16),
+			channel.Type,
+			channel.MinSeverity+"+",
+			truncateString(scope, 12),
+			channel.RedactedTarget(),
+		)
+	}
+	fmt.Println()
+	fmt.Printf("💡 Use 'shipyard alerts test' to send a sample notification to every channel\n")
 }
 
 func formatDuration(d time.Duration) string {
@@ -12,12 +12,14 @@ import (
 	"github.com/shipyard/cli/pkg/config"
 	"github.com/shipyard/cli/pkg/domains"
 	"github.com/shipyard/cli/pkg/manifests"
+	corev1 "k8s.io/api/core/v1"
 )
 
 var (
 	deleteAll     bool
 	forceDelete   bool
 	confirmDelete bool
+	deleteVolumes bool
 )
 
 var deleteCmd = &cobra.Command{
@@ -28,11 +30,16 @@ var deleteCmd = &cobra.Command{
 - Local manifest files
 - Database entries
 
+Persistent volumes are only deleted after a separate confirmation. With
+--force or --yes they are kept, with the namespace holding them, unless
+--delete-volumes is given.
+
 Examples:
   shipyard delete                    # Delete current app (from paas.yaml)
   shipyard delete hello-world       # Delete specific app
   shipyard delete --all             # Delete all apps
-  shipyard delete --force           # Skip confirmation prompts`,
+  shipyard delete --force           # Skip confirmation prompts, keep volumes
+  shipyard delete --yes --delete-volumes   # Also delete volumes and their data`,
 	Args: cobra.MaximumNArgs(1),
 	Run:  func(cmd *cobra.Command, args []string) {
 		runDelete(args)
@@ -44,6 +51,7 @@ func init() {
 	deleteCmd.Flags().BoolVar(&deleteAll, "all", false, "Delete all applications")
 	deleteCmd.Flags().BoolVar(&forceDelete, "force", false, "Force deletion without confirmation")
 	deleteCmd.Flags().BoolVar(&confirmDelete, "yes", false, "Automatically confirm deletion")
+	deleteCmd.Flags().BoolVar(&deleteVolumes, "delete-volumes", false, "Delete persistent volumes and their data without asking")
 }
 
 func runDelete(args []string) {
@@ -84,13 +92,16 @@ func deleteApp(appName string) error {
 		}
 	}
 
+	// Persistent volumes hold data that cannot be recovered: ask separately
+	removeVolumes := confirmVolumeDeletion(appName)
+
 	// Initialize version manager to get database connection
 	vm := manifests.NewVersionManager(appName)
 	defer vm.Close()
 
 	// Delete Kubernetes resources (including ingress)
 	fmt.Printf("☸️  Deleting Kubernetes resources for %s...\n", appName)
-	if err := deleteKubernetesResources(appName); err != nil {
+	if err := deleteKubernetesResources(appName, removeVolumes); err != nil {
 		fmt.Printf("⚠️  Warning: Failed to delete some Kubernetes resources: %v\n", err)
 	}
 
@@ -172,6 +183,7 @@ func deleteAllApps() error {
 func confirmDeletion(appName string) bool {
 	fmt.Printf("⚠️  This will permanently delete the application '%s' and all its resources:\n", appName)
 	fmt.Println("   - Kubernetes deployment, service, ingress, secrets")
+	fmt.Println("   - Persistent volumes, after a separate confirmation")
 	fmt.Println("   - Local manifest files")
 	fmt.Println("   - Database entries and deployment history")
 	fmt.Print("\nAre you sure you want to continue? [y/N]: ")
@@ -183,7 +195,60 @@ func confirmDeletion(appName string) bool {
 	return response == "y" || response == "yes"
 }
 
-func deleteKubernetesResources(appName string) error {
+// confirmVolumeDeletion lists the persistent volumes of an app and reports
+// whether they should be deleted
+func confirmVolumeDeletion(appName string) bool {
+	app := manifests.AppConfig{Name: appName}
+	namespace := appName // TODO: Add DNS validation warning if needed
+
+	client, err := manifests.CreateK8sClient()
+	var claims []corev1.PersistentVolumeClaim
+	if err == nil {
+		claims, err = client.ListVolumeClaims(namespace, fmt.Sprintf("shipyard.app=%s", app.GetDNSName()))
+	}
+	if err != nil {
+		// Without the cluster, rely on the claims declared in the manifests
+		appsDir, dirErr := config.GetAppsDir()
+		if dirErr != nil {
+			return false
+		}
+		if _, statErr := os.Stat(filepath.Join(appsDir, appName, manifests.VolumeClaimsFile)); os.IsNotExist(statErr) {
+			return true
+		}
+		fmt.Printf("⚠️  Warning: Failed to list persistent volumes, they will be kept: %v\n", err)
+		return false
+	}
+	if len(claims) == 0 {
+		return true
+	}
+
+	fmt.Printf("💾 %s has %d persistent volume(s):\n", appName, len(claims))
+	for _, claim := range claims {
+		size := claim.Spec.Resources.Requests.Storage()
+		fmt.Printf("   - %s (%s)\n", claim.Name, size.String())
+	}
+
+	if deleteVolumes {
+		return true
+	}
+	if forceDelete || confirmDelete {
+		fmt.Println("💾 Keeping volumes and namespace (use --delete-volumes to delete them)")
+		return false
+	}
+
+	fmt.Print("\nDelete these volumes and all their data? [y/N]: ")
+	reader := bufio.NewReader(os.Stdin)
+	response, _ := reader.ReadString('\n')
+	response = strings.TrimSpace(strings.ToLower(response))
+
+	if response == "y" || response == "yes" {
+		return true
+	}
+	fmt.Println("💾 Keeping volumes and namespace")
+	return false
+}
+
+func deleteKubernetesResources(appName string, removeVolumes bool) error {
 	// Create a Kubernetes client
 	client, err := manifests.CreateK8sClient()
 	if err != nil {
@@ -204,10 +269,16 @@ func deleteKubernetesResources(appName string) error {
 	}
 
 	// Delete app resources
-	if err := manifests.DeleteManifestsFromDirectory(client, manifestDir); err != nil {
+	if err := manifests.DeleteManifestsFromDirectory(client, manifestDir, !removeVolumes); err != nil {
 		return fmt.Errorf("failed to delete app resources: %w", err)
 	}
 
+	// Deleting the namespace would delete the volumes it holds
+	if !removeVolumes {
+		fmt.Printf("💾 Keeping namespace %s with the persistent volumes\n", appName)
+		return nil
+	}
+
 	// Also delete the namespace if it exists
 	namespaceName := appName // TODO: Add DNS validation warning if needed
 	fmt.Printf("🗑️  Deleting namespace: %s\n", namespaceName)
@@ -2,14 +2,17 @@ package cmd
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/shipyard/cli/pkg/manifests"
 	"github.com/shipyard/cli/pkg/k8s"
+	"github.com/shipyard/cli/pkg/monitoring"
 	versionpkg "github.com/shipyard/cli/pkg/version"
 )
 
@@ -24,31 +27,98 @@ This will create:
 - A service.yaml for internal load balancing
 - Update shared ingress files for domains
 
-You'll be prompted to select which registry secrets to use.`,
+When paas.yaml has a release command, it runs as a Kubernetes Job with the
+new image and config before anything else is applied. If it fails, the
+deployment is marked failed and the running version is left untouched.
+
+With --env, the environments.<env> block of paas.yaml and the
+paas.<env>.yaml file are merged over the base config. Each environment is
+deployed as its own app, <app>-<env>, with its own namespace, manifests and
+release history.
+
+You'll be prompted to select which registry secrets to use.
+
+Manifests are applied with server-side apply, as the shipyard field manager:
+fields set by other tools, such as the replicas of an autoscaler, are left
+alone. A deploy changing a field another tool manages fails and lists the
+conflicts; --force-conflicts takes those fields over.
+
+The deploy then waits for every deployment of the app to roll out, up to
+--timeout. Pods that cannot start, because their image cannot be pulled or
+they keep crashing, fail it early; the reason is recorded in the release
+history.
+
+With --dry-run, the manifests are printed instead, as by shipyard render:
+nothing is applied and no release is recorded.
+
+Examples:
+  shipyard deploy                 # Deploy paas.yaml
+  shipyard deploy --env staging   # Deploy the staging environment
+  shipyard deploy --dry-run       # Print the manifests without deploying`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := runDeploy(); err != nil {
+		env, _ := cmd.Flags().GetString("env")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if dryRun {
+			if err := runRender(env, ""); err != nil {
+				log.Fatalf("Deploy failed: %v", err)
+			}
+			return
+		}
+
+		if err := runDeploy(env); err != nil {
 			log.Fatalf("Deploy failed: %v", err)
 		}
 	},
 }
 
-func runDeploy() error {
+// forceConflicts makes apply take over the fields other tools manage
+var forceConflicts bool
+
+// prune deletes the objects of the app that the deploy no longer renders
+var prune bool
+
+// rolloutTimeout is how long to wait for the deployments of the app to roll out
+var rolloutTimeout time.Duration
+
+func init() {
+	deployCmd.Flags().String("env", "", "Environment to deploy, merged over paas.yaml (e.g. staging)")
+	deployCmd.Flags().Bool("dry-run", false, "Print the manifests without applying them or recording a release")
+	deployCmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false, "Take over the fields other tools manage instead of failing")
+	deployCmd.Flags().BoolVar(&prune, "prune", true, "Delete the objects of the app that are no longer in its manifests")
+	deployCmd.Flags().DurationVar(&rolloutTimeout, "timeout", k8s.DefaultRolloutTimeout, "How long to wait for the deployments of the app to roll out")
+}
+
+func runDeploy(env string) error {
 	// Check for updates (non-blocking)
 	go versionpkg.NotifyIfUpdateAvailable(versionpkg.Current)
 	
 	fmt.Println("🚀 Starting deployment...")
 
 	// 1. Parse paas.yaml configuration
-	config, err := manifests.LoadConfig("paas.yaml")
+	config, err := manifests.LoadConfigForEnv("paas.yaml", env)
 	if err != nil {
 		return fmt.Errorf("failed to load paas.yaml: %w", err)
 	}
+	if env != "" {
+		fmt.Printf("🌍 Environment: %s (app %s, namespace %s)\n", env, config.App.Name, config.App.GetNamespace())
+	}
 
-	// 1.5. Validate DNS names and ask for confirmation if needed
+	// 1.5. Validate the config, see shipyard validate, then ask for
+	// confirmation if names need DNS normalization
+	if err := manifests.ValidationErrors(config.Validate()); err != nil {
+		return fmt.Errorf("invalid paas.yaml: %w", err)
+	}
 	if err := validateAndConfirmDNSNames(config); err != nil {
 		return fmt.Errorf("DNS validation failed: %w", err)
 	}
 
+	// 1.6. Check the monitoring block before deploying
+	monitoringUpdate, err := monitoring.NewMonitoringConfigUpdate(config.Monitoring)
+	if err != nil {
+		return fmt.Errorf("invalid monitoring configuration: %w", err)
+	}
+
 	// 2. Create version manager and generate new version
 	versionManager := manifests.NewVersionManager(config.App.Name)
 	deployVersion, err := versionManager.GenerateVersion(config)
@@ -61,6 +131,41 @@ func runDeploy() error {
 		return fmt.Errorf("failed to save version: %w", err)
 	}
 
+	// 3-5. Generate and apply the manifests
+	if err := applyDeployment(config, versionManager, deployVersion, monitoringUpdate); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Deployment successful!\n")
+	fmt.Printf("   App: %s\n", config.App.Name)
+	fmt.Printf("   Version: %s\n", deployVersion.Version)
+	fmt.Printf("   Image: %s\n", config.App.Image)
+	
+	// Offer to show logs
+	fmt.Printf("\n💡 To follow logs, run: shipyard logs %s -f\n", config.App.Name)
+	fmt.Printf("💡 To check status, run: shipyard status\n")
+	if env != "" {
+		fmt.Printf("💡 To list releases, run: shipyard releases --env %s\n", env)
+	}
+	
+	return nil
+}
+
+// failureReason is the error recorded for a failed deployment: the reason
+// the rollout failed when it did, such as "ImagePullBackOff: deployment
+// shop/shop, container shop of pod shop-6d4cf56db6-x2x9q: ..."
+func failureReason(err error) string {
+	var rolloutErr *k8s.RolloutError
+	if errors.As(err, &rolloutErr) {
+		return rolloutErr.Error()
+	}
+	return err.Error()
+}
+
+// applyDeployment generates and applies the manifests of a saved deployment
+// version, running the release command first, marks the version successful
+// or failed, then syncs the monitoring block and CI/CD manifests
+func applyDeployment(config *manifests.Config, versionManager *manifests.VersionManager, deployVersion *manifests.DeploymentVersion, monitoringUpdate monitoring.MonitoringConfigUpdate) error {
 	// 3. Generate manifests for the application with version tracking
 	generator := manifests.NewGeneratorWithVersion(config, deployVersion)
 	
@@ -88,15 +193,39 @@ func runDeploy() error {
 		versionManager.UpdateVersionStatus(deployVersion.Version, "failed")
 		return fmt.Errorf("failed to create k8s client: %w", err)
 	}
+	client.SetForceConflicts(forceConflicts)
+	client.SetRolloutTimeout(rolloutTimeout)
 
-	fmt.Printf("🔧 Applying manifests for %s...\n", config.App.Name)
-	if err := client.ApplyManifestsWithNamespace(config.App.Name, config.App.GetNamespace()); err != nil {
-		// Mark deployment as failed
+	// 5.5. Run the release command before the new version goes live
+	release, err := config.GetRelease()
+	if err != nil {
 		versionManager.UpdateVersionStatus(deployVersion.Version, "failed")
+		return fmt.Errorf("invalid release configuration: %w", err)
+	}
+	if release != nil {
+		fmt.Printf("🏗️  Running release command: %s\n", strings.Join(release.Command, " "))
+		jobName := manifests.ReleaseJobName(config, deployVersion)
+		if err := client.RunRelease(config.App.Name, config.App.GetNamespace(), jobName, release.Timeout()); err != nil {
+			// Mark deployment as failed, the running version was not touched
+			if updateErr := versionManager.UpdateVersionError(deployVersion.Version, err.Error()); updateErr != nil {
+				fmt.Printf("⚠️  Warning: failed to update version status: %v\n", updateErr)
+			}
+			fmt.Printf("↩️  The previous version of %s is still running\n", config.App.Name)
+			return fmt.Errorf("release phase failed: %w", err)
+		}
+		fmt.Println("✅ Release command succeeded")
+	}
+
+	fmt.Printf("🔧 Applying manifests for %s...\n", config.App.Name)
+	if err := client.ApplyManifestsWithNamespace(config.App.Name, config.App.GetNamespace(), deployVersion.Version); err != nil {
+		// Mark deployment as failed, with the reason
+		if updateErr := versionManager.UpdateVersionError(deployVersion.Version, failureReason(err)); updateErr != nil {
+			fmt.Printf("⚠️  Warning: failed to update version status: %v\n", updateErr)
+		}
 		
 		// Try to get some diagnostic information
 		fmt.Println("\n🔍 Diagnostic information:")
-		if pods, podErr := client.GetPods(config.App.Name); podErr == nil {
+		if pods, podErr := client.GetPods(config.App.Name, config.App.GetNamespace()); podErr == nil {
 			for _, pod := range pods {
 				fmt.Printf("   Pod %s: %s\n", pod.Name, pod.Status.Phase)
 				if pod.Status.Phase == "Failed" || pod.Status.Phase == "Pending" {
@@ -117,6 +246,23 @@ func runDeploy() error {
 		fmt.Printf("⚠️  Warning: failed to update version status: %v\n", err)
 	}
 
+	// Delete what the previous versions applied and this one no longer renders
+	if prune {
+		fmt.Printf("🧹 Pruning resources %s no longer uses...\n", config.App.Name)
+		if err := client.PruneApp(config.App.Name, config.App.GetNamespace(), deployVersion.Version); err != nil {
+			fmt.Printf("⚠️  Warning: failed to prune resources: %v\n", err)
+		}
+	}
+
+	// Sync the monitoring block of paas.yaml
+	if config.Monitoring != nil {
+		if err := syncMonitoringConfig(config.App.Name, monitoringUpdate); err != nil {
+			fmt.Printf("⚠️  Warning: failed to sync monitoring configuration: %v\n", err)
+		} else {
+			fmt.Printf("📈 Monitoring configuration synced from paas.yaml\n")
+		}
+	}
+
 	// Update deployment for CI/CD if enabled (after successful deployment)
 	if config.CICD.Enabled {
 		fmt.Printf("🔄 Updating deployment for CI/CD mode...\n")
@@ -127,34 +273,35 @@ func runDeploy() error {
 		}
 	}
 
-
-	fmt.Printf("✅ Deployment successful!\n")
-	fmt.Printf("   App: %s\n", config.App.Name)
-	fmt.Printf("   Version: %s\n", deployVersion.Version)
-	fmt.Printf("   Image: %s\n", config.App.Image)
-	
-	// Offer to show logs
-	fmt.Printf("\n💡 To follow logs, run: shipyard logs %s -f\n", config.App.Name)
-	fmt.Printf("💡 To check status, run: shipyard status\n")
-	
 	return nil
 }
 
+// syncMonitoringConfig stores the monitoring settings of paas.yaml for an app
+func syncMonitoringConfig(appName string, update monitoring.MonitoringConfigUpdate) error {
+	collector, err := monitoring.NewCollector()
+	if err != nil {
+		return fmt.Errorf("failed to initialize monitoring: %w", err)
+	}
+	defer collector.Close()
+
+	_, err = collector.UpdateMonitoringConfig(appName, update)
+	return err
+}
+
 // validateAndConfirmDNSNames checks if names need DNS normalization and asks for user confirmation
 func validateAndConfirmDNSNames(config *manifests.Config) error {
-	// Check if app name is DNS compliant
-	originalName := config.App.Name
-	// TODO: Add DNS validation instead of normalization
-	_ = originalName // prevent unused variable warning
-	
 	changes := []string{}
-	// DNS validation would go here
-	
+
+	// Check if app name is DNS compliant
+	if dnsName := config.App.GetDNSName(); dnsName != config.App.Name {
+		changes = append(changes, fmt.Sprintf("app.name: %s → %s", config.App.Name, dnsName))
+	}
+
 	// Check namespace if specified
 	if config.App.Namespace != "" {
-		originalNamespace := config.App.Namespace
-		// TODO: Add DNS validation for namespace
-		_ = originalNamespace // prevent unused variable warning
+		if namespace := config.App.GetNamespace(); namespace != config.App.Namespace {
+			changes = append(changes, fmt.Sprintf("app.namespace: %s → %s", config.App.Namespace, namespace))
+		}
 	}
 	
 	// If no changes needed, continue
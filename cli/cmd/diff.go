@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	"github.com/shipyard/cli/pkg/k8s"
+	"github.com/shipyard/cli/pkg/manifests"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show what a deploy would change in the cluster",
+	Long: `Compare the manifests of paas.yaml with the objects live in the cluster.
+
+The manifests are rendered as by shipyard render, then every object is
+applied with a server-side dry run, so that both sides have the cluster
+defaults and the fields other tools manage. Fields managed by the cluster
+(status, uid, resourceVersion, managed fields...) and the version labels
+are left out. The values of secrets are never printed: they show as ***
+and as *** (before) and *** (after) when they change.
+
+The objects of the app that a deploy would prune, because they are no
+longer in the manifests, are listed as well. Use --prune=false to leave
+them out, as for a deploy with --prune=false.
+
+Nothing is applied and nothing is recorded in the database.
+
+The command exits with status 0 when there is no change, 1 when something
+would change and 2 on errors, so it can gate pipelines.
+
+Examples:
+  shipyard diff                 # Diff paas.yaml against the cluster
+  shipyard diff --env staging   # Diff the staging environment`,
+	Run: func(cmd *cobra.Command, args []string) {
+		env, _ := cmd.Flags().GetString("env")
+
+		changed, err := runDiff(env)
+		if err != nil {
+			log.Printf("Diff failed: %v", err)
+			os.Exit(2)
+		}
+		if changed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	diffCmd.Flags().String("env", "", "Environment to diff, merged over paas.yaml (e.g. staging)")
+	diffCmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false, "Show the changes of a deploy with --force-conflicts")
+	diffCmd.Flags().BoolVar(&prune, "prune", true, "Show the objects a deploy would prune")
+}
+
+// runDiff prints the differences between the manifests of paas.yaml and the
+// cluster, and reports whether a deploy would change anything
+func runDiff(env string) (bool, error) {
+	config, err := manifests.LoadConfigForEnv("paas.yaml", env)
+	if err != nil {
+		return false, fmt.Errorf("failed to load paas.yaml: %w", err)
+	}
+	if err := manifests.ValidationErrors(config.Validate()); err != nil {
+		return false, fmt.Errorf("invalid paas.yaml: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "shipyard-diff-")
+	if err != nil {
+		return false, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Secrets are rendered with their values to compare them, and masked in the diff
+	if err := renderManifestsQuietly(config, dir, false); err != nil {
+		return false, err
+	}
+
+	client, err := k8s.NewClient()
+	if err != nil {
+		return false, fmt.Errorf("failed to create k8s client: %w", err)
+	}
+	client.SetForceConflicts(forceConflicts)
+
+	// Compare the manifests applied by deploy, shared ones first
+	diffs, err := client.DiffManifestsInDir(filepath.Join(dir, "shared"), nil)
+	if err != nil {
+		return false, err
+	}
+	appDiffs, err := client.DiffManifestsInDir(filepath.Join(dir, "apps", config.App.Name), k8s.OwnerLabels(config.App.Name, config.App.GetNamespace(), ""))
+	if err != nil {
+		return false, err
+	}
+	diffs = append(diffs, appDiffs...)
+
+	if prune {
+		pruned, err := client.DiffPrunedObjects(config.App.Name, config.App.GetNamespace(), appDiffs)
+		if err != nil {
+			return false, err
+		}
+		diffs = append(diffs, pruned...)
+	}
+
+	color := isTerminal(os.Stdout)
+	changed := 0
+	for _, diff := range diffs {
+		if !diff.Changed() {
+			continue
+		}
+		changed++
+		if err := printResourceDiff(diff, color); err != nil {
+			return false, err
+		}
+	}
+
+	if changed == 0 {
+		fmt.Printf("✅ No changes: the %d resources of %s match the cluster\n", len(diffs), config.App.Name)
+		return false, nil
+	}
+	fmt.Printf("📝 %d of %d resources of %s would change\n", changed, len(diffs), config.App.Name)
+	return true, nil
+}
+
+// printResourceDiff prints the unified diff of a resource
+func printResourceDiff(diff k8s.ResourceDiff, color bool) error {
+	name := diff.Name
+	if diff.Namespace != "" {
+		name = diff.Namespace + "/" + diff.Name
+	}
+	if diff.Live == "" {
+		fmt.Printf("➕ %s %s would be created\n", diff.Kind, name)
+	} else if diff.Desired == "" {
+		fmt.Printf("🗑️  %s %s would be pruned\n", diff.Kind, name)
+	} else {
+		fmt.Printf("📝 %s %s would change\n", diff.Kind, name)
+	}
+
+	text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        diffLines(diff.Live),
+		B:        diffLines(diff.Desired),
+		FromFile: "live/" + diff.Kind + "/" + name,
+		ToFile:   "desired/" + diff.Kind + "/" + name,
+		Context:  3,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to diff %s %s: %w", diff.Kind, name, err)
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line == "" {
+			continue
+		}
+		fmt.Print(colorDiffLine(line, color))
+	}
+	fmt.Println()
+	return nil
+}
+
+// diffLines splits a text into lines, keeping their line breaks
+func diffLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// colorDiffLine colors a line of a unified diff for a terminal
+func colorDiffLine(line string, color bool) string {
+	if !color {
+		return line
+	}
+
+	text := strings.TrimSuffix(line, "\n")
+	switch {
+	case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+		return "\033[1m" + text + "\033[0m\n"
+	case strings.HasPrefix(line, "@@"):
+		return "\033[36m" + text + "\033[0m\n"
+	case strings.HasPrefix(line, "-"):
+		return "\033[31m" + text + "\033[0m\n"
+	case strings.HasPrefix(line, "+"):
+		return "\033[32m" + text + "\033[0m\n"
+	}
+	return line
+}
+
+// isTerminal reports whether a file is a terminal
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -3,6 +3,10 @@ package cmd
 import (
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -14,11 +18,14 @@ var eventsCmd = &cobra.Command{
 	Short: "Show application and cluster events",
 	Long: `Display recent events for applications and cluster resources.
 
+Kubernetes events of your apps are stored in the local database, so past
+events remain available after the cluster has expired them (about 1 hour).
+--follow watches the cluster and stores events as they happen.
+
 Events include:
 - Deployment updates
 - Pod state changes
 - Service modifications
-- Error conditions
 - Warning messages
 
 Examples:
@@ -26,7 +33,7 @@ Examples:
   shipyard events my-app              # Show events for specific app
   shipyard events --follow           # Stream events in real-time
   shipyard events --since 1h         # Show events from last hour
-  shipyard events --type error       # Show only error events`,
+  shipyard events --type warning     # Show only warning events`,
 	Run: func(cmd *cobra.Command, args []string) {
 		var appName string
 		if len(args) > 0 {
@@ -46,7 +53,7 @@ Examples:
 func init() {
 	eventsCmd.Flags().BoolP("follow", "f", false, "Stream events in real-time")
 	eventsCmd.Flags().DurationP("since", "s", time.Hour, "Show events since specified duration")
-	eventsCmd.Flags().StringP("type", "t", "", "Filter by event type (normal, warning, error)")
+	eventsCmd.Flags().StringP("type", "t", "", "Filter by event type (normal, warning)")
 }
 
 // EventInfo represents an application or cluster event
@@ -57,7 +64,6 @@ type EventInfo struct {
 	Type         string
 	Reason       string
 	Message      string
-	Source       string
 	Count        int
 	FirstSeen    time.Time
 	LastSeen     time.Time
@@ -94,170 +100,68 @@ func runEventsFollow(collector *monitoring.Collector, appName, eventType string)
 	}
 	fmt.Println()
 
-	lastEventTime := time.Now()
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			// Get new events since last check
-			newEvents, err := getEventsSince(collector, appName, lastEventTime, eventType)
-			if err != nil {
-				fmt.Printf("Error getting events: %v\n", err)
-				continue
-			}
-
-			// Display new events
-			for _, event := range newEvents {
-				displaySingleEvent(event)
-				if event.Timestamp.After(lastEventTime) {
-					lastEventTime = event.Timestamp
-				}
-			}
-		}
-	}
-}
+	// Handle Ctrl+C
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-func getEvents(collector *monitoring.Collector, appName string, since time.Duration, eventType string) ([]EventInfo, error) {
-	// This would normally query Kubernetes events and application events from the database
-	// For now, return sample data
-	
-	now := time.Now()
-	events := []EventInfo{
-		{
-			Timestamp: now.Add(-10 * time.Minute),
-			AppName:   "web-app",
-			Component: "deployment",
-			Type:      "normal",
-			Reason:    "ScalingReplicaSet",
-			Message:   "Scaled up replica set web-app-7d4b9c8f5c to 3",
-			Source:    "deployment-controller",
-			Count:     1,
-			FirstSeen: now.Add(-10 * time.Minute),
-			LastSeen:  now.Add(-10 * time.Minute),
-		},
-		{
-			Timestamp: now.Add(-15 * time.Minute),
-			AppName:   "api-service",
-			Component: "pod",
-			Type:      "warning",
-			Reason:    "BackOff",
-			Message:   "Back-off restarting failed container api-service in pod api-service-6b8f4d9c7a-xyz12",
-			Source:    "kubelet",
-			Count:     3,
-			FirstSeen: now.Add(-25 * time.Minute),
-			LastSeen:  now.Add(-15 * time.Minute),
-		},
-		{
-			Timestamp: now.Add(-20 * time.Minute),
-			AppName:   "api-service",
-			Component: "deployment",
-			Type:      "normal",
-			Reason:    "DeploymentRollout",
-			Message:   "Deployment has minimum availability. Replica set api-service-6b8f4d9c7a has 2 available replicas",
-			Source:    "deployment-controller",
-			Count:     1,
-			FirstSeen: now.Add(-20 * time.Minute),
-			LastSeen:  now.Add(-20 * time.Minute),
-		},
-		{
-			Timestamp: now.Add(-30 * time.Minute),
-			AppName:   "worker",
-			Component: "pod",
-			Type:      "error",
-			Reason:    "FailedMount",
-			Message:   "Unable to attach or mount volumes: unmounted volumes=[config], unattached volumes=[config default-token]: timed out waiting for the condition",
-			Source:    "kubelet",
-			Count:     1,
-			FirstSeen: now.Add(-30 * time.Minute),
-			LastSeen:  now.Add(-30 * time.Minute),
-		},
-		{
-			Timestamp: now.Add(-45 * time.Minute),
-			AppName:   "web-app",
-			Component: "service",
-			Type:      "normal",
-			Reason:    "ServiceCreated",
-			Message:   "Service web-app created successfully",
-			Source:    "service-controller",
-			Count:     1,
-			FirstSeen: now.Add(-45 * time.Minute),
-			LastSeen:  now.Add(-45 * time.Minute),
-		},
-		{
-			Timestamp: now.Add(-1 * time.Hour),
-			AppName:   "database",
-			Component: "pod",
-			Type:      "warning",
-			Reason:    "HighMemoryUsage",
-			Message:   "Pod database-0 is using 95% of allocated memory",
-			Source:    "monitoring-system",
-			Count:     1,
-			FirstSeen: now.Add(-1 * time.Hour),
-			LastSeen:  now.Add(-1 * time.Hour),
-		},
-	}
+	go func() {
+		<-sigChan
+		collector.Stop()
+	}()
 
-	// Filter by app name
-	if appName != "" {
-		var filtered []EventInfo
-		for _, event := range events {
-			if event.AppName == appName {
-				filtered = append(filtered, event)
-			}
+	return collector.WatchEvents(func(event monitoring.AppEvent) {
+		if appName != "" && event.AppName != appName {
+			return
 		}
-		events = filtered
-	}
-
-	// Filter by time period
-	cutoff := now.Add(-since)
-	var timeFiltered []EventInfo
-	for _, event := range events {
-		if event.Timestamp.After(cutoff) {
-			timeFiltered = append(timeFiltered, event)
+		if eventType != "" && !strings.EqualFold(event.Type, eventType) {
+			return
 		}
+		displaySingleEvent(toEventInfo(event))
+	})
+}
+
+func getEvents(collector *monitoring.Collector, appName string, since time.Duration, eventType string) ([]EventInfo, error) {
+	// Store the events still known by the cluster before querying
+	if _, err := collector.IngestEvents(); err != nil {
+		fmt.Printf("⚠️  Could not fetch events from the cluster, showing stored events: %v\n\n", err)
 	}
-	events = timeFiltered
 
-	// Filter by event type
-	if eventType != "" {
-		var typeFiltered []EventInfo
-		for _, event := range events {
-			if event.Type == eventType {
-				typeFiltered = append(typeFiltered, event)
-			}
-		}
-		events = typeFiltered
+	stored, err := collector.GetStoredEvents(appName, since, eventType)
+	if err != nil {
+		return nil, err
 	}
 
-	// Sort by timestamp (most recent first)
-	for i := 0; i < len(events)-1; i++ {
-		for j := i + 1; j < len(events); j++ {
-			if events[i].Timestamp.Before(events[j].Timestamp) {
-				events[i], events[j] = events[j], events[i]
-			}
-		}
+	events := make([]EventInfo, 0, len(stored))
+	for _, event := range stored {
+		events = append(events, toEventInfo(event))
 	}
 
 	return events, nil
 }
 
-func getEventsSince(collector *monitoring.Collector, appName string, since time.Time, eventType string) ([]EventInfo, error) {
-	// Get all events and filter by timestamp
-	allEvents, err := getEvents(collector, appName, time.Since(since)+time.Minute, eventType)
-	if err != nil {
-		return nil, err
+// toEventInfo converts a stored event to EventInfo for display
+func toEventInfo(event monitoring.AppEvent) EventInfo {
+	appName := event.AppName
+	if appName == "" {
+		appName = "cluster"
 	}
 
-	var newEvents []EventInfo
-	for _, event := range allEvents {
-		if event.Timestamp.After(since) {
-			newEvents = append(newEvents, event)
-		}
+	component := strings.ToLower(event.ObjectKind)
+	if event.ObjectName != "" {
+		component += "/" + event.ObjectName
 	}
 
-	return newEvents, nil
+	return EventInfo{
+		Timestamp: event.LastTimestamp,
+		AppName:   appName,
+		Component: component,
+		Type:      strings.ToLower(event.Type),
+		Reason:    event.Reason,
+		Message:   event.Message,
+		Count:     event.Count,
+		FirstSeen: event.FirstTimestamp,
+		LastSeen:  event.LastTimestamp,
+	}
 }
 
 func displayEventsTable(events []EventInfo, appName string, since time.Duration, eventType string) {
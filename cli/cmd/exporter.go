@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/shipyard/cli/pkg/database"
+	"github.com/shipyard/cli/pkg/monitoring"
+)
+
+var exporterCmd = &cobra.Command{
+	Use:   "exporter",
+	Short: "Expose collected data as a Prometheus metrics endpoint",
+	Long: `Serve the data stored by Shipyard in the Prometheus text format on /metrics.
+
+The endpoint exposes the latest collected metrics, deployment counts by
+status, active alerts and the latest health check of every app, labelled
+with the app and its namespace. The exporter only reads the database: run
+'shipyard agent' to keep the data up to date, or use 'shipyard agent
+--listen' to do both in one process.
+
+Examples:
+  shipyard exporter                  # Serve on :9100
+  shipyard exporter --listen :9200   # Serve on another port`,
+	Run: func(cmd *cobra.Command, args []string) {
+		listen, _ := cmd.Flags().GetString("listen")
+
+		if err := runExporter(listen); err != nil {
+			log.Fatalf("Exporter failed: %v", err)
+		}
+	},
+}
+
+func init() {
+	exporterCmd.Flags().String("listen", ":9100", "Address to serve /metrics on")
+}
+
+func runExporter(listen string) error {
+	db, err := database.NewDB()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	server, err := serveMetrics(listen, monitoring.NewExporter(db))
+	if err != nil {
+		return err
+	}
+
+	// Serve until Ctrl+C or pod termination
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+
+	fmt.Println("\n👋 Stopping exporter...")
+	return shutdownMetrics(server)
+}
+
+// serveMetrics serves the exporter on /metrics in the background
+func serveMetrics(listen string, exporter *monitoring.Exporter) (*http.Server, error) {
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", listen, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("Warning: metrics endpoint stopped: %v\n", err)
+		}
+	}()
+
+	fmt.Printf("📈 Serving Prometheus metrics on http://%s/metrics\n", listener.Addr())
+	return server, nil
+}
+
+// shutdownMetrics stops the metrics endpoint, letting running scrapes finish
+func shutdownMetrics(server *http.Server) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return server.Shutdown(ctx)
+}
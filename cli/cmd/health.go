@@ -24,7 +24,8 @@ Examples:
   shipyard health                     # Check health of all apps
   shipyard health my-app              # Check health of specific app
   shipyard health --watch            # Watch health status continuously
-  shipyard health --history 1h       # Show health history for last hour`,
+  shipyard health --history 1h       # Show health history for last hour
+  shipyard health --period 24h       # Compute uptime over the last day`,
 	Run: func(cmd *cobra.Command, args []string) {
 		var appName string
 		if len(args) > 0 {
@@ -33,8 +34,9 @@ Examples:
 
 		watch, _ := cmd.Flags().GetBool("watch")
 		history, _ := cmd.Flags().GetDuration("history")
+		period, _ := cmd.Flags().GetDuration("period")
 
-		if err := runHealth(appName, watch, history); err != nil {
+		if err := runHealth(appName, watch, history, period); err != nil {
 			log.Fatalf("Health command failed: %v", err)
 		}
 	},
@@ -43,9 +45,14 @@ Examples:
 func init() {
 	healthCmd.Flags().BoolP("watch", "w", false, "Watch health status continuously")
 	healthCmd.Flags().DurationP("history", "t", 0, "Show health check history for specified duration")
+	healthCmd.Flags().DurationP("period", "p", time.Hour, "Time period to compute uptime over")
 }
 
-func runHealth(appName string, watch bool, history time.Duration) error {
+func runHealth(appName string, watch bool, history, period time.Duration) error {
+	if period <= 0 {
+		return fmt.Errorf("--period must be a positive duration, got %v", period)
+	}
+
 	// Initialize monitoring collector
 	collector, err := monitoring.NewCollector()
 	if err != nil {
@@ -54,44 +61,56 @@ func runHealth(appName string, watch bool, history time.Duration) error {
 	defer collector.Close()
 
 	if watch {
-		return runHealthWatch(collector, appName)
+		return runHealthWatch(collector, appName, period)
 	}
 
 	if history > 0 {
 		return showHealthHistory(collector, appName, history)
 	}
 
-	return showCurrentHealth(collector, appName)
+	return showCurrentHealth(collector, appName, period)
 }
 
-func showCurrentHealth(collector *monitoring.Collector, appName string) error {
-	healthChecks, err := getCurrentHealthChecks(collector, appName)
+func showCurrentHealth(collector *monitoring.Collector, appName string, period time.Duration) error {
+	healthChecks, err := getCurrentHealthChecks(collector, appName, period)
 	if err != nil {
 		return fmt.Errorf("failed to get health checks: %w", err)
 	}
 
-	displayHealthTable(healthChecks, appName)
+	displayHealthTable(healthChecks, appName, period)
 	return nil
 }
 
-func runHealthWatch(collector *monitoring.Collector, appName string) error {
-	fmt.Printf("🔍 Watching health status (press Ctrl+C to stop)\n")
+func runHealthWatch(collector *monitoring.Collector, appName string, period time.Duration) error {
+	// Use the interval configured in monitoring_config
+	interval, err := collector.GetHealthCheckInterval(appName)
+	if err != nil {
+		return fmt.Errorf("failed to get health check interval: %w", err)
+	}
+
+	fmt.Printf("🔍 Watching health status every %v (press Ctrl+C to stop)\n", interval)
 	fmt.Println()
 
-	ticker := time.NewTicker(10 * time.Second)
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	// Initial check
-	if err := showCurrentHealth(collector, appName); err != nil {
+	if err := collector.RunHealthChecks(appName); err != nil {
+		return fmt.Errorf("failed to run health checks: %w", err)
+	}
+	if err := showCurrentHealth(collector, appName, period); err != nil {
 		return err
 	}
 
 	for {
 		select {
 		case <-ticker.C:
+			if err := collector.RunHealthChecks(appName); err != nil {
+				fmt.Printf("Error: %v\n", err)
+			}
 			fmt.Print("\033[2J\033[H") // Clear screen
-			fmt.Printf("🔍 Health Status - %s (auto-refresh: 10s)\n\n", time.Now().Format("15:04:05"))
-			if err := showCurrentHealth(collector, appName); err != nil {
+			fmt.Printf("🔍 Health Status - %s (auto-refresh: %v)\n\n", time.Now().Format("15:04:05"), interval)
+			if err := showCurrentHealth(collector, appName, period); err != nil {
 				fmt.Printf("Error: %v\n", err)
 			}
 		}
@@ -130,94 +149,51 @@ type HealthHistoryItem struct {
 	CheckedAt    time.Time
 }
 
-func getCurrentHealthChecks(collector *monitoring.Collector, appName string) ([]HealthCheckResult, error) {
-	// This would normally query the database for latest health checks
-	// For now, return sample data
-	
-	results := []HealthCheckResult{
-		{
-			AppName:      "web-app",
-			Endpoint:     "/health",
-			Status:       "healthy",
-			StatusCode:   200,
-			ResponseTime: 45,
-			LastCheck:    time.Now().Add(-30 * time.Second),
-			Uptime:       99.8,
-		},
-		{
-			AppName:      "api-service",
-			Endpoint:     "/health",
-			Status:       "unhealthy",
-			StatusCode:   503,
-			ResponseTime: 2000,
-			ErrorMessage: "Service unavailable",
-			LastCheck:    time.Now().Add(-15 * time.Second),
-			Uptime:       95.2,
-		},
-		{
-			AppName:      "worker",
-			Endpoint:     "/ping",
-			Status:       "healthy",
-			StatusCode:   200,
-			ResponseTime: 12,
-			LastCheck:    time.Now().Add(-45 * time.Second),
-			Uptime:       100.0,
-		},
+func getCurrentHealthChecks(collector *monitoring.Collector, appName string, period time.Duration) ([]HealthCheckResult, error) {
+	summaries, err := collector.GetHealthSummaries(appName, period)
+	if err != nil {
+		return nil, err
 	}
 
-	// Filter by app name if specified
-	if appName != "" {
-		var filtered []HealthCheckResult
-		for _, result := range results {
-			if result.AppName == appName {
-				filtered = append(filtered, result)
-			}
-		}
-		return filtered, nil
+	var results []HealthCheckResult
+	for _, summary := range summaries {
+		results = append(results, HealthCheckResult{
+			AppName:      summary.AppName,
+			Endpoint:     summary.Latest.Endpoint,
+			Status:       string(summary.Latest.Status),
+			StatusCode:   summary.Latest.StatusCode,
+			ResponseTime: summary.Latest.ResponseTime,
+			ErrorMessage: summary.Latest.ErrorMessage,
+			LastCheck:    summary.Latest.CheckedAt,
+			Uptime:       summary.Uptime,
+		})
 	}
 
 	return results, nil
 }
 
 func getHealthHistory(collector *monitoring.Collector, appName string, duration time.Duration) ([]HealthHistoryItem, error) {
-	// This would normally query the database for historical health checks
-	// For now, return sample data
-	
-	now := time.Now()
+	checks, err := collector.GetHealthHistory(appName, duration)
+	if err != nil {
+		return nil, err
+	}
+
 	var history []HealthHistoryItem
-	
-	// Generate sample historical data
-	for i := 0; i < 20; i++ {
-		checkTime := now.Add(-time.Duration(i*5) * time.Minute)
-		if checkTime.Before(now.Add(-duration)) {
-			break
-		}
-		
-		status := "healthy"
-		statusCode := 200
-		responseTime := 50 + i*2
-		
-		// Add some failures for demonstration
-		if i%7 == 0 {
-			status = "unhealthy"
-			statusCode = 503
-			responseTime = 2000
-		}
-		
+	for _, check := range checks {
 		history = append(history, HealthHistoryItem{
-			AppName:      "web-app",
-			Endpoint:     "/health",
-			Status:       status,
-			StatusCode:   statusCode,
-			ResponseTime: responseTime,
-			CheckedAt:    checkTime,
+			AppName:      check.AppName,
+			Endpoint:     check.Endpoint,
+			Status:       string(check.Status),
+			StatusCode:   check.StatusCode,
+			ResponseTime: check.ResponseTime,
+			CheckedAt:    check.CheckedAt,
 		})
 	}
 
 	return history, nil
 }
 
-func displayHealthTable(results []HealthCheckResult, appName string) {
+func displayHealthTable(results []HealthCheckResult, appName string, period time.Duration) {
 	if len(results) == 0 {
 		fmt.Println("🏥 No health check data found")
 		return
@@ -273,7 +249,8 @@ func displayHealthTable(results []HealthCheckResult, appName string) {
 	fmt.Printf("└%-15s┴%-12s┴%-10s┴%-6s┴%-12s┴%-8s┴%-12s┘\n",
 		"───────────────", "────────────", "──────────", "──────", "────────────", "────────", "────────────")
 	
-	fmt.Printf("\n💡 Tip: Use --watch to monitor health continuously\n")
+	fmt.Printf("\n📊 Uptime is computed from the checks of the last %v\n", period)
+	fmt.Printf("💡 Tip: Use --watch to monitor health continuously, --period 24h for uptime over a longer window\n")
 }
 
 func displayHealthHistory(history []HealthHistoryItem, appName string, duration time.Duration) {
@@ -322,16 +299,16 @@ func displayHealthHistory(history []HealthHistoryItem, appName string, duration
 	fmt.Printf("└%-20s┴%-10s┴%-6s┴%-12s┴%-12s┘\n",
 		"────────────────────", "──────────", "──────", "────────────", "────────────")
 
-	// Calculate success rate
+	// Calculate uptime over the window
 	healthyCount := 0
 	for _, item := range history {
 		if item.Status == "healthy" {
 			healthyCount++
 		}
 	}
-	successRate := float64(healthyCount) / float64(len(history)) * 100
+	uptime := float64(healthyCount) / float64(len(history)) * 100
 
-	fmt.Printf("\n📊 Success Rate: %.1f%% (%d/%d checks successful)\n", successRate, healthyCount, len(history))
+	fmt.Printf("\n📊 Uptime: %.1f%% (%d/%d checks successful)\n", uptime, healthyCount, len(history))
 }
 
 func formatTimeAgo(t time.Time) string {
@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/shipyard/cli/pkg/k8s"
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Manage the batch jobs of applications",
+	Long: `List, run and inspect the jobs defined in the jobs section of paas.yaml.
+
+Every job is deployed as a Kubernetes CronJob. Jobs without a schedule are
+suspended CronJobs that only run with 'shipyard jobs run'.`,
+}
+
+var jobsListCmd = &cobra.Command{
+	Use:   "list <app-name>",
+	Short: "List the jobs of an application and their recent runs",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runJobsList(args[0]); err != nil {
+			log.Fatalf("Failed to list jobs: %v", err)
+		}
+	},
+}
+
+var jobsRunCmd = &cobra.Command{
+	Use:   "run <app-name> <job>",
+	Short: "Run a job now",
+	Long: `Start a run of a job immediately, whatever its schedule.
+
+Examples:
+  shipyard jobs run my-app cleanup            # Start a run in the background
+  shipyard jobs run my-app migrate --follow   # Stream its logs until it finishes`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		follow, _ := cmd.Flags().GetBool("follow")
+		if err := runJobsRun(args[0], args[1], follow); err != nil {
+			log.Fatalf("Failed to run job: %v", err)
+		}
+	},
+}
+
+var jobsLogsCmd = &cobra.Command{
+	Use:   "logs <app-name> <job|run>",
+	Short: "Show the logs of a job run",
+	Long: `Show the logs of a run, or of the latest run when given a job name.
+
+Examples:
+  shipyard jobs logs my-app cleanup                        # Latest run of cleanup
+  shipyard jobs logs my-app my-app-cleanup-manual-x7k2p    # A specific run
+  shipyard jobs logs my-app cleanup --follow               # Stream until it finishes`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		follow, _ := cmd.Flags().GetBool("follow")
+		if err := runJobsLogs(args[0], args[1], follow); err != nil {
+			log.Fatalf("Failed to get job logs: %v", err)
+		}
+	},
+}
+
+func init() {
+	jobsRunCmd.Flags().BoolP("follow", "f", false, "Stream the logs of the run until it finishes")
+	jobsLogsCmd.Flags().BoolP("follow", "f", false, "Stream the logs until the run finishes")
+
+	jobsCmd.AddCommand(jobsListCmd)
+	jobsCmd.AddCommand(jobsRunCmd)
+	jobsCmd.AddCommand(jobsLogsCmd)
+}
+
+func runJobsList(appName string) error {
+	config, err := loadDeployedConfig(appName)
+	if err != nil {
+		return err
+	}
+
+	jobs, err := config.GetJobs()
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		fmt.Printf("📅 No jobs defined for app: %s\n", appName)
+		return nil
+	}
+
+	client, err := k8s.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create k8s client: %w", err)
+	}
+
+	namespace := config.App.GetNamespace()
+	runs, err := client.ListJobs(namespace, fmt.Sprintf("shipyard.app=%s", config.App.GetDNSName()))
+	if err != nil {
+		return fmt.Errorf("failed to list job runs: %w", err)
+	}
+
+	fmt.Printf("📅 Jobs for %s:\n\n", appName)
+
+	fmt.Printf("┌%-20s┬%-20s┬%-12s┬%-15s┐\n",
+		strings.Repeat("─", 20), strings.Repeat("─", 20), strings.Repeat("─", 12), strings.Repeat("─", 15))
+	fmt.Printf("│%-20s│%-20s│%-12s│%-15s│\n", "JOB", "SCHEDULE", "LAST RUN", "STATUS")
+	fmt.Printf("├%-20s┼%-20s┼%-12s┼%-15s┤\n",
+		strings.Repeat("─", 20), strings.Repeat("─", 20), strings.Repeat("─", 12), strings.Repeat("─", 15))
+
+	for _, job := range jobs {
+		schedule := job.Schedule
+		if job.Suspend {
+			schedule = "on demand"
+		}
+
+		lastRun, status := "-", "-"
+		for _, run := range runs {
+			if run.Labels["shipyard.job"] == job.Name {
+				lastRun = formatAge(time.Since(run.CreationTimestamp.Time)) + " ago"
+				status = formatJobStatus(&run)
+				break
+			}
+		}
+
+		fmt.Printf("│%-20s│%-20s│%-12s│%-15s│\n", job.Name, schedule, lastRun, status)
+	}
+
+	fmt.Printf("└%-20s┴%-20s┴%-12s┴%-15s┘\n",
+		strings.Repeat("─", 20), strings.Repeat("─", 20), strings.Repeat("─", 12), strings.Repeat("─", 15))
+
+	if len(runs) == 0 {
+		fmt.Printf("\n💡 No runs yet. Start one with: shipyard jobs run %s %s\n", appName, jobs[0].Name)
+		return nil
+	}
+
+	fmt.Printf("\n🕘 Recent runs:\n\n")
+	fmt.Printf("┌%-40s┬%-20s┬%-15s┬%-20s┬%-10s┐\n",
+		strings.Repeat("─", 40), strings.Repeat("─", 20), strings.Repeat("─", 15), strings.Repeat("─", 20), strings.Repeat("─", 10))
+	fmt.Printf("│%-40s│%-20s│%-15s│%-20s│%-10s│\n", "RUN", "JOB", "STATUS", "STARTED", "DURATION")
+	fmt.Printf("├%-40s┼%-20s┼%-15s┼%-20s┼%-10s┤\n",
+		strings.Repeat("─", 40), strings.Repeat("─", 20), strings.Repeat("─", 15), strings.Repeat("─", 20), strings.Repeat("─", 10))
+
+	for _, run := range runs {
+		started := run.CreationTimestamp.Time
+		if run.Status.StartTime != nil {
+			started = run.Status.StartTime.Time
+		}
+
+		duration := "-"
+		if run.Status.CompletionTime != nil {
+			duration = run.Status.CompletionTime.Sub(started).Round(time.Second).String()
+		} else if k8s.JobStatus(&run) == "Running" {
+			duration = time.Since(started).Round(time.Second).String()
+		}
+
+		fmt.Printf("│%-40s│%-20s│%-15s│%-20s│%-10s│\n",
+			run.Name, run.Labels["shipyard.job"], formatJobStatus(&run), started.Format("2006-01-02 15:04:05"), duration)
+	}
+
+	fmt.Printf("└%-40s┴%-20s┴%-15s┴%-20s┴%-10s┘\n",
+		strings.Repeat("─", 40), strings.Repeat("─", 20), strings.Repeat("─", 15), strings.Repeat("─", 20), strings.Repeat("─", 10))
+
+	return nil
+}
+
+func runJobsRun(appName, jobName string, follow bool) error {
+	config, err := loadDeployedConfig(appName)
+	if err != nil {
+		return err
+	}
+
+	job, err := config.GetJob(jobName)
+	if err != nil {
+		return err
+	}
+
+	client, err := k8s.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create k8s client: %w", err)
+	}
+
+	namespace := config.App.GetNamespace()
+	run, err := client.RunCronJob(job.Resource, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to start job %s: %w", jobName, err)
+	}
+
+	fmt.Printf("🚀 Started run %s of job %s\n", run.Name, jobName)
+
+	if !follow {
+		fmt.Printf("💡 Follow it with: shipyard jobs logs %s %s --follow\n", appName, run.Name)
+		return nil
+	}
+
+	return followJobRun(client, run.Name, namespace, job.Timeout())
+}
+
+func runJobsLogs(appName, target string, follow bool) error {
+	config, err := loadDeployedConfig(appName)
+	if err != nil {
+		return err
+	}
+
+	client, err := k8s.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create k8s client: %w", err)
+	}
+
+	namespace := config.App.GetNamespace()
+	runName := target
+
+	// A job name means its latest run
+	if job, err := config.GetJob(target); err == nil {
+		runs, err := client.ListJobs(namespace, fmt.Sprintf("shipyard.app=%s,shipyard.job=%s", config.App.GetDNSName(), job.Name))
+		if err != nil {
+			return fmt.Errorf("failed to list runs of job %s: %w", job.Name, err)
+		}
+		if len(runs) == 0 {
+			return fmt.Errorf("job %s has not run yet", job.Name)
+		}
+		runName = runs[0].Name
+	}
+
+	fmt.Printf("📋 Logs for run: %s\n", runName)
+
+	if follow {
+		return followJobRun(client, runName, namespace, 0)
+	}
+	return client.StreamJobLogs(runName, namespace, false, 0, os.Stdout)
+}
+
+// followJobRun streams the logs of a run and reports how it finished. timeout
+// is the timeout of the job, 0 when unknown or unlimited.
+func followJobRun(client *k8s.Client, runName, namespace string, timeout time.Duration) error {
+	if err := client.StreamJobLogs(runName, namespace, true, timeout, os.Stdout); err != nil {
+		return err
+	}
+
+	run, err := client.WaitForJob(runName, namespace, time.Minute)
+	if err != nil {
+		return fmt.Errorf("failed to get the result of run %s: %w", runName, err)
+	}
+
+	if k8s.JobStatus(run) == "Failed" {
+		return fmt.Errorf("run %s failed", runName)
+	}
+	fmt.Printf("✅ Run %s succeeded\n", runName)
+	return nil
+}
+
+func formatJobStatus(job *batchv1.Job) string {
+	switch status := k8s.JobStatus(job); status {
+	case "Succeeded":
+		return "✅ " + status
+	case "Failed":
+		return "❌ " + status
+	default:
+		return "⏳ " + status
+	}
+}
@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -15,16 +17,17 @@ var metricsCmd = &cobra.Command{
 	Long: `Display detailed resource metrics for your deployed applications.
 
 This command shows:
-- CPU and memory usage over time
-- Pod metrics breakdown
-- Request rates and response times
-- Historical trends
+- CPU and memory usage over time (avg, max and p95)
+- Pod counts
+- Historical trends grouped in time buckets sized for the period
 
 Examples:
   shipyard metrics                    # Show metrics for all apps
   shipyard metrics my-app             # Show metrics for specific app
   shipyard metrics --period 1h       # Show metrics for the last hour
-  shipyard metrics --format table    # Display in table format`,
+  shipyard metrics --type cpu,memory # Only show CPU and memory
+  shipyard metrics --dashboard       # Show trends for all apps side by side
+  shipyard metrics --format csv      # Export every bucket as CSV`,
 	Run: func(cmd *cobra.Command, args []string) {
 		var appName string
 		if len(args) > 0 {
@@ -33,8 +36,10 @@ Examples:
 
 		period, _ := cmd.Flags().GetDuration("period")
 		format, _ := cmd.Flags().GetString("format")
+		typeNames, _ := cmd.Flags().GetStringSlice("type")
+		dashboard, _ := cmd.Flags().GetBool("dashboard")
 
-		if err := runMetrics(appName, period, format); err != nil {
+		if err := runMetrics(appName, period, format, typeNames, dashboard); err != nil {
 			log.Fatalf("Metrics command failed: %v", err)
 		}
 	},
@@ -43,9 +48,23 @@ Examples:
 func init() {
 	metricsCmd.Flags().DurationP("period", "p", time.Hour, "Time period to show metrics for")
 	metricsCmd.Flags().StringP("format", "f", "table", "Output format (table, json, csv)")
+	metricsCmd.Flags().StringSliceP("type", "t", nil, "Metric types to show (cpu, memory, pods, ...)")
+	metricsCmd.Flags().Bool("dashboard", false, "Show a trend dashboard for all applications")
 }
 
-func runMetrics(appName string, period time.Duration, format string) error {
+func runMetrics(appName string, period time.Duration, format string, typeNames []string, dashboard bool) error {
+	if period <= 0 {
+		return fmt.Errorf("--period must be a positive duration, got %v", period)
+	}
+
+	types, err := monitoring.ParseMetricTypes(typeNames)
+	if err != nil {
+		return err
+	}
+	if len(types) == 0 {
+		types = monitoring.DefaultMetricTypes
+	}
+
 	// Initialize monitoring collector
 	collector, err := monitoring.NewCollector()
 	if err != nil {
@@ -54,19 +73,24 @@ func runMetrics(appName string, period time.Duration, format string) error {
 	defer collector.Close()
 
 	// Get metrics for the specified period
-	metrics, err := getMetricsForPeriod(collector, appName, period)
+	series, err := collector.GetMetricsSeries(appName, period, types)
 	if err != nil {
 		return fmt.Errorf("failed to get metrics: %w", err)
 	}
 
+	if dashboard {
+		displayMetricsDashboard(series, types, period)
+		return nil
+	}
+
 	// Display metrics based on format
 	switch format {
 	case "table":
-		displayMetricsTable(metrics, appName, period)
+		displayMetricsTable(series, types, appName, period)
 	case "json":
-		displayMetricsJSON(metrics)
+		return displayMetricsJSON(series, period)
 	case "csv":
-		displayMetricsCSV(metrics)
+		displayMetricsCSV(series, types)
 	default:
 		return fmt.Errorf("unsupported format: %s", format)
 	}
@@ -74,68 +98,28 @@ func runMetrics(appName string, period time.Duration, format string) error {
 	return nil
 }
 
-// MetricsSummary represents aggregated metrics for an app
-type MetricsSummary struct {
-	AppName          string
-	AvgCPU           float64
-	MaxCPU           float64
-	AvgMemory        float64
-	MaxMemory        float64
-	PodCount         int
-	RequestsPerSec   float64
-	AvgResponseTime  float64
-	DataPoints       int
-	Period           time.Duration
-}
-
-func getMetricsForPeriod(collector *monitoring.Collector, appName string, period time.Duration) ([]MetricsSummary, error) {
-	// This is a simplified implementation - in a real scenario, you'd query the database
-	// for historical metrics within the specified period
-	
-	// For now, return sample data
-	summaries := []MetricsSummary{
-		{
-			AppName:         "web-app",
-			AvgCPU:          42.5,
-			MaxCPU:          78.2,
-			AvgMemory:       256.0,
-			MaxMemory:       412.8,
-			PodCount:        3,
-			RequestsPerSec:  125.5,
-			AvgResponseTime: 89.3,
-			DataPoints:      120,
-			Period:          period,
-		},
-		{
-			AppName:         "api-service",
-			AvgCPU:          65.1,
-			MaxCPU:          89.7,
-			AvgMemory:       512.0,
-			MaxMemory:       724.1,
-			PodCount:        2,
-			RequestsPerSec:  89.2,
-			AvgResponseTime: 156.8,
-			DataPoints:      118,
-			Period:          period,
-		},
-	}
-
-	// Filter by app name if specified
-	if appName != "" {
-		var filtered []MetricsSummary
-		for _, summary := range summaries {
-			if summary.AppName == appName {
-				filtered = append(filtered, summary)
-			}
-		}
-		return filtered, nil
+// formatMetricValue formats a metric value in the unit shown to users
+func formatMetricValue(metricType monitoring.MetricType, value float64) string {
+	switch metricType {
+	case monitoring.MetricTypeCPU:
+		return fmt.Sprintf("%.0fm", value)
+	case monitoring.MetricTypeMemory:
+		return fmt.Sprintf("%.1fMB", value/(1024*1024))
+	case monitoring.MetricTypePods:
+		return fmt.Sprintf("%.0f", value)
+	case monitoring.MetricTypeCPUPercent, monitoring.MetricTypeMemoryPercent, monitoring.MetricTypeErrors:
+		return fmt.Sprintf("%.1f%%", value)
+	case monitoring.MetricTypeRequests:
+		return fmt.Sprintf("%.1f/s", value)
+	case monitoring.MetricTypeLatency, monitoring.MetricTypeLatencyP50, monitoring.MetricTypeLatencyP95, monitoring.MetricTypeLatencyP99:
+		return fmt.Sprintf("%.0fms", value)
+	default:
+		return fmt.Sprintf("%.1f", value)
 	}
-
-	return summaries, nil
 }
 
-func displayMetricsTable(metrics []MetricsSummary, appName string, period time.Duration) {
-	if len(metrics) == 0 {
+func displayMetricsTable(series []monitoring.MetricsSeries, types []monitoring.MetricType, appName string, period time.Duration) {
+	if len(series) == 0 {
 		fmt.Println("📊 No metrics found for the specified criteria")
 		return
 	}
@@ -150,63 +134,147 @@ func displayMetricsTable(metrics []MetricsSummary, appName string, period time.D
 	fmt.Println()
 
 	// Header
-	fmt.Printf("┌%-15s┬%-10s┬%-10s┬%-12s┬%-12s┬%-8s┬%-10s┬%-12s┐\n", 
-		"───────────────", "──────────", "──────────", "────────────", "────────────", "────────", "──────────", "────────────")
-	fmt.Printf("│%-15s│%-10s│%-10s│%-12s│%-12s│%-8s│%-10s│%-12s│\n",
-		"APP NAME", "AVG CPU%", "MAX CPU%", "AVG MEM(MB)", "MAX MEM(MB)", "PODS", "REQ/SEC", "AVG RESP(ms)")
-	fmt.Printf("├%-15s┼%-10s┼%-10s┼%-12s┼%-12s┼%-8s┼%-10s┼%-12s┤\n",
-		"───────────────", "──────────", "──────────", "────────────", "────────────", "────────", "──────────", "────────────")
+	fmt.Printf("┌%-15s┬%-10s┬%-12s┬%-12s┬%-12s┬%-8s┐\n",
+		"───────────────", "──────────", "────────────", "────────────", "────────────", "────────")
+	fmt.Printf("│%-15s│%-10s│%-12s│%-12s│%-12s│%-8s│\n",
+		"APP NAME", "METRIC", "AVG", "MAX", "P95", "POINTS")
+	fmt.Printf("├%-15s┼%-10s┼%-12s┼%-12s┼%-12s┼%-8s┤\n",
+		"───────────────", "──────────", "────────────", "────────────", "────────────", "────────")
 
 	// Data rows
-	for _, metric := range metrics {
-		fmt.Printf("│%-15s│%-10.1f│%-10.1f│%-12.1f│%-12.1f│%-8d│%-10.1f│%-12.1f│\n",
-			truncateString(metric.AppName, 15),
-			metric.AvgCPU,
-			metric.MaxCPU,
-			metric.AvgMemory,
-			metric.MaxMemory,
-			metric.PodCount,
-			metric.RequestsPerSec,
-			metric.AvgResponseTime,
-		)
-	}
-
-	fmt.Printf("└%-15s┴%-10s┴%-10s┴%-12s┴%-12s┴%-8s┴%-10s┴%-12s┘\n",
-		"───────────────", "──────────", "──────────", "────────────", "────────────", "────────", "──────────", "────────────")
-	
-	fmt.Printf("\n💡 Tip: Use 'shipyard monitor' for real-time monitoring\n")
+	for _, appSeries := range series {
+		for i, metricType := range types {
+			stats := appSeries.Summary[metricType]
+			name := ""
+			if i == 0 {
+				name = appSeries.AppName
+			}
+
+			avg, max, p95 := "N/A", "N/A", "N/A"
+			if stats.Count > 0 {
+				avg = formatMetricValue(metricType, stats.Avg)
+				max = formatMetricValue(metricType, stats.Max)
+				p95 = formatMetricValue(metricType, stats.P95)
+			}
+
+			fmt.Printf("│%-15s│%-10s│%-12s│%-12s│%-12s│%-8d│\n",
+				truncateString(name, 15),
+				truncateString(string(metricType), 10),
+				avg,
+				max,
+				p95,
+				stats.Count,
+			)
+		}
+	}
+
+	fmt.Printf("└%-15s┴%-10s┴%-12s┴%-12s┴%-12s┴%-8s┘\n",
+		"───────────────", "──────────", "────────────", "────────────", "────────────", "────────")
+
+	fmt.Printf("\n🕒 %d buckets of %v (use --format json or csv to see every bucket)\n",
+		len(series[0].Buckets), series[0].BucketSize)
+	fmt.Printf("💡 Tip: Use 'shipyard monitor' for real-time monitoring\n")
+}
+
+func displayMetricsDashboard(series []monitoring.MetricsSeries, types []monitoring.MetricType, period time.Duration) {
+	if len(series) == 0 {
+		fmt.Println("📊 No metrics found for the specified criteria")
+		return
+	}
+
+	title := fmt.Sprintf("📊 Metrics Dashboard (Last %v, %v buckets)", period, series[0].BucketSize)
+	fmt.Println(title)
+	fmt.Println("=" + fmt.Sprintf("%*s", len(title)-1, ""))
+
+	for _, appSeries := range series {
+		fmt.Printf("\n📱 %s\n", appSeries.AppName)
+		for _, metricType := range types {
+			stats := appSeries.Summary[metricType]
+			if stats.Count == 0 {
+				fmt.Printf("   %-8s no data\n", metricType)
+				continue
+			}
+
+			values := make([]float64, len(appSeries.Buckets))
+			for i, bucket := range appSeries.Buckets {
+				values[i] = bucket.Stats[metricType].Avg
+			}
+
+			fmt.Printf("   %-8s %s  avg %s  max %s  p95 %s\n",
+				metricType,
+				sparkline(values),
+				formatMetricValue(metricType, stats.Avg),
+				formatMetricValue(metricType, stats.Max),
+				formatMetricValue(metricType, stats.P95),
+			)
+		}
+	}
 }
 
-func displayMetricsJSON(metrics []MetricsSummary) {
-	fmt.Printf("{\n  \"metrics\": [\n")
-	for i, metric := range metrics {
-		comma := ","
-		if i == len(metrics)-1 {
-			comma = ""
+// sparkline renders values as a single line of block characters
+func sparkline(values []float64) string {
+	blocks := []rune("▁▂▃▄▅▆▇█")
+
+	max := 0.0
+	for _, value := range values {
+		if value > max {
+			max = value
+		}
+	}
+
+	var line strings.Builder
+	for _, value := range values {
+		if max == 0 || value == 0 {
+			line.WriteRune(' ')
+			continue
 		}
-		fmt.Printf(`    {
-      "app_name": "%s",
-      "avg_cpu_percent": %.1f,
-      "max_cpu_percent": %.1f,
-      "avg_memory_mb": %.1f,
-      "max_memory_mb": %.1f,
-      "pod_count": %d,
-      "requests_per_second": %.1f,
-      "avg_response_time_ms": %.1f,
-      "data_points": %d,
-      "period_seconds": %.0f
-    }%s
-`, metric.AppName, metric.AvgCPU, metric.MaxCPU, metric.AvgMemory, metric.MaxMemory,
-			metric.PodCount, metric.RequestsPerSec, metric.AvgResponseTime, metric.DataPoints, metric.Period.Seconds(), comma)
-	}
-	fmt.Printf("  ]\n}\n")
+		index := int(value / max * float64(len(blocks)-1))
+		line.WriteRune(blocks[index])
+	}
+	return line.String()
+}
+
+func displayMetricsJSON(series []monitoring.MetricsSeries, period time.Duration) error {
+	bucketSize := monitoring.BucketSizeFor(period)
+	output := struct {
+		PeriodSeconds float64                    `json:"period_seconds"`
+		BucketSeconds float64                    `json:"bucket_seconds"`
+		Metrics       []monitoring.MetricsSeries `json:"metrics"`
+	}{
+		PeriodSeconds: period.Seconds(),
+		BucketSeconds: bucketSize.Seconds(),
+		Metrics:       series,
+	}
+	if output.Metrics == nil {
+		output.Metrics = []monitoring.MetricsSeries{}
+	}
+
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode metrics: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
 }
 
-func displayMetricsCSV(metrics []MetricsSummary) {
-	fmt.Println("app_name,avg_cpu_percent,max_cpu_percent,avg_memory_mb,max_memory_mb,pod_count,requests_per_second,avg_response_time_ms,data_points,period_seconds")
-	for _, metric := range metrics {
-		fmt.Printf("%s,%.1f,%.1f,%.1f,%.1f,%d,%.1f,%.1f,%d,%.0f\n",
-			metric.AppName, metric.AvgCPU, metric.MaxCPU, metric.AvgMemory, metric.MaxMemory,
-			metric.PodCount, metric.RequestsPerSec, metric.AvgResponseTime, metric.DataPoints, metric.Period.Seconds())
+func displayMetricsCSV(series []monitoring.MetricsSeries, types []monitoring.MetricType) {
+	fmt.Println("app_name,bucket_start,bucket_seconds,metric_type,unit,avg,max,p95,data_points")
+	for _, appSeries := range series {
+		for _, bucket := range appSeries.Buckets {
+			for _, metricType := range types {
+				stats := bucket.Stats[metricType]
+				fmt.Printf("%s,%s,%.0f,%s,%s,%.2f,%.2f,%.2f,%d\n",
+					appSeries.AppName,
+					bucket.Start.UTC().Format(time.RFC3339),
+					appSeries.BucketSize.Seconds(),
+					metricType,
+					stats.Unit,
+					stats.Avg,
+					stats.Max,
+					stats.P95,
+					stats.Count,
+				)
+			}
+		}
 	}
-}
\ No newline at end of file
+}
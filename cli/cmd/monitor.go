@@ -323,7 +323,7 @@ func (m *MonitorDisplay) getAppStatus(app monitoring.App, alertsOnly bool) (moni
 	}
 
 	// Get deployment info from Kubernetes
-	deployment, err := m.collector.GetK8sClient().GetDeployment(app.Name)
+	deployment, err := m.collector.GetK8sClient().GetDeployment(app.Name, app.Namespace)
 	if err != nil {
 		// App might not be deployed yet
 		status.Status = "not-deployed"
@@ -382,7 +382,7 @@ func (m *MonitorDisplay) getAppStatus(app monitoring.App, alertsOnly bool) (moni
 
 func (m *MonitorDisplay) getAppMetrics(app monitoring.App) (*monitoring.AppMetrics, error) {
 	// Get pod metrics from Kubernetes
-	podMetrics, err := m.collector.GetK8sClient().GetPodMetrics(app.Name)
+	podMetrics, err := m.collector.GetK8sClient().GetPodMetrics(app.Name, app.Namespace)
 	if err != nil {
 		return nil, err
 	}
@@ -392,20 +392,24 @@ func (m *MonitorDisplay) getAppMetrics(app monitoring.App) (*monitoring.AppMetri
 	}
 
 	// Get pods for counting
-	pods, err := m.collector.GetK8sClient().GetPods(app.Name)
+	pods, err := m.collector.GetK8sClient().GetPods(app.Name, app.Namespace)
 	if err != nil {
 		return nil, err
 	}
 
-	// Calculate aggregate metrics
-	totalCPU := int64(0)
+	// Calculate aggregate metrics; percentages are those of the busiest pod
 	totalMemory := int64(0)
+	cpuPercent := 0.0
+	memoryPercent := 0.0
 	readyPods := 0
 
-	for _, podMetric := range podMetrics {
-		if len(podMetric.Containers) > 0 {
-			totalCPU += podMetric.Containers[0].Usage.Cpu().MilliValue()
-			totalMemory += podMetric.Containers[0].Usage.Memory().Value()
+	for _, usage := range monitoring.GetPodUsage(pods, podMetrics) {
+		totalMemory += usage.MemoryBytes
+		if usage.HasCPUBound && usage.CPUPercent > cpuPercent {
+			cpuPercent = usage.CPUPercent
+		}
+		if usage.HasMemoryBound && usage.MemoryPercent > memoryPercent {
+			memoryPercent = usage.MemoryPercent
 		}
 	}
 
@@ -415,15 +419,9 @@ func (m *MonitorDisplay) getAppMetrics(app monitoring.App) (*monitoring.AppMetri
 		}
 	}
 
-	// Convert CPU from millicores to percentage (simplified)
-	// This is a rough estimation - in production you'd use resource requests/limits
-	cpuPercent := float64(totalCPU) / 10.0 // Rough conversion
-	if cpuPercent > 100 {
-		cpuPercent = 100
-	}
-
 	return &monitoring.AppMetrics{
 		CPUPercent:     cpuPercent,
+		MemoryPercent:  memoryPercent,
 		MemoryBytes:    totalMemory,
 		PodCount:       len(pods),
 		PodReady:       readyPods,
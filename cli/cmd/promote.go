@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/shipyard/cli/pkg/k8s"
+	"github.com/shipyard/cli/pkg/manifests"
+	"github.com/shipyard/cli/pkg/monitoring"
+)
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote <app-name> --from <env> --to <env>",
+	Short: "Promote the latest successful release of an environment to another",
+	Long: `Deploy to an environment the exact image and config recorded by the
+latest successful deployment of another environment.
+
+The environments are those of paas.yaml (see shipyard deploy --env); leave
+out --from or --to to use the base config. The promoted config is the one
+recorded by the source release, with processes, jobs, volumes, resources,
+health checks, monitoring and release command as they were tested. Only the
+app name, namespace, domains, env and secrets come from the target. The
+release command runs as on a deploy.
+
+The new release records the version it was promoted from, shown by
+shipyard releases.
+
+Examples:
+  shipyard promote my-app --from staging --to production
+  shipyard promote my-app --from staging   # Promote to the base config`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+
+		if err := runPromote(args[0], from, to); err != nil {
+			log.Fatalf("Promote failed: %v", err)
+		}
+	},
+}
+
+func init() {
+	promoteCmd.Flags().String("from", "", "Environment to promote from (default: the base config)")
+	promoteCmd.Flags().String("to", "", "Environment to promote to (default: the base config)")
+	promoteCmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false, "Take over the fields other tools manage instead of failing")
+	promoteCmd.Flags().BoolVar(&prune, "prune", true, "Delete the objects of the target that are no longer in its manifests")
+	promoteCmd.Flags().DurationVar(&rolloutTimeout, "timeout", k8s.DefaultRolloutTimeout, "How long to wait for the deployments of the target to roll out")
+}
+
+func runPromote(appName, from, to string) error {
+	if from == to {
+		return fmt.Errorf("--from and --to must name different environments")
+	}
+
+	base, err := manifests.LoadConfig("paas.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to load paas.yaml: %w", err)
+	}
+	if base.App.Name != appName {
+		return fmt.Errorf("paas.yaml describes app %s, not %s", base.App.Name, appName)
+	}
+
+	// Find the release to promote
+	source, err := manifests.LoadConfigForEnv("paas.yaml", from)
+	if err != nil {
+		return fmt.Errorf("failed to load environment %s: %w", environmentLabel(from), err)
+	}
+
+	sourceVersions := manifests.NewVersionManager(source.App.Name)
+	sourceVersion, err := sourceVersions.GetLatestSuccessfulVersion()
+	sourceVersions.Close()
+	if err != nil {
+		return fmt.Errorf("failed to find a successful deployment of %s: %w", source.App.Name, err)
+	}
+	if sourceVersion.Config == nil {
+		return fmt.Errorf("deployment %s of %s has no stored configuration", sourceVersion.Version, source.App.Name)
+	}
+
+	config, err := manifests.PromoteConfig("paas.yaml", sourceVersion.Config, to)
+	if err != nil {
+		return fmt.Errorf("failed to load environment %s: %w", environmentLabel(to), err)
+	}
+
+	fmt.Printf("🚢 Promoting %s from %s to %s\n", appName, environmentLabel(from), environmentLabel(to))
+	fmt.Printf("   Source: %s %s (%s)\n", source.App.Name, sourceVersion.Version, sourceVersion.Image)
+	fmt.Printf("   Target: %s (namespace %s)\n", config.App.Name, config.App.GetNamespace())
+
+	if err := manifests.ValidationErrors(config.Validate()); err != nil {
+		return fmt.Errorf("invalid promoted config: %w", err)
+	}
+	if err := validateAndConfirmDNSNames(config); err != nil {
+		return fmt.Errorf("DNS validation failed: %w", err)
+	}
+
+	monitoringUpdate, err := monitoring.NewMonitoringConfigUpdate(config.Monitoring)
+	if err != nil {
+		return fmt.Errorf("invalid monitoring configuration: %w", err)
+	}
+
+	// Create the new version of the target, recording its origin
+	versionManager := manifests.NewVersionManager(config.App.Name)
+	promoteVersion, err := versionManager.GenerateVersion(config)
+	if err != nil {
+		return fmt.Errorf("failed to generate version: %w", err)
+	}
+	promoteVersion.PromotedFromApp = source.App.Name
+	promoteVersion.PromotedFrom = sourceVersion.Version
+
+	if err := versionManager.SaveVersion(promoteVersion); err != nil {
+		return fmt.Errorf("failed to save version: %w", err)
+	}
+
+	if err := applyDeployment(config, versionManager, promoteVersion, monitoringUpdate); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Promotion successful!\n")
+	fmt.Printf("   App: %s\n", config.App.Name)
+	fmt.Printf("   Version: %s (from %s %s)\n", promoteVersion.Version, source.App.Name, sourceVersion.Version)
+	fmt.Printf("   Image: %s\n", config.App.Image)
+
+	return nil
+}
+
+// environmentLabel names an environment in messages, the empty one being the base config
+func environmentLabel(env string) string {
+	if env == "" {
+		return "base"
+	}
+	return env
+}
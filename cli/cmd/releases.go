@@ -16,9 +16,14 @@ var (
 var releasesCmd = &cobra.Command{
 	Use:   "releases",
 	Short: "Show deployment release history",
-	Long:  `Display the history of deployments with versions, images, and status.`,
+	Long: `Display the history of deployments with versions, images, and status.
+
+Each environment has its own history: use --env to show that of an
+environment deployed with shipyard deploy --env.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := runReleases(); err != nil {
+		env, _ := cmd.Flags().GetString("env")
+
+		if err := runReleases(env); err != nil {
 			fmt.Printf("Error: %v\n", err)
 		}
 	},
@@ -26,11 +31,12 @@ var releasesCmd = &cobra.Command{
 
 func init() {
 	releasesCmd.Flags().IntVar(&releasesLimit, "limit", 10, "Number of releases to show")
+	releasesCmd.Flags().String("env", "", "Environment to show the history of (e.g. staging)")
 }
 
-func runReleases() error {
+func runReleases(env string) error {
 	// Parse current config to get app name
-	config, err := manifests.LoadConfig("paas.yaml")
+	config, err := manifests.LoadConfigForEnv("paas.yaml", env)
 	if err != nil {
 		return fmt.Errorf("failed to load paas.yaml: %w", err)
 	}
@@ -52,14 +58,14 @@ func runReleases() error {
 	fmt.Printf("📋 Deployment History for %s:\n\n", config.App.Name)
 
 	// Table header
-	fmt.Printf("┌%-12s┬%-20s┬%-15s┬%-10s┬%-20s┬%-15s┐\n", 
+	fmt.Printf("┌%-12s┬%-20s┬%-15s┬%-10s┬%-20s┬%-15s┬%-26s┐\n", 
 		strings.Repeat("─", 12), strings.Repeat("─", 20), strings.Repeat("─", 15), 
-		strings.Repeat("─", 10), strings.Repeat("─", 20), strings.Repeat("─", 15))
-	fmt.Printf("│%-12s│%-20s│%-15s│%-10s│%-20s│%-15s│\n", 
-		"VERSION", "IMAGE TAG", "STATUS", "AGE", "DEPLOYED AT", "ROLLBACK FROM")
-	fmt.Printf("├%-12s┼%-20s┼%-15s┼%-10s┼%-20s┼%-15s┤\n", 
+		strings.Repeat("─", 10), strings.Repeat("─", 20), strings.Repeat("─", 15), strings.Repeat("─", 26))
+	fmt.Printf("│%-12s│%-20s│%-15s│%-10s│%-20s│%-15s│%-26s│\n", 
+		"VERSION", "IMAGE TAG", "STATUS", "AGE", "DEPLOYED AT", "ROLLBACK FROM", "PROMOTED FROM")
+	fmt.Printf("├%-12s┼%-20s┼%-15s┼%-10s┼%-20s┼%-15s┼%-26s┤\n", 
 		strings.Repeat("─", 12), strings.Repeat("─", 20), strings.Repeat("─", 15), 
-		strings.Repeat("─", 10), strings.Repeat("─", 20), strings.Repeat("─", 15))
+		strings.Repeat("─", 10), strings.Repeat("─", 20), strings.Repeat("─", 15), strings.Repeat("─", 26))
 
 	// Current deployment indicator
 	for i, version := range versions {
@@ -87,18 +93,39 @@ func runReleases() error {
 			}
 		}
 
-		fmt.Printf("│%-12s│%-20s│%-15s│%-10s│%-20s│%-15s│\n", 
+		// Lineage of promoted releases: source app and version
+		promotedFrom := ""
+		if version.PromotedFrom != "" {
+			promotedFrom = version.PromotedFromApp + " " + version.PromotedFrom
+			if len(promotedFrom) > 26 {
+				promotedFrom = promotedFrom[:23] + "..."
+			}
+		}
+
+		fmt.Printf("│%-12s│%-20s│%-15s│%-10s│%-20s│%-15s│%-26s│\n", 
 			version.Version + currentMarker, 
 			imageTag, 
 			statusIcon + " " + version.Status, 
 			age, 
 			deployedAt,
-			rollbackFrom)
+			rollbackFrom,
+			promotedFrom)
 	}
 
-	fmt.Printf("└%-12s┴%-20s┴%-15s┴%-10s┴%-20s┴%-15s┘\n", 
+	fmt.Printf("└%-12s┴%-20s┴%-15s┴%-10s┴%-20s┴%-15s┴%-26s┘\n", 
 		strings.Repeat("─", 12), strings.Repeat("─", 20), strings.Repeat("─", 15), 
-		strings.Repeat("─", 10), strings.Repeat("─", 20), strings.Repeat("─", 15))
+		strings.Repeat("─", 10), strings.Repeat("─", 20), strings.Repeat("─", 15), strings.Repeat("─", 26))
+
+	// Why the failed releases failed
+	var failures []string
+	for _, version := range versions {
+		if version.Status == "failed" && version.ErrorMessage != "" {
+			failures = append(failures, fmt.Sprintf("❌ %s: %s", version.Version, version.ErrorMessage))
+		}
+	}
+	if len(failures) > 0 {
+		fmt.Printf("\n%s\n", strings.Join(failures, "\n"))
+	}
 
 	fmt.Printf("\n💡 Usage:\n")
 	if len(versions) > 1 {
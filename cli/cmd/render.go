@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/shipyard/cli/pkg/manifests"
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Render the manifests of paas.yaml without deploying",
+	Long: `Generate the manifests shipyard deploy would apply, without touching the
+cluster or the database.
+
+The full manifest set is rendered: namespace, deployments, services,
+cronjobs, volume claims, config files, the release job, registry secrets and
+the ingress of the domains of the app. The values of secrets are redacted,
+and the registry secret is the one of the image registry, without prompting.
+Version labels are left out, so the output only changes with the
+config.
+
+Without --output, the manifests are printed to stdout as a single YAML
+stream; progress messages go to stderr. With --output, they are written to
+the directory, laid out as in ~/.shipyard/manifests.
+
+Examples:
+  shipyard render                       # Print the manifests of paas.yaml
+  shipyard render --env staging         # Print the manifests of staging
+  shipyard render -o rendered/          # Write the manifests to rendered/`,
+	Run: func(cmd *cobra.Command, args []string) {
+		env, _ := cmd.Flags().GetString("env")
+		output, _ := cmd.Flags().GetString("output")
+
+		if err := runRender(env, output); err != nil {
+			log.Fatalf("Render failed: %v", err)
+		}
+	},
+}
+
+func init() {
+	renderCmd.Flags().String("env", "", "Environment to render, merged over paas.yaml (e.g. staging)")
+	renderCmd.Flags().StringP("output", "o", "", "Directory to write the manifests to (default: stdout)")
+}
+
+// runRender renders the manifests of paas.yaml for an environment into
+// outputDir, or prints them when outputDir is empty
+func runRender(env, outputDir string) error {
+	config, err := manifests.LoadConfigForEnv("paas.yaml", env)
+	if err != nil {
+		return fmt.Errorf("failed to load paas.yaml: %w", err)
+	}
+
+	problems := config.Validate()
+	if err := manifests.ValidationErrors(problems); err != nil {
+		return fmt.Errorf("invalid paas.yaml: %w", err)
+	}
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "⚠️  %s\n", problem)
+	}
+
+	if outputDir != "" {
+		if err := renderManifests(config, outputDir, true); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Manifests of %s rendered to %s\n", config.App.Name, outputDir)
+		return nil
+	}
+
+	dir, err := os.MkdirTemp("", "shipyard-render-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := renderManifestsQuietly(config, dir, true); err != nil {
+		return err
+	}
+
+	return printManifests(dir)
+}
+
+// renderManifests generates the manifests of an app and its ingress into a
+// directory, without touching the cluster or the database
+func renderManifests(config *manifests.Config, dir string, redactSecrets bool) error {
+	generator := manifests.NewRenderGenerator(config, nil, dir, redactSecrets)
+	if err := generator.GenerateAppManifests(); err != nil {
+		return fmt.Errorf("failed to generate app manifests: %w", err)
+	}
+	if err := generator.UpdateIngressManifests(); err != nil {
+		return fmt.Errorf("failed to generate ingress: %w", err)
+	}
+	return nil
+}
+
+// renderManifestsQuietly renders the manifests of an app, reporting the
+// progress of the generator on stderr to keep stdout for the result
+func renderManifestsQuietly(config *manifests.Config, dir string, redactSecrets bool) error {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = stdout }()
+
+	return renderManifests(config, dir, redactSecrets)
+}
+
+// printManifests prints the manifest files of a directory as a single YAML
+// stream, namespaces first and shared ingress last
+func printManifests(dir string) error {
+	files, err := renderedFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		content, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		manifest := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(content)), "---"))
+		if manifest == "" {
+			continue
+		}
+		fmt.Printf("---\n# Source: %s\n%s\n", filepath.ToSlash(file), manifest)
+	}
+
+	return nil
+}
+
+// renderedFiles lists the manifest files of a directory relative to it, in
+// the order they are applied
+func renderedFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(path) != ".yaml" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rendered manifests: %w", err)
+	}
+
+	rank := func(file string) int {
+		switch {
+		case strings.HasPrefix(filepath.Base(file), "namespace-"):
+			return 0
+		case strings.HasPrefix(file, "apps"+string(filepath.Separator)):
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return rank(files[i]) < rank(files[j])
+	})
+
+	return files, nil
+}
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/shipyard/cli/pkg/database"
+)
+
+const renderConfig = `app:
+  name: shop
+  image: ghcr.io/company/shop:v1
+  port: 3000
+env:
+  LOG_LEVEL: info
+secrets:
+  DATABASE_URL: postgres://user:hunter2@db/shop
+domains:
+  - shop.example.com
+processes:
+  web: {}
+  worker:
+    command: bin/worker
+jobs:
+  cleanup:
+    schedule: "@daily"
+    command: bin/cleanup
+release: bin/migrate
+environments:
+  staging:
+    domains: [staging.shop.example.com]
+`
+
+// inRenderProject runs the test in a project holding renderConfig, with a
+// home directory of its own
+func inRenderProject(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "paas.yaml"), []byte(renderConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// captureStdout returns what fn prints to stdout
+func captureStdout(t *testing.T, fn func() error) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+
+	fnErr := fn()
+	w.Close()
+	os.Stdout = stdout
+	if fnErr != nil {
+		t.Fatal(fnErr)
+	}
+	return <-output
+}
+
+// sourceHeader matches the comment naming the file of each printed manifest
+var sourceHeader = regexp.MustCompile(`(?m)^# Source: (.+)$`)
+
+func TestRenderPrintsManifests(t *testing.T) {
+	tests := []struct {
+		env     string
+		sources []string
+		domain  string
+	}{
+		{
+			env: "",
+			sources: []string{
+				"shared/namespace-shop.yaml",
+				"apps/shop/cronjob-cleanup.yaml",
+				"apps/shop/deployment-worker.yaml",
+				"apps/shop/deployment.yaml",
+				"apps/shop/release/job.yaml",
+				"apps/shop/secrets.yaml",
+				"apps/shop/service.yaml",
+				"shared/example.com.yaml",
+			},
+			domain: "host: shop.example.com",
+		},
+		{
+			env: "staging",
+			sources: []string{
+				"shared/namespace-shop-staging.yaml",
+				"apps/shop-staging/cronjob-cleanup.yaml",
+				"apps/shop-staging/deployment-worker.yaml",
+				"apps/shop-staging/deployment.yaml",
+				"apps/shop-staging/release/job.yaml",
+				"apps/shop-staging/secrets.yaml",
+				"apps/shop-staging/service.yaml",
+				"shared/example.com.yaml",
+			},
+			domain: "host: staging.shop.example.com",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(environmentLabel(test.env), func(t *testing.T) {
+			inRenderProject(t)
+
+			output := captureStdout(t, func() error { return runRender(test.env, "") })
+
+			var sources []string
+			for _, match := range sourceHeader.FindAllStringSubmatch(output, -1) {
+				sources = append(sources, match[1])
+			}
+			if !reflect.DeepEqual(sources, test.sources) {
+				t.Errorf("printed %v, want %v", sources, test.sources)
+			}
+
+			// Only manifests reach stdout, progress goes to stderr
+			if !strings.HasPrefix(output, "---\n# Source: ") {
+				t.Errorf("output does not start with a manifest: %.80q", output)
+			}
+			if !strings.Contains(output, test.domain) {
+				t.Errorf("output has no ingress rule %q", test.domain)
+			}
+
+			// Secrets are redacted, in the app Secret and in the release Secret
+			if strings.Contains(output, "hunter2") || strings.Contains(output, "cG9zdGdyZXM6") {
+				t.Error("output holds the value of DATABASE_URL")
+			}
+			if got := strings.Count(output, "DATABASE_URL: <redacted>"); got != 2 {
+				t.Errorf("DATABASE_URL redacted %d times, want 2", got)
+			}
+
+			// Version labels are left out, so the output only changes with the config
+			if strings.Contains(output, "shipyard.version") {
+				t.Error("output holds version labels")
+			}
+		})
+	}
+}
+
+func TestRenderToDirectory(t *testing.T) {
+	inRenderProject(t)
+	outputDir := filepath.Join(t.TempDir(), "rendered")
+
+	captureStdout(t, func() error { return runRender("", outputDir) })
+
+	files, err := renderedFiles(outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"shared/namespace-shop.yaml",
+		"apps/shop/cronjob-cleanup.yaml",
+		"apps/shop/deployment-worker.yaml",
+		"apps/shop/deployment.yaml",
+		"apps/shop/release/job.yaml",
+		"apps/shop/secrets.yaml",
+		"apps/shop/service.yaml",
+		"shared/example.com.yaml",
+	}
+	for i := range want {
+		want[i] = filepath.FromSlash(want[i])
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("rendered %v, want %v", files, want)
+	}
+
+	secrets, err := os.ReadFile(filepath.Join(outputDir, "apps", "shop", "secrets.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(secrets), "DATABASE_URL: <redacted>") {
+		t.Errorf("secrets.yaml is not redacted:\n%s", secrets)
+	}
+}
+
+// A dry run reads the database but records nothing: no release, no domain
+func TestRenderLeavesDatabaseUntouched(t *testing.T) {
+	inRenderProject(t)
+
+	captureStdout(t, func() error { return runRender("", "") })
+
+	db, err := database.NewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, table := range []string{"apps", "deployments", "domains"} {
+		var count int
+		if err := db.GetConnection().QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("render added %d rows to %s", count, table)
+		}
+	}
+}
@@ -16,28 +16,38 @@ var rollbackCmd = &cobra.Command{
 	Short: "Rollback to a previous deployment version",
 	Long: `Rollback your application to a previous deployment version.
 You can specify either a version (e.g., v1634567890) or an image tag (e.g., v1.2.3).
-If no version is specified, it will show an interactive list of deployments.`,
+If no version is specified, it will show an interactive list of deployments.
+Use --env to roll back an environment deployed with shipyard deploy --env.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		env, _ := cmd.Flags().GetString("env")
+
 		var targetVersion string
 		if len(args) > 0 {
 			targetVersion = args[0]
-			if err := runRollback(targetVersion); err != nil {
+			if err := runRollback(targetVersion, env); err != nil {
 				log.Fatalf("Rollback failed: %v", err)
 			}
 		} else {
 			// Interactive mode when no version specified
-			if err := runRollbackInteractive(); err != nil {
+			if err := runRollbackInteractive(env); err != nil {
 				log.Fatalf("Rollback failed: %v", err)
 			}
 		}
 	},
 }
 
-func runRollback(targetIdentifier string) error {
+func init() {
+	rollbackCmd.Flags().String("env", "", "Environment to roll back (e.g. staging)")
+	rollbackCmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false, "Take over the fields other tools manage instead of failing")
+	rollbackCmd.Flags().BoolVar(&prune, "prune", true, "Delete the objects of the app that the rolled back version does not have")
+	rollbackCmd.Flags().DurationVar(&rolloutTimeout, "timeout", k8s.DefaultRolloutTimeout, "How long to wait for the deployments of the app to roll out")
+}
+
+func runRollback(targetIdentifier, env string) error {
 	fmt.Println("🔄 Starting rollback...")
 
 	// Parse current config to get app name
-	config, err := manifests.LoadConfig("paas.yaml")
+	config, err := manifests.LoadConfigForEnv("paas.yaml", env)
 	if err != nil {
 		return fmt.Errorf("failed to load paas.yaml: %w", err)
 	}
@@ -114,10 +124,14 @@ func runRollback(targetIdentifier string) error {
 		newVersionManager.UpdateVersionStatus(rollbackVersion.Version, "failed")
 		return fmt.Errorf("failed to create k8s client: %w", err)
 	}
+	client.SetForceConflicts(forceConflicts)
+	client.SetRolloutTimeout(rolloutTimeout)
 
-	if err := client.ApplyManifestsWithNamespace(config.App.Name, config.App.GetNamespace()); err != nil {
-		// Mark rollback as failed
-		newVersionManager.UpdateVersionStatus(rollbackVersion.Version, "failed")
+	if err := client.ApplyManifestsWithNamespace(config.App.Name, config.App.GetNamespace(), rollbackVersion.Version); err != nil {
+		// Mark rollback as failed, with the reason
+		if updateErr := newVersionManager.UpdateVersionError(rollbackVersion.Version, failureReason(err)); updateErr != nil {
+			fmt.Printf("⚠️  Warning: failed to update version status: %v\n", updateErr)
+		}
 		return fmt.Errorf("failed to apply rollback manifests: %w", err)
 	}
 
@@ -126,6 +140,14 @@ func runRollback(targetIdentifier string) error {
 		fmt.Printf("⚠️  Warning: failed to update version status: %v\n", err)
 	}
 
+	// Delete what the later versions added
+	if prune {
+		fmt.Printf("🧹 Pruning resources %s no longer uses...\n", config.App.Name)
+		if err := client.PruneApp(config.App.Name, config.App.GetNamespace(), rollbackVersion.Version); err != nil {
+			fmt.Printf("⚠️  Warning: failed to prune resources: %v\n", err)
+		}
+	}
+
 	fmt.Printf("✅ Rollback successful!\n")
 	fmt.Printf("   Rolled back from current to %s (%s)\n", targetVersion.Version, targetVersion.ImageTag)
 	fmt.Printf("   New deployment version: %s\n", rollbackVersion.Version)
@@ -134,12 +156,12 @@ func runRollback(targetIdentifier string) error {
 }
 
 // runRollbackInteractive provides an interactive rollback menu
-func runRollbackInteractive() error {
+func runRollbackInteractive(env string) error {
 	fmt.Println("🔄 Interactive Rollback")
 	fmt.Println("======================")
 
 	// Parse current config to get app name
-	config, err := manifests.LoadConfig("paas.yaml")
+	config, err := manifests.LoadConfigForEnv("paas.yaml", env)
 	if err != nil {
 		return fmt.Errorf("failed to load paas.yaml: %w", err)
 	}
@@ -236,5 +258,5 @@ func runRollbackInteractive() error {
 	}
 
 	// Perform rollback
-	return runRollback(selectedVersion.Version)
+	return runRollback(selectedVersion.Version, env)
 }
\ No newline at end of file
@@ -38,4 +38,12 @@ func init() {
 	rootCmd.AddCommand(healthCmd)
 	rootCmd.AddCommand(alertsCmd)
 	rootCmd.AddCommand(eventsCmd)
+	rootCmd.AddCommand(agentCmd)
+	rootCmd.AddCommand(exporterCmd)
+	rootCmd.AddCommand(scaleCmd)
+	rootCmd.AddCommand(jobsCmd)
+	rootCmd.AddCommand(promoteCmd)
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(renderCmd)
+	rootCmd.AddCommand(diffCmd)
 }
\ No newline at end of file
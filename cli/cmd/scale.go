@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/shipyard/cli/pkg/k8s"
+	"github.com/shipyard/cli/pkg/manifests"
+)
+
+var scaleCmd = &cobra.Command{
+	Use:   "scale <app-name> <process>=<replicas>...",
+	Short: "Scale the processes of an application",
+	Long: `Set the number of replicas of one or more processes of a deployed application.
+
+Processes are the entries of the processes section of paas.yaml; apps
+without one run a single web process. Processes scaled by an autoscaler
+(scaling.max greater than scaling.min) cannot be scaled by hand, and
+processes mounting a ReadWriteOnce volume run at most one replica.
+
+The next deploy resets every process to the replicas set in paas.yaml.
+
+Examples:
+  shipyard scale my-app worker=3
+  shipyard scale my-app web=2 worker=5`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runScale(args[0], args[1:]); err != nil {
+			log.Fatalf("Scale failed: %v", err)
+		}
+	},
+}
+
+func runScale(appName string, targets []string) error {
+	replicas, order, err := parseScaleTargets(targets)
+	if err != nil {
+		return err
+	}
+
+	// Processes are resolved from the config of the running version
+	config, err := loadDeployedConfig(appName)
+	if err != nil {
+		return err
+	}
+
+	processes := make([]*manifests.Process, 0, len(order))
+	for _, name := range order {
+		process, err := config.GetProcess(name)
+		if err != nil {
+			return err
+		}
+		if process.Autoscaled() {
+			return fmt.Errorf("process %s is autoscaled between %d and %d replicas: change its scaling in paas.yaml instead",
+				name, process.Scaling.Min, process.Scaling.Max)
+		}
+		if volume, ok := process.SingleWriterVolume(); ok && process.Recreate && replicas[name] > 1 {
+			return fmt.Errorf("volume %s is %s: process %s must run a single replica (use access_mode: ReadWriteMany in paas.yaml to run more)",
+				volume.Name, volume.AccessMode, name)
+		}
+		processes = append(processes, process)
+	}
+
+	client, err := k8s.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create k8s client: %w", err)
+	}
+
+	namespace := config.App.GetNamespace()
+	for _, process := range processes {
+		count := replicas[process.Name]
+		if err := client.ScaleDeployment(process.Resource, namespace, int32(count)); err != nil {
+			return fmt.Errorf("failed to scale process %s: %w", process.Name, err)
+		}
+		fmt.Printf("⚖️  Scaled %s/%s to %d replicas\n", appName, process.Name, count)
+	}
+
+	fmt.Println("💡 The next deploy resets replicas to the values in paas.yaml")
+	return nil
+}
+
+// loadDeployedConfig returns the config of the latest successful deployment of an app
+func loadDeployedConfig(appName string) (*manifests.Config, error) {
+	versionManager := manifests.NewVersionManager(appName)
+	version, err := versionManager.GetLatestSuccessfulVersion()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find a successful deployment of %s: %w", appName, err)
+	}
+	if version.Config == nil {
+		return nil, fmt.Errorf("deployment %s of %s has no stored configuration", version.Version, appName)
+	}
+	return version.Config, nil
+}
+
+// parseScaleTargets parses arguments such as worker=3, keeping their order
+func parseScaleTargets(targets []string) (map[string]int, []string, error) {
+	replicas := make(map[string]int)
+	var order []string
+
+	for _, target := range targets {
+		name, value, ok := strings.Cut(target, "=")
+		if !ok || name == "" {
+			return nil, nil, fmt.Errorf("invalid argument %q: use <process>=<replicas>", target)
+		}
+
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 0 {
+			return nil, nil, fmt.Errorf("invalid replicas for process %s: %q", name, value)
+		}
+
+		if _, seen := replicas[name]; !seen {
+			order = append(order, name)
+		}
+		replicas[name] = count
+	}
+
+	return replicas, order, nil
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/shipyard/cli/pkg/manifests"
+	"github.com/shipyard/cli/pkg/monitoring"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check paas.yaml for errors",
+	Long: `Check paas.yaml without deploying it.
+
+Unknown fields and values of the wrong type are reported with their line
+and column. Values are checked too: resource quantities, ports, probe paths,
+scaling bounds, DNS-1035 names, domains, processes, jobs, volumes, release
+and files. The same checks run at the start of every deploy.
+
+Without --env, the base config and every environment defined in paas.yaml
+or by a paas.<env>.yaml file are checked. The command exits with status 1
+when errors are found.
+
+With --schema, the JSON Schema of paas.yaml is printed instead, for editors
+to complete and check the file.
+
+Examples:
+  shipyard validate                      # Check paas.yaml and its environments
+  shipyard validate --env staging        # Check the staging environment
+  shipyard validate --schema > paas.schema.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		file, _ := cmd.Flags().GetString("file")
+		env, _ := cmd.Flags().GetString("env")
+		schema, _ := cmd.Flags().GetBool("schema")
+
+		if schema {
+			if err := printConfigSchema(); err != nil {
+				log.Fatalf("Validate failed: %v", err)
+			}
+			return
+		}
+
+		valid, err := runValidate(file, env)
+		if err != nil {
+			log.Fatalf("Validate failed: %v", err)
+		}
+		if !valid {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	validateCmd.Flags().StringP("file", "f", "paas.yaml", "Config file to check")
+	validateCmd.Flags().String("env", "", "Only check this environment (e.g. staging)")
+	validateCmd.Flags().Bool("schema", false, "Print the JSON Schema of paas.yaml")
+}
+
+// runValidate checks a config file and reports whether it has no errors
+func runValidate(file, env string) (bool, error) {
+	envs := []string{env}
+	if env == "" {
+		defined, err := manifests.ListEnvironments(file)
+		if err != nil {
+			return false, err
+		}
+		envs = append(envs, defined...)
+	}
+
+	errorCount, warningCount := 0, 0
+	for _, env := range envs {
+		if env == "" {
+			fmt.Printf("🔍 Checking %s\n", file)
+		} else {
+			fmt.Printf("🔍 Checking %s (environment %s)\n", file, env)
+		}
+
+		for _, problem := range checkConfig(file, env) {
+			if problem.Warning {
+				warningCount++
+				fmt.Printf("   ⚠️  %s\n", problem)
+			} else {
+				errorCount++
+				fmt.Printf("   ❌ %s\n", problem)
+			}
+		}
+	}
+
+	fmt.Println()
+	if errorCount > 0 {
+		fmt.Printf("❌ %d error(s), %d warning(s)\n", errorCount, warningCount)
+		return false, nil
+	}
+	if warningCount > 0 {
+		fmt.Printf("✅ %s is valid, with %d warning(s)\n", file, warningCount)
+	} else {
+		fmt.Printf("✅ %s is valid\n", file)
+	}
+	return true, nil
+}
+
+// checkConfig loads a config file for an environment and returns its problems
+func checkConfig(file, env string) []manifests.Problem {
+	config, err := manifests.LoadConfigForEnv(file, env)
+	if err != nil {
+		if problems, ok := manifests.AsValidationError(err); ok {
+			return problems
+		}
+		return []manifests.Problem{{Message: err.Error()}}
+	}
+
+	problems := config.Validate()
+	if _, err := monitoring.NewMonitoringConfigUpdate(config.Monitoring); err != nil {
+		problems = append(problems, manifests.Problem{File: file, Path: "monitoring", Message: err.Error()})
+	}
+	return problems
+}
+
+// printConfigSchema prints the JSON Schema of paas.yaml
+func printConfigSchema() error {
+	schema, err := manifests.ConfigSchema()
+	if err != nil {
+		return fmt.Errorf("failed to generate schema: %w", err)
+	}
+	fmt.Println(string(schema))
+	return nil
+}
@@ -26,8 +26,9 @@ func NewDB() (*DB, error) {
 		return nil, fmt.Errorf("failed to get database path: %w", err)
 	}
 
-	// Open database connection
-	conn, err := sql.Open("sqlite", dbPath)
+	// Open database connection, waiting for locks held by other connections
+	// (e.g. the agent writing while a command reads)
+	conn, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -61,9 +62,103 @@ func (db *DB) initSchema() error {
 		return fmt.Errorf("failed to execute schema: %w", err)
 	}
 
+	// Add columns introduced after the table was first created
+	if err := db.migrateSchema(string(schema)); err != nil {
+		return fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
 	return nil
 }
 
+// columnMigrations lists columns added to existing tables, in order.
+// CREATE TABLE IF NOT EXISTS leaves older databases untouched, so each
+// column is also declared in schema.sql for new databases.
+var columnMigrations = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{"alerts", "suppressed_until", "DATETIME"},
+	{"apps", "namespace", "TEXT"},
+	{"deployments", "promoted_from_app", "TEXT"},
+	{"deployments", "promoted_from_version", "TEXT"},
+}
+
+// viewMigrations lists columns added to views. Views cannot be altered, so
+// a view missing one of its columns is dropped and created again by schema.sql.
+var viewMigrations = []struct {
+	view   string
+	column string
+}{
+	{"deployment_history", "promoted_from_version"},
+}
+
+// migrateSchema adds missing columns to tables and views created by older versions
+func (db *DB) migrateSchema(schema string) error {
+	for _, migration := range columnMigrations {
+		exists, err := db.columnExists(migration.table, migration.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", migration.table, migration.column, migration.definition)
+		if _, err := db.conn.Exec(query); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", migration.table, migration.column, err)
+		}
+	}
+
+	recreateViews := false
+	for _, migration := range viewMigrations {
+		exists, err := db.columnExists(migration.view, migration.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		if _, err := db.conn.Exec(fmt.Sprintf("DROP VIEW IF EXISTS %s", migration.view)); err != nil {
+			return fmt.Errorf("failed to drop view %s: %w", migration.view, err)
+		}
+		recreateViews = true
+	}
+
+	if recreateViews {
+		if _, err := db.conn.Exec(schema); err != nil {
+			return fmt.Errorf("failed to recreate views: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// columnExists checks if a table has a column
+func (db *DB) columnExists(table, column string) (bool, error) {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid int
+		var name, columnType string
+		var notNull, primaryKey int
+		var defaultValue *string
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &primaryKey); err != nil {
+			return false, fmt.Errorf("failed to scan column of %s: %w", table, err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
+
 // Close closes the database connection
 func (db *DB) Close() error {
 	return db.conn.Close()
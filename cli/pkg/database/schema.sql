@@ -4,6 +4,7 @@
 CREATE TABLE IF NOT EXISTS apps (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     name TEXT NOT NULL UNIQUE,
+    namespace TEXT, -- namespace found in the cluster by the agent, for apps without deployments
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
@@ -19,6 +20,8 @@ CREATE TABLE IF NOT EXISTS deployments (
     config_hash TEXT NOT NULL,
     status TEXT NOT NULL CHECK (status IN ('pending', 'success', 'failed')),
     rollback_to_version TEXT, -- NULL if not a rollback
+    promoted_from_app TEXT, -- app of the environment the version was promoted from, NULL if not a promotion
+    promoted_from_version TEXT, -- version promoted from, NULL if not a promotion
     deployed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     completed_at DATETIME, -- When deployment finished (success or failed)
     error_message TEXT, -- Error details if deployment failed
@@ -46,6 +49,8 @@ SELECT
     d.config_hash,
     d.status,
     d.rollback_to_version,
+    d.promoted_from_app,
+    d.promoted_from_version,
     d.deployed_at,
     d.completed_at,
     d.error_message
@@ -169,7 +174,7 @@ CREATE TABLE IF NOT EXISTS health_checks (
 CREATE TABLE IF NOT EXISTS alerts (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     app_id INTEGER NOT NULL,
-    alert_type TEXT NOT NULL, -- cpu_high, memory_high, error_rate, response_time
+    alert_type TEXT NOT NULL, -- cpu_high, memory_high, error_rate_high, response_time_high
     threshold REAL NOT NULL,
     current_value REAL NOT NULL,
     severity TEXT NOT NULL, -- info, warning, critical
@@ -178,10 +183,23 @@ CREATE TABLE IF NOT EXISTS alerts (
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     resolved_at DATETIME,
     acknowledged_at DATETIME,
+    suppressed_until DATETIME, -- silenced until this time (status = suppressed)
     
     FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE
 );
 
+-- Alert types silenced with shipyard alerts silence, until the silence ends
+CREATE TABLE IF NOT EXISTS alert_silences (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    app_id INTEGER NOT NULL,
+    alert_type TEXT NOT NULL,
+    silenced_until DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    
+    FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE,
+    UNIQUE (app_id, alert_type)
+);
+
 CREATE TABLE IF NOT EXISTS events (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     app_id INTEGER, -- NULL for cluster-wide events
@@ -220,6 +238,25 @@ CREATE TABLE IF NOT EXISTS monitoring_config (
     FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE
 );
 
+-- Alert notification channels
+CREATE TABLE IF NOT EXISTS notification_channels (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    channel_type TEXT NOT NULL CHECK (channel_type IN ('webhook', 'slack', 'email')),
+    target TEXT NOT NULL, -- webhook URL, or comma-separated recipients for email
+    app_id INTEGER, -- NULL for all apps
+    min_severity TEXT NOT NULL DEFAULT 'warning' CHECK (min_severity IN ('info', 'warning', 'critical')),
+    smtp_host TEXT,
+    smtp_port INTEGER DEFAULT 587,
+    smtp_username TEXT,
+    smtp_password TEXT,
+    smtp_from TEXT,
+    enabled BOOLEAN DEFAULT TRUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE
+);
+
 -- Indexes for performance
 CREATE INDEX IF NOT EXISTS idx_metrics_app_timestamp ON metrics(app_id, timestamp DESC);
 CREATE INDEX IF NOT EXISTS idx_metrics_type_timestamp ON metrics(metric_type, timestamp DESC);
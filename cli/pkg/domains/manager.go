@@ -2,6 +2,7 @@ package domains
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -287,6 +288,69 @@ func (m *Manager) SyncDomainsFromConfig(appName string, configDomains []string)
 	return nil
 }
 
+// PlanDomainsFromConfig returns, by base domain, the domains that
+// SyncDomainsFromConfig would leave in the database for the base domains of
+// the config, without changing the database
+func (m *Manager) PlanDomainsFromConfig(appName string, configDomains []string) (map[string][]Domain, error) {
+	currentDomains, err := m.GetDomainsForApp(appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current domains: %w", err)
+	}
+
+	currentMap := make(map[string]Domain)
+	for _, domain := range currentDomains {
+		currentMap[domain.Hostname] = domain
+	}
+
+	planned := make(map[string][]Domain)
+	for _, hostname := range configDomains {
+		baseDomain := extractBaseDomain(hostname)
+		if _, ok := planned[baseDomain]; ok {
+			continue
+		}
+
+		// Keep the domains of the other apps
+		domains, err := m.GetDomainsByBaseDomain(baseDomain)
+		if err != nil {
+			return nil, err
+		}
+		for _, domain := range domains {
+			if domain.AppName != appName {
+				planned[baseDomain] = append(planned[baseDomain], domain)
+			}
+		}
+	}
+
+	for _, hostname := range configDomains {
+		baseDomain := extractBaseDomain(hostname)
+		for _, domain := range planned[baseDomain] {
+			if domain.Hostname == hostname {
+				return nil, fmt.Errorf("domain %s is already used by app %s", hostname, domain.AppName)
+			}
+		}
+
+		domain, ok := currentMap[hostname]
+		if !ok {
+			domain = Domain{
+				AppName:    appName,
+				Hostname:   hostname,
+				BaseDomain: baseDomain,
+				Path:       "/",
+				SSLEnabled: true,
+			}
+		}
+		planned[baseDomain] = append(planned[baseDomain], domain)
+	}
+
+	for _, domains := range planned {
+		sort.Slice(domains, func(i, j int) bool {
+			return domains[i].Hostname < domains[j].Hostname
+		})
+	}
+
+	return planned, nil
+}
+
 // GetBaseDomains returns all unique base domains
 func (m *Manager) GetBaseDomains() ([]string, error) {
 	query := `SELECT DISTINCT base_domain FROM domains ORDER BY base_domain`
@@ -0,0 +1,120 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// FieldManager is the field manager of the objects Shipyard applies. Fields
+// set by others, such as the replicas of an autoscaler, are left alone.
+const FieldManager = "shipyard"
+
+// conflictManagerPattern extracts the field manager from a conflict message
+// such as: conflict with "kubectl-edit" using apps/v1
+var conflictManagerPattern = regexp.MustCompile(`conflict with "([^"]*)"`)
+
+// FieldConflict is a field of an object owned by another field manager
+type FieldConflict struct {
+	Field   string
+	Manager string
+	Message string
+}
+
+// ConflictError reports the fields of an object that server-side apply would
+// take over from other field managers
+type ConflictError struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Conflicts []FieldConflict
+}
+
+func (e *ConflictError) Error() string {
+	name := e.Name
+	if e.Namespace != "" {
+		name = e.Namespace + "/" + e.Name
+	}
+
+	lines := []string{fmt.Sprintf("%s %s has fields managed by other tools:", e.Kind, name)}
+	for _, conflict := range e.Conflicts {
+		lines = append(lines, fmt.Sprintf("  %s: %s", conflict.Field, conflict.Message))
+	}
+	lines = append(lines, "remove them from the manifests, or rerun with --force-conflicts to take them over")
+	return strings.Join(lines, "\n")
+}
+
+// SetForceConflicts makes apply take over the fields owned by other field
+// managers instead of failing
+func (c *Client) SetForceConflicts(force bool) {
+	c.forceConflicts = force
+}
+
+// applyObject applies an object with server-side apply, or with a dry run of
+// it, and returns the object as stored by the cluster. Conflicts with the
+// earlier updates of Shipyard itself, such as shipyard scale, are taken over;
+// the others fail with a ConflictError unless conflicts are forced.
+func (c *Client) applyObject(resourceClient dynamic.ResourceInterface, obj *unstructured.Unstructured, dryRun bool) (*unstructured.Unstructured, error) {
+	options := metav1.ApplyOptions{
+		FieldManager: FieldManager,
+		Force:        c.forceConflicts,
+	}
+	if dryRun {
+		options.DryRun = []string{metav1.DryRunAll}
+	}
+
+	applied, err := resourceClient.Apply(context.TODO(), obj.GetName(), obj, options)
+	if err == nil || !errors.IsConflict(err) {
+		return applied, err
+	}
+
+	conflicts := fieldConflicts(err)
+	if len(conflicts) == 0 {
+		return nil, err
+	}
+
+	ownConflicts := true
+	for _, conflict := range conflicts {
+		if conflict.Manager != FieldManager {
+			ownConflicts = false
+		}
+	}
+	if ownConflicts {
+		options.Force = true
+		return resourceClient.Apply(context.TODO(), obj.GetName(), obj, options)
+	}
+
+	return nil, &ConflictError{
+		Kind:      obj.GetKind(),
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		Conflicts: conflicts,
+	}
+}
+
+// fieldConflicts returns the field manager conflicts of an apply error
+func fieldConflicts(err error) []FieldConflict {
+	statusErr, ok := err.(errors.APIStatus)
+	if !ok || statusErr.Status().Details == nil {
+		return nil
+	}
+
+	var conflicts []FieldConflict
+	for _, cause := range statusErr.Status().Details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		conflict := FieldConflict{Field: cause.Field, Message: cause.Message}
+		if match := conflictManagerPattern.FindStringSubmatch(cause.Message); match != nil {
+			conflict.Manager = match[1]
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts
+}
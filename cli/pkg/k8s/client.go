@@ -14,14 +14,17 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/runtime/serializer/yaml"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	metricsclientset "k8s.io/metrics/pkg/client/clientset/versioned"
@@ -29,11 +32,16 @@ import (
 
 // Client wraps Kubernetes client functionality
 type Client struct {
-	clientset     kubernetes.Interface
-	dynamicClient dynamic.Interface
-	metricsClient metricsclientset.Interface
-	config        *rest.Config
-	namespace     string
+	clientset      kubernetes.Interface
+	dynamicClient  dynamic.Interface
+	metricsClient  metricsclientset.Interface
+	config         *rest.Config
+	namespace      string
+	discovery      discovery.CachedDiscoveryInterface      // Resources of the cluster, cached for the life of the client
+	mapper         *restmapper.DeferredDiscoveryRESTMapper // Resources and scopes of kinds, from the discovery API
+	forceConflicts bool                                    // Take over the fields of other field managers on apply
+	rolloutTimeout time.Duration                           // How long apply waits for the deployments of an app
+	inCluster      bool                                    // Configured from the service account of a pod
 }
 
 // LogsOptions configures log retrieval
@@ -45,7 +53,7 @@ type LogsOptions struct {
 // NewClient creates a new Kubernetes client
 func NewClient() (*Client, error) {
 	// Try to load kubeconfig
-	config, err := loadKubeConfig()
+	config, inCluster, err := loadKubeConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
 	}
@@ -68,6 +76,10 @@ func NewClient() (*Client, error) {
 		return nil, fmt.Errorf("failed to create metrics client: %w", err)
 	}
 
+	// Resolve kinds through the discovery API, cached for the life of the client
+	cachedDiscovery := memory.NewMemCacheClient(clientset.Discovery())
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(cachedDiscovery)
+
 	// Use default namespace or from context
 	namespace := "default"
 	if ns := os.Getenv("SHIPYARD_NAMESPACE"); ns != "" {
@@ -75,42 +87,53 @@ func NewClient() (*Client, error) {
 	}
 
 	return &Client{
-		clientset:     clientset,
-		dynamicClient: dynamicClient,
-		metricsClient: metricsClient,
-		config:        config,
-		namespace:     namespace,
+		clientset:      clientset,
+		dynamicClient:  dynamicClient,
+		metricsClient:  metricsClient,
+		config:         config,
+		namespace:      namespace,
+		discovery:      cachedDiscovery,
+		mapper:         mapper,
+		rolloutTimeout: DefaultRolloutTimeout,
+		inCluster:      inCluster,
 	}, nil
 }
 
+// InCluster reports whether the client runs in a pod, with the service
+// account of the pod
+func (c *Client) InCluster() bool {
+	return c.inCluster
+}
+
 // ApplyManifests applies all manifests for an application
 func (c *Client) ApplyManifests(appName string) error {
-	return c.ApplyManifestsWithNamespace(appName, appName)
+	return c.ApplyManifestsWithNamespace(appName, appName, "")
 }
 
-// ApplyManifestsWithNamespace applies all manifests for an application with specific namespace
-func (c *Client) ApplyManifestsWithNamespace(appName, appNamespace string) error {
+// ApplyManifestsWithNamespace applies all manifests for an application with specific namespace.
+// The objects of the app are labelled as owned by the app and the version.
+func (c *Client) ApplyManifestsWithNamespace(appName, appNamespace, version string) error {
 	// Get app directory from global config
 	appsDir, err := config.GetAppsDir()
 	if err != nil {
 		return fmt.Errorf("failed to get apps directory: %w", err)
 	}
 	appDir := filepath.Join(appsDir, appName)
-	
+
 	// Apply shared manifests first (including namespaces)
 	sharedDir, err := config.GetSharedDir()
 	if err != nil {
 		return fmt.Errorf("failed to get shared directory: %w", err)
 	}
-	
+
 	if _, err := os.Stat(sharedDir); err == nil {
-		if err := c.applyManifestsFromDir(sharedDir); err != nil {
+		if err := c.applyManifestsFromDir(sharedDir, nil); err != nil {
 			return fmt.Errorf("failed to apply shared manifests: %w", err)
 		}
 	}
 
 	// Apply app manifests after shared manifests
-	if err := c.applyManifestsFromDir(appDir); err != nil {
+	if err := c.applyManifestsFromDir(appDir, OwnerLabels(appName, appNamespace, version)); err != nil {
 		return fmt.Errorf("failed to apply app manifests: %w", err)
 	}
 
@@ -120,18 +143,39 @@ func (c *Client) ApplyManifestsWithNamespace(appName, appNamespace string) error
 		fmt.Printf("⚠️  Warning: failed to copy registry secrets: %v\n", err)
 	}
 
-	// Wait for deployment to be ready
+	// Wait for deployment to be ready, all the deployments of the app sharing the timeout
+	deadline := time.Now().Add(c.rolloutTimeout)
 	dnsName := appName // TODO: Add DNS validation warning if needed
 	fmt.Printf("⏳ Waiting for deployment %s to be ready...\n", dnsName)
-	if err := c.waitForDeployment(dnsName, appNamespace, 5*time.Minute); err != nil {
+	if err := c.waitForDeployment(dnsName, appNamespace, remainingTimeout(deadline)); err != nil {
 		return fmt.Errorf("deployment failed to become ready: %w", err)
 	}
 
+	// Wait for the deployments of the other processes of the app
+	processes, err := c.clientset.AppsV1().Deployments(appNamespace).List(
+		context.TODO(), metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("shipyard.app=%s", dnsName),
+		})
+	if err != nil {
+		fmt.Printf("⚠️  Warning: failed to list process deployments: %v\n", err)
+		return nil
+	}
+	for _, deployment := range processes.Items {
+		if deployment.Name == dnsName {
+			continue
+		}
+		fmt.Printf("⏳ Waiting for deployment %s to be ready...\n", deployment.Name)
+		if err := c.waitForDeployment(deployment.Name, appNamespace, remainingTimeout(deadline)); err != nil {
+			return fmt.Errorf("deployment %s failed to become ready: %w", deployment.Name, err)
+		}
+	}
+
 	return nil
 }
 
-// applyManifestsFromDir applies all YAML files in a directory
-func (c *Client) applyManifestsFromDir(dir string) error {
+// applyManifestsFromDir applies all YAML and JSON files in a directory,
+// adding labels to every object
+func (c *Client) applyManifestsFromDir(dir string, labels map[string]string) error {
 	files, err := ioutil.ReadDir(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -141,98 +185,49 @@ func (c *Client) applyManifestsFromDir(dir string) error {
 	}
 
 	for _, file := range files {
-		if !strings.HasSuffix(file.Name(), ".yaml") && !strings.HasSuffix(file.Name(), ".yml") {
+		if !isManifestFile(file.Name()) {
 			continue
 		}
 
 		filePath := filepath.Join(dir, file.Name())
-		if err := c.applyManifest(filePath); err != nil {
+		if err := c.applyManifest(filePath, labels); err != nil {
 			return fmt.Errorf("failed to apply %s: %w", filePath, err)
 		}
-		
+
 		fmt.Printf("✅ Applied: %s\n", filePath)
 	}
 
 	return nil
 }
 
-// applyManifest applies a single YAML manifest file
-func (c *Client) applyManifest(filename string) error {
-	data, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return fmt.Errorf("failed to read manifest file: %w", err)
-	}
-
-	// Handle multiple documents in one file
-	documents := strings.Split(string(data), "---")
-	
-	for _, doc := range documents {
-		doc = strings.TrimSpace(doc)
-		if doc == "" {
-			continue
-		}
-
-		if err := c.applyYAMLDocument([]byte(doc)); err != nil {
-			return fmt.Errorf("failed to apply document: %w", err)
+// applyManifest applies the objects of a single manifest file
+func (c *Client) applyManifest(filename string, labels map[string]string) error {
+	return eachManifestObject(filename, func(obj *unstructured.Unstructured) error {
+		if err := c.applyManifestObject(obj, labels); err != nil {
+			return fmt.Errorf("failed to apply %s %s: %w", obj.GetKind(), obj.GetName(), err)
 		}
-	}
-
-	return nil
+		return nil
+	})
 }
 
-// applyYAMLDocument applies a single YAML document
-func (c *Client) applyYAMLDocument(data []byte) error {
-	// Decode YAML to unstructured object
-	decoder := yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme)
-	obj := &unstructured.Unstructured{}
-	
-	if _, _, err := decoder.Decode(data, nil, obj); err != nil {
-		return fmt.Errorf("failed to decode YAML: %w", err)
-	}
+// applyManifestObject applies a single object of a manifest
+func (c *Client) applyManifestObject(obj *unstructured.Unstructured, labels map[string]string) error {
+	setLabels(obj, labels)
 
-	// Set namespace if not specified (except for cluster-scoped resources like Namespace)
-	if obj.GetNamespace() == "" && obj.GetKind() != "Namespace" {
-		obj.SetNamespace(c.namespace)
-	}
-
-	// Use kubectl-like apply logic: create or update
-	gvr, err := c.getGVRForObject(obj)
+	resourceClient, err := c.resourceFor(obj)
 	if err != nil {
-		return fmt.Errorf("failed to get GVR: %w", err)
-	}
-
-	// Determine if resource is cluster-scoped or namespaced
-	var resourceClient dynamic.ResourceInterface
-	if obj.GetKind() == "Namespace" {
-		// Cluster-scoped resource
-		resourceClient = c.dynamicClient.Resource(gvr)
-	} else {
-		// Namespaced resource
-		resourceClient = c.dynamicClient.Resource(gvr).Namespace(obj.GetNamespace())
-	}
-
-	// Try to get existing resource
-	existing, err := resourceClient.Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
-	
-	if errors.IsNotFound(err) {
-		// Create new resource
-		_, err = resourceClient.Create(context.TODO(), obj, metav1.CreateOptions{})
-		return err
-	} else if err != nil {
 		return err
 	}
 
-	// Update existing resource
-	obj.SetResourceVersion(existing.GetResourceVersion())
-	_, err = resourceClient.Update(context.TODO(), obj, metav1.UpdateOptions{})
-	
+	// Server-side apply creates or updates the fields set by the manifest
+	_, err = c.applyObject(resourceClient, obj, false)
 	return err
 }
 
 // ShowStatus displays the status of applications
 func (c *Client) ShowStatus() error {
 	fmt.Println("📊 Application Status:")
-	
+
 	// Get all deployments with shipyard label
 	deployments, err := c.clientset.AppsV1().Deployments(c.namespace).List(
 		context.TODO(), metav1.ListOptions{
@@ -263,12 +258,12 @@ func (c *Client) ShowStatus() error {
 		replicas := fmt.Sprintf("%d/%d", deployment.Status.ReadyReplicas, deployment.Status.Replicas)
 		age := time.Since(deployment.CreationTimestamp.Time).Truncate(time.Minute)
 
-		fmt.Printf("│%-20s│%-12s│%-10s│%-15s│\n", 
+		fmt.Printf("│%-20s│%-12s│%-10s│%-15s│\n",
 			deployment.Name, status, replicas, age.String())
 	}
 
 	fmt.Printf("└%-20s┴%-12s┴%-10s┴%-15s┘\n", strings.Repeat("─", 20), strings.Repeat("─", 12), strings.Repeat("─", 10), strings.Repeat("─", 15))
-	
+
 	return nil
 }
 
@@ -279,7 +274,7 @@ func (c *Client) GetLogs(appName string, options LogsOptions) error {
 	}
 
 	fmt.Printf("📋 Logs for app: %s\n", appName)
-	
+
 	// Get pods for the app
 	pods, err := c.clientset.CoreV1().Pods(c.namespace).List(
 		context.TODO(), metav1.ListOptions{
@@ -296,11 +291,11 @@ func (c *Client) GetLogs(appName string, options LogsOptions) error {
 	// For now, just get logs from the first pod
 	// TODO: Merge logs from multiple pods
 	pod := pods.Items[0]
-	
+
 	logOptions := &corev1.PodLogOptions{
 		Follow: options.Follow,
 	}
-	
+
 	if options.Since != "" {
 		duration, err := time.ParseDuration(options.Since)
 		if err != nil {
@@ -311,7 +306,7 @@ func (c *Client) GetLogs(appName string, options LogsOptions) error {
 	}
 
 	req := c.clientset.CoreV1().Pods(c.namespace).GetLogs(pod.Name, logOptions)
-	
+
 	logs, err := req.Stream(context.TODO())
 	if err != nil {
 		return fmt.Errorf("failed to stream logs: %w", err)
@@ -323,11 +318,12 @@ func (c *Client) GetLogs(appName string, options LogsOptions) error {
 	return err
 }
 
-// loadKubeConfig loads the Kubernetes configuration
-func loadKubeConfig() (*rest.Config, error) {
+// loadKubeConfig loads the Kubernetes configuration, and reports whether it
+// is the in-cluster one
+func loadKubeConfig() (*rest.Config, bool, error) {
 	// Try in-cluster config first
 	if config, err := rest.InClusterConfig(); err == nil {
-		return config, nil
+		return config, true, nil
 	}
 
 	// Try kubeconfig file
@@ -335,181 +331,105 @@ func loadKubeConfig() (*rest.Config, error) {
 	if kubeconfig == "" {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
-			return nil, fmt.Errorf("failed to get home directory: %w", err)
+			return nil, false, fmt.Errorf("failed to get home directory: %w", err)
 		}
 		kubeconfig = filepath.Join(homeDir, ".kube", "config")
 	}
 
-	return clientcmd.BuildConfigFromFlags("", kubeconfig)
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	return config, false, err
 }
 
-// waitForDeployment waits for a deployment to be ready with detailed status
-func (c *Client) waitForDeployment(name, namespace string, timeout time.Duration) error {
-	lastReplicasReady := int32(-1)
-	lastEventsCount := 0
-	
-	return wait.PollImmediate(2*time.Second, timeout, func() (bool, error) {
-		// Get deployment status
-		deployment, err := c.clientset.AppsV1().Deployments(namespace).Get(
-			context.TODO(), name, metav1.GetOptions{})
-		if err != nil {
-			fmt.Printf("❌ Error getting deployment: %v\n", err)
-			return false, err
-		}
+// resourceFor returns the dynamic client of the resource of an object. The
+// namespace of cluster-scoped objects is cleared, and namespaced objects
+// without one get the namespace of the client.
+func (c *Client) resourceFor(obj *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	mapping, err := c.restMapping(obj.GroupVersionKind())
+	if err != nil {
+		return nil, err
+	}
 
-		// Show replica status if changed
-		if deployment.Status.ReadyReplicas != lastReplicasReady {
-			fmt.Printf("🔄 Replicas: %d/%d ready\n", deployment.Status.ReadyReplicas, deployment.Status.Replicas)
-			lastReplicasReady = deployment.Status.ReadyReplicas
-		}
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		obj.SetNamespace("")
+		return c.dynamicClient.Resource(mapping.Resource), nil
+	}
 
-		// Check for any conditions
-		for _, condition := range deployment.Status.Conditions {
-			if condition.Type == "Progressing" && condition.Status == "False" {
-				fmt.Printf("⚠️  Deployment condition: %s - %s\n", condition.Reason, condition.Message)
-			}
-		}
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace(c.namespace)
+	}
+	return c.dynamicClient.Resource(mapping.Resource).Namespace(obj.GetNamespace()), nil
+}
 
-		// Show recent events related to this deployment
-		events, err := c.clientset.CoreV1().Events(namespace).List(
-			context.TODO(), metav1.ListOptions{
-				FieldSelector: fmt.Sprintf("involvedObject.name=%s", name),
-			})
-		if err == nil && len(events.Items) > lastEventsCount {
-			// Show new events
-			for i := lastEventsCount; i < len(events.Items); i++ {
-				event := events.Items[i]
-				if time.Since(event.CreationTimestamp.Time) < 30*time.Second {
-					fmt.Printf("📋 Event: %s - %s\n", event.Reason, event.Message)
-				}
-			}
-			lastEventsCount = len(events.Items)
-		}
+// restMapping resolves the resource and scope of a kind with the discovery
+// API. The discovery cache is refreshed once when the kind is unknown, for
+// kinds whose CRD was applied since it was filled.
+func (c *Client) restMapping(gvk schema.GroupVersionKind) (*meta.RESTMapping, error) {
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		c.mapper.Reset()
+		mapping, err = c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	if meta.IsNoMatchError(err) {
+		return nil, fmt.Errorf("unknown resource kind %s in %s: is its CRD installed?", gvk.Kind, gvk.GroupVersion())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve resource kind %s: %w", gvk.Kind, err)
+	}
+	return mapping, nil
+}
 
-		// Also check pod status for more detailed info
-		pods, err := c.clientset.CoreV1().Pods(c.namespace).List(
-			context.TODO(), metav1.ListOptions{
-				LabelSelector: fmt.Sprintf("app=%s", name),
-			})
-		if err == nil {
-			for _, pod := range pods.Items {
-				if pod.Status.Phase == "Pending" {
-					for _, condition := range pod.Status.Conditions {
-						if condition.Type == "PodScheduled" && condition.Status == "False" {
-							fmt.Printf("⏳ Pod %s: %s - %s\n", pod.Name, condition.Reason, condition.Message)
-						}
-					}
-					// Show container statuses for pending pods
-					for _, containerStatus := range pod.Status.ContainerStatuses {
-						if containerStatus.State.Waiting != nil {
-							fmt.Printf("📦 Container %s: %s - %s\n", 
-								containerStatus.Name, 
-								containerStatus.State.Waiting.Reason, 
-								containerStatus.State.Waiting.Message)
-						}
-					}
-				}
-				if pod.Status.Phase == "Running" {
-					// Show if containers are still starting
-					for _, containerStatus := range pod.Status.ContainerStatuses {
-						if !containerStatus.Ready {
-							if containerStatus.State.Running != nil {
-								fmt.Printf("🚀 Container %s starting...\n", containerStatus.Name)
-								// Show recent logs for starting containers
-								c.showRecentLogs(pod.Name, containerStatus.Name, 5)
-							}
-						}
-					}
-				}
-				if pod.Status.Phase == "Failed" {
-					fmt.Printf("❌ Pod %s failed: %s\n", pod.Name, pod.Status.Message)
-					// Show logs for failed pods
-					c.showRecentLogs(pod.Name, "", 10)
-				}
-			}
-		}
+// Monitoring and Metrics Methods
 
-		// Check if deployment is ready
-		isReady := deployment.Status.ReadyReplicas == deployment.Status.Replicas && 
-				   deployment.Status.Replicas > 0
-		
-		if isReady {
-			fmt.Printf("✅ Deployment %s is ready!\n", name)
-		}
-		
-		return isReady, nil
-	})
+// processPodsSelector selects the pods of every process of an app, but not
+// those of its jobs
+func processPodsSelector(appName string) string {
+	return fmt.Sprintf("shipyard.app=%s,shipyard.process", appName)
 }
 
-// getGVRForObject determines the GroupVersionResource for a Kubernetes object
-func (c *Client) getGVRForObject(obj *unstructured.Unstructured) (schema.GroupVersionResource, error) {
-	gvk := obj.GroupVersionKind()
-	
-	// Map common resources - in a real implementation, you'd use discovery client
-	resourceMap := map[string]string{
-		"Deployment":             "deployments",
-		"Service":                "services",
-		"Secret":                 "secrets",
-		"ConfigMap":              "configmaps",
-		"Ingress":                "ingresses",
-		"HorizontalPodAutoscaler": "horizontalpodautoscalers",
-		"Namespace":              "namespaces",
-	}
-	
-	resource, ok := resourceMap[gvk.Kind]
-	if !ok {
-		return schema.GroupVersionResource{}, fmt.Errorf("unsupported resource kind: %s", gvk.Kind)
-	}
-	
-	// Handle different API groups
-	var group string
-	switch gvk.Kind {
-	case "Deployment":
-		group = "apps"
-	case "Service", "Secret", "ConfigMap", "Namespace":
-		group = ""
-	case "Ingress":
-		group = "networking.k8s.io"
-	case "HorizontalPodAutoscaler":
-		group = "autoscaling"
-	}
-	
-	return schema.GroupVersionResource{
-		Group:    group,
-		Version:  gvk.Version,
-		Resource: resource,
-	}, nil
+// legacyPodsSelector selects the pods of the web process of an app deployed
+// before pods carried the shipyard.app label
+func legacyPodsSelector(appName string) string {
+	return fmt.Sprintf("app=%s", appName)
 }
 
-// Monitoring and Metrics Methods
-
-// GetPods returns pods for a given app
-func (c *Client) GetPods(appName string) ([]corev1.Pod, error) {
-	pods, err := c.clientset.CoreV1().Pods(c.namespace).List(
-		context.TODO(), metav1.ListOptions{
-			LabelSelector: fmt.Sprintf("app=%s", appName),
-		})
-	if err != nil {
-		return nil, err
+// GetPods returns the pods of every process of an app deployed to a namespace
+func (c *Client) GetPods(appName, namespace string) ([]corev1.Pod, error) {
+	var pods *corev1.PodList
+	var err error
+	for _, selector := range []string{processPodsSelector(appName), legacyPodsSelector(appName)} {
+		pods, err = c.clientset.CoreV1().Pods(namespace).List(
+			context.TODO(), metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, err
+		}
+		if len(pods.Items) > 0 {
+			break
+		}
 	}
 	return pods.Items, nil
 }
 
-// GetPodMetrics returns metrics for pods of a given app
-func (c *Client) GetPodMetrics(appName string) ([]metricsv1beta1.PodMetrics, error) {
-	podMetrics, err := c.metricsClient.MetricsV1beta1().PodMetricses(c.namespace).List(
-		context.TODO(), metav1.ListOptions{
-			LabelSelector: fmt.Sprintf("app=%s", appName),
-		})
-	if err != nil {
-		return nil, err
+// GetPodMetrics returns the metrics of the pods of every process of an app
+// deployed to a namespace
+func (c *Client) GetPodMetrics(appName, namespace string) ([]metricsv1beta1.PodMetrics, error) {
+	var podMetrics *metricsv1beta1.PodMetricsList
+	var err error
+	for _, selector := range []string{processPodsSelector(appName), legacyPodsSelector(appName)} {
+		podMetrics, err = c.metricsClient.MetricsV1beta1().PodMetricses(namespace).List(
+			context.TODO(), metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, err
+		}
+		if len(podMetrics.Items) > 0 {
+			break
+		}
 	}
 	return podMetrics.Items, nil
 }
 
-// GetDeployment returns a specific deployment
-func (c *Client) GetDeployment(appName string) (*appsv1.Deployment, error) {
-	deployment, err := c.clientset.AppsV1().Deployments(c.namespace).Get(
+// GetDeployment returns the deployment of an app in a namespace
+func (c *Client) GetDeployment(appName, namespace string) (*appsv1.Deployment, error) {
+	deployment, err := c.clientset.AppsV1().Deployments(namespace).Get(
 		context.TODO(), appName, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
@@ -517,31 +437,72 @@ func (c *Client) GetDeployment(appName string) (*appsv1.Deployment, error) {
 	return deployment, nil
 }
 
-// GetService returns a specific service
-func (c *Client) GetService(appName string) (*corev1.Service, error) {
-	service, err := c.clientset.CoreV1().Services(c.namespace).Get(
-		context.TODO(), appName, metav1.GetOptions{})
+// ScaleDeployment sets the number of replicas of a deployment
+func (c *Client) ScaleDeployment(name, namespace string, replicas int32) error {
+	scale, err := c.clientset.AppsV1().Deployments(namespace).GetScale(
+		context.TODO(), name, metav1.GetOptions{})
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return service, nil
+
+	scale.Spec.Replicas = replicas
+	_, err = c.clientset.AppsV1().Deployments(namespace).UpdateScale(
+		context.TODO(), name, scale, metav1.UpdateOptions{FieldManager: FieldManager})
+	return err
 }
 
-// GetEvents returns Kubernetes events for an app or cluster-wide
-func (c *Client) GetEvents(appName string) ([]corev1.Event, error) {
-	var labelSelector string
-	if appName != "" {
-		labelSelector = fmt.Sprintf("involvedObject.name=%s", appName)
-	}
+// ManagedApp is an app deployed by shipyard, found in the cluster
+type ManagedApp struct {
+	Name      string // DNS name of the app
+	Namespace string
+}
 
-	events, err := c.clientset.CoreV1().Events(c.namespace).List(
+// ListManagedApps returns the apps deployed by shipyard in every namespace,
+// from the labels of their deployments
+func (c *Client) ListManagedApps() ([]ManagedApp, error) {
+	deployments, err := c.clientset.AppsV1().Deployments(metav1.NamespaceAll).List(
 		context.TODO(), metav1.ListOptions{
-			LabelSelector: labelSelector,
+			LabelSelector: "managed-by=shipyard,shipyard.app",
 		})
 	if err != nil {
 		return nil, err
 	}
-	return events.Items, nil
+
+	var apps []ManagedApp
+	seen := make(map[ManagedApp]bool)
+	for _, deployment := range deployments.Items {
+		app := ManagedApp{Name: deployment.Labels["shipyard.app"], Namespace: deployment.Namespace}
+		if !seen[app] {
+			seen[app] = true
+			apps = append(apps, app)
+		}
+	}
+	return apps, nil
+}
+
+// GetService returns the service of an app in a namespace
+func (c *Client) GetService(appName, namespace string) (*corev1.Service, error) {
+	service, err := c.clientset.CoreV1().Services(namespace).Get(
+		context.TODO(), appName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
+// GetEvents returns the Kubernetes events of a namespace, or of all
+// namespaces when namespace is empty
+func (c *Client) GetEvents(namespace string) (*corev1.EventList, error) {
+	return c.clientset.CoreV1().Events(namespace).List(context.TODO(), metav1.ListOptions{})
+}
+
+// WatchEvents watches the Kubernetes events of a namespace (all namespaces
+// when empty), starting after resourceVersion
+func (c *Client) WatchEvents(ctx context.Context, namespace, resourceVersion string) (watch.Interface, error) {
+	return c.clientset.CoreV1().Events(namespace).Watch(ctx, metav1.ListOptions{
+		ResourceVersion:     resourceVersion,
+		AllowWatchBookmarks: true,
+	})
 }
 
 // GetNodesMetrics returns node metrics for cluster health
@@ -594,18 +555,20 @@ func (c *Client) IsMetricsServerAvailable() bool {
 	return err == nil
 }
 
-// showRecentLogs displays recent logs from a pod/container
-func (c *Client) showRecentLogs(podName, containerName string, lines int) {
+// showRecentLogs displays recent logs from a pod/container, or from its
+// previous instance when it crashed
+func (c *Client) showRecentLogs(namespace, podName, containerName string, lines int, previous bool) {
 	logOptions := &corev1.PodLogOptions{
 		TailLines: int64Ptr(int64(lines)),
+		Previous:  previous,
 	}
-	
+
 	if containerName != "" {
 		logOptions.Container = containerName
 	}
 
-	req := c.clientset.CoreV1().Pods(c.namespace).GetLogs(podName, logOptions)
-	
+	req := c.clientset.CoreV1().Pods(namespace).GetLogs(podName, logOptions)
+
 	logs, err := req.Stream(context.TODO())
 	if err != nil {
 		fmt.Printf("   (could not get logs: %v)\n", err)
@@ -619,7 +582,7 @@ func (c *Client) showRecentLogs(podName, containerName string, lines int) {
 	if err != nil && err != io.EOF {
 		return
 	}
-	
+
 	if n > 0 {
 		logStr := string(logData[:n])
 		fmt.Printf("   📝 Last logs:\n")
@@ -638,18 +601,29 @@ func int64Ptr(i int64) *int64 {
 
 // DeleteManifests deletes all manifests for an application
 func (c *Client) DeleteManifests(appName string) error {
-	appDir := filepath.Join("manifests", "apps", appName)
-	
+	// Get app directory from global config
+	appsDir, err := config.GetAppsDir()
+	if err != nil {
+		return fmt.Errorf("failed to get apps directory: %w", err)
+	}
+	appDir := filepath.Join(appsDir, appName)
+
 	// Delete app manifests
-	if err := c.deleteManifestsFromDir(appDir); err != nil {
+	if err := c.DeleteManifestsInDir(appDir); err != nil {
 		return fmt.Errorf("failed to delete app manifests: %w", err)
 	}
 
 	return nil
 }
 
-// deleteManifestsFromDir deletes all resources defined in YAML files in a directory
-func (c *Client) deleteManifestsFromDir(dir string) error {
+// DeleteManifestsInDir deletes all resources defined in the manifest files of a
+// directory, except those of the given files
+func (c *Client) DeleteManifestsInDir(dir string, except ...string) error {
+	skip := make(map[string]bool)
+	for _, name := range except {
+		skip[name] = true
+	}
+
 	files, err := ioutil.ReadDir(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -661,7 +635,11 @@ func (c *Client) deleteManifestsFromDir(dir string) error {
 	// Delete in reverse order to handle dependencies
 	for i := len(files) - 1; i >= 0; i-- {
 		file := files[i]
-		if !strings.HasSuffix(file.Name(), ".yaml") && !strings.HasSuffix(file.Name(), ".yml") {
+		if !isManifestFile(file.Name()) {
+			continue
+		}
+		if skip[file.Name()] {
+			fmt.Printf("⏭️  Kept: %s\n", filepath.Join(dir, file.Name()))
 			continue
 		}
 
@@ -670,83 +648,55 @@ func (c *Client) deleteManifestsFromDir(dir string) error {
 			fmt.Printf("⚠️  Warning: Failed to delete %s: %v\n", filePath, err)
 			continue // Continue with other files
 		}
-		
+
 		fmt.Printf("🗑️  Deleted: %s\n", filePath)
 	}
 
 	return nil
 }
 
-// deleteManifest deletes a single YAML manifest file
+// deleteManifest deletes the objects of a single manifest file
 func (c *Client) deleteManifest(filename string) error {
-	data, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return fmt.Errorf("failed to read manifest file: %w", err)
-	}
-
-	// Handle multiple documents in one file
-	documents := strings.Split(string(data), "---")
-	
-	for _, doc := range documents {
-		doc = strings.TrimSpace(doc)
-		if doc == "" {
-			continue
+	return eachManifestObject(filename, func(obj *unstructured.Unstructured) error {
+		if err := c.deleteManifestObject(obj); err != nil {
+			return fmt.Errorf("failed to delete %s %s: %w", obj.GetKind(), obj.GetName(), err)
 		}
-
-		if err := c.deleteYAMLDocument([]byte(doc)); err != nil {
-			return fmt.Errorf("failed to delete document: %w", err)
-		}
-	}
-
-	return nil
+		return nil
+	})
 }
 
-// deleteYAMLDocument deletes a single YAML document
-func (c *Client) deleteYAMLDocument(data []byte) error {
-	// Decode YAML to unstructured object
-	decoder := yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme)
-	obj := &unstructured.Unstructured{}
-	
-	if _, _, err := decoder.Decode(data, nil, obj); err != nil {
-		return fmt.Errorf("failed to decode YAML: %w", err)
-	}
-
-	// Set namespace if not specified (except for cluster-scoped resources like Namespace)
-	if obj.GetNamespace() == "" && obj.GetKind() != "Namespace" {
-		obj.SetNamespace(c.namespace)
-	}
-
-	// Get GVR for the object
-	gvr, err := c.getGVRForObject(obj)
+// deleteManifestObject deletes a single object of a manifest
+func (c *Client) deleteManifestObject(obj *unstructured.Unstructured) error {
+	// Delete the resource
+	resourceClient, err := c.resourceFor(obj)
 	if err != nil {
-		return fmt.Errorf("failed to get GVR: %w", err)
+		return err
 	}
 
-	// Delete the resource
-	var resourceClient dynamic.ResourceInterface
-	if obj.GetKind() == "Namespace" {
-		// Cluster-scoped resource
-		resourceClient = c.dynamicClient.Resource(gvr)
-	} else {
-		// Namespaced resource
-		resourceClient = c.dynamicClient.Resource(gvr).Namespace(obj.GetNamespace())
-	}
-	
 	err = resourceClient.Delete(context.TODO(), obj.GetName(), metav1.DeleteOptions{})
-	
+
 	if errors.IsNotFound(err) {
 		// Resource already deleted, that's fine
 		return nil
 	}
-	
+
 	return err
 }
 
+// ListVolumeClaims returns the PersistentVolumeClaims matching a label selector
+func (c *Client) ListVolumeClaims(namespace, labelSelector string) ([]corev1.PersistentVolumeClaim, error) {
+	claims, err := c.clientset.CoreV1().PersistentVolumeClaims(namespace).List(
+		context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, err
+	}
+	return claims.Items, nil
+}
 
 // DeleteResourcesByApp deletes all resources for an app by label selector
 func (c *Client) DeleteResourcesByApp(appName string) error {
 	labelSelector := fmt.Sprintf("app=%s", appName)
-	
+
 	// Delete common resource types
 	resourceTypes := []struct {
 		resource string
@@ -784,11 +734,11 @@ func (c *Client) GetServiceClusterIP(serviceName, namespace string) (string, err
 	if err != nil {
 		return "", fmt.Errorf("failed to get service %s in namespace %s: %w", serviceName, namespace, err)
 	}
-	
+
 	if service.Spec.ClusterIP == "" || service.Spec.ClusterIP == "None" {
 		return "", fmt.Errorf("service %s has no ClusterIP", serviceName)
 	}
-	
+
 	return service.Spec.ClusterIP, nil
 }
 
@@ -798,7 +748,7 @@ func (c *Client) UpdateEndpointsIP(endpointsName, namespace, ip string, port int
 	if err != nil {
 		return fmt.Errorf("failed to get endpoints %s: %w", endpointsName, err)
 	}
-	
+
 	// Update the IP address
 	if len(endpoints.Subsets) > 0 && len(endpoints.Subsets[0].Addresses) > 0 {
 		endpoints.Subsets[0].Addresses[0].IP = ip
@@ -815,12 +765,12 @@ func (c *Client) UpdateEndpointsIP(endpointsName, namespace, ip string, port int
 			},
 		}
 	}
-	
+
 	_, err = c.clientset.CoreV1().Endpoints(namespace).Update(context.TODO(), endpoints, metav1.UpdateOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to update endpoints %s: %w", endpointsName, err)
 	}
-	
+
 	return nil
 }
 
@@ -833,29 +783,37 @@ func (c *Client) CopyRegistrySecretsFromDefault(targetNamespace string) error {
 	if err != nil {
 		return fmt.Errorf("failed to list secrets in default namespace: %w", err)
 	}
-	
+
 	for _, secret := range secrets.Items {
+		// The copies belong to no app, so that no prune deletes them
+		labels := make(map[string]string)
+		for key, value := range secret.Labels {
+			if key != OwnerLabel && key != OwnerVersionLabel {
+				labels[key] = value
+			}
+		}
+
 		// Create a copy in the target namespace
 		newSecret := &corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      secret.Name,
 				Namespace: targetNamespace,
-				Labels:    secret.Labels,
+				Labels:    labels,
 			},
 			Type: secret.Type,
 			Data: secret.Data,
 		}
-		
+
 		// Try to create the secret, ignore if it already exists
 		_, err := c.clientset.CoreV1().Secrets(targetNamespace).Create(context.TODO(), newSecret, metav1.CreateOptions{})
 		if err != nil && !errors.IsAlreadyExists(err) {
 			return fmt.Errorf("failed to copy secret %s to namespace %s: %w", secret.Name, targetNamespace, err)
 		}
-		
+
 		if err == nil {
 			fmt.Printf("📋 Copied registry secret %s to namespace %s\n", secret.Name, targetNamespace)
 		}
 	}
-	
+
 	return nil
-}
\ No newline at end of file
+}
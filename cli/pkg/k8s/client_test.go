@@ -0,0 +1,136 @@
+package k8s
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+func TestListManagedApps(t *testing.T) {
+	deployment := func(namespace, name string, labels map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels}}
+	}
+	managed := func(app string) map[string]string {
+		return map[string]string{"managed-by": "shipyard", "shipyard.app": app}
+	}
+
+	client := &Client{clientset: fake.NewSimpleClientset(
+		deployment("shop", "shop", managed("shop")),
+		deployment("shop", "shop-worker", managed("shop")),
+		deployment("blog", "blog", managed("blog")),
+		deployment("shop-staging", "shop-staging", managed("shop-staging")),
+		deployment("tools", "grafana", map[string]string{"app": "grafana"}),
+		deployment("tools", "legacy", map[string]string{"managed-by": "shipyard"}),
+	)}
+
+	apps, err := client.ListManagedApps()
+	if err != nil {
+		t.Fatalf("ListManagedApps() failed: %v", err)
+	}
+
+	got := make(map[ManagedApp]bool)
+	for _, app := range apps {
+		if got[app] {
+			t.Errorf("app %+v listed twice", app)
+		}
+		got[app] = true
+	}
+	want := map[ManagedApp]bool{
+		{Name: "shop", Namespace: "shop"}:                 true,
+		{Name: "blog", Namespace: "blog"}:                 true,
+		{Name: "shop-staging", Namespace: "shop-staging"}: true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListManagedApps() = %v, want %v", apps, want)
+	}
+}
+
+func TestGetPods(t *testing.T) {
+	pod := func(name string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: name, Labels: labels}}
+	}
+	podMetrics := func(name string, labels map[string]string) *metricsv1beta1.PodMetrics {
+		return &metricsv1beta1.PodMetrics{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: name, Labels: labels}}
+	}
+	process := func(resource, process string) map[string]string {
+		return map[string]string{"app": resource, "shipyard.app": "shop", "shipyard.process": process}
+	}
+	job := map[string]string{"app": "shop-cleanup", "shipyard.app": "shop", "shipyard.job": "cleanup"}
+
+	tests := []struct {
+		name  string
+		pods  map[string]map[string]string
+		want []string
+	}{
+		{
+			name: "every process",
+			pods: map[string]map[string]string{
+				"shop-1":         process("shop", "web"),
+				"shop-worker-1":  process("shop-worker", "worker"),
+				"shop-worker-2":  process("shop-worker", "worker"),
+				"shop-cleanup-1": job,
+				"blog-1":         {"app": "blog", "shipyard.app": "blog", "shipyard.process": "web"},
+			},
+			want: []string{"shop-1", "shop-worker-1", "shop-worker-2"},
+		},
+		{
+			name: "deployed before pods had shipyard labels",
+			pods: map[string]map[string]string{
+				"shop-1":         {"app": "shop"},
+				"shop-cleanup-1": job,
+			},
+			want: []string{"shop-1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset()
+			metricsClient := metricsfake.NewSimpleClientset()
+			// Pod metrics are served as the pods resource of metrics.k8s.io
+			podMetricsResource := metricsv1beta1.SchemeGroupVersion.WithResource("pods")
+			for name, labels := range test.pods {
+				if err := clientset.Tracker().Add(pod(name, labels)); err != nil {
+					t.Fatal(err)
+				}
+				if err := metricsClient.Tracker().Create(podMetricsResource, podMetrics(name, labels), "shop"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			client := &Client{clientset: clientset, metricsClient: metricsClient}
+
+			gotPods, err := client.GetPods("shop", "shop")
+			if err != nil {
+				t.Fatalf("GetPods() failed: %v", err)
+			}
+			var names []string
+			for _, pod := range gotPods {
+				names = append(names, pod.Name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, test.want) {
+				t.Errorf("GetPods() = %v, want %v", names, test.want)
+			}
+
+			gotMetrics, err := client.GetPodMetrics("shop", "shop")
+			if err != nil {
+				t.Fatalf("GetPodMetrics() failed: %v", err)
+			}
+			names = nil
+			for _, podMetrics := range gotMetrics {
+				names = append(names, podMetrics.Name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, test.want) {
+				t.Errorf("GetPodMetrics() = %v, want %v", names, test.want)
+			}
+		})
+	}
+}
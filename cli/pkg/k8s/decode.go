@@ -0,0 +1,102 @@
+package k8s
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// manifestExtensions are the extensions of the files read as manifests
+var manifestExtensions = map[string]bool{
+	".yaml": true,
+	".yml":  true,
+	".json": true,
+}
+
+// isManifestFile reports whether a file holds manifests, from its name
+func isManifestFile(name string) bool {
+	return manifestExtensions[filepath.Ext(name)]
+}
+
+// manifestDecoder streams the objects of a manifest: YAML documents
+// separated by --- lines, or JSON objects. Only a --- at the start of a line
+// ends a document, so values holding three dashes, such as the content of a
+// config file in a block scalar, are kept whole. The items of List kinds,
+// such as the output of kubectl get -o yaml, are returned in their place.
+type manifestDecoder struct {
+	decoder  *utilyaml.YAMLOrJSONDecoder
+	document int
+	items    []*unstructured.Unstructured // Items of a List left to return
+}
+
+// newManifestDecoder creates a decoder reading a manifest from r
+func newManifestDecoder(r io.Reader) *manifestDecoder {
+	return &manifestDecoder{decoder: utilyaml.NewYAMLOrJSONDecoder(r, 4096)}
+}
+
+// Next returns the next object of the manifest, or io.EOF after the last one.
+// Empty documents are skipped.
+func (d *manifestDecoder) Next() (*unstructured.Unstructured, error) {
+	for len(d.items) == 0 {
+		var raw runtime.RawExtension
+		if err := d.decoder.Decode(&raw); err == io.EOF {
+			return nil, io.EOF
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode document %d: %w", d.document+1, err)
+		}
+		d.document++
+
+		data := bytes.TrimSpace(raw.Raw)
+		if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+			continue
+		}
+
+		decoded, _, err := unstructured.UnstructuredJSONScheme.Decode(data, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode document %d: %w", d.document, err)
+		}
+
+		switch obj := decoded.(type) {
+		case *unstructured.Unstructured:
+			d.items = append(d.items, obj)
+		case *unstructured.UnstructuredList:
+			for i := range obj.Items {
+				d.items = append(d.items, &obj.Items[i])
+			}
+		default:
+			return nil, fmt.Errorf("failed to decode document %d: unexpected %T", d.document, decoded)
+		}
+	}
+
+	obj := d.items[0]
+	d.items = d.items[1:]
+	return obj, nil
+}
+
+// eachManifestObject calls fn with every object of a manifest file, in order
+func eachManifestObject(filename string, fn func(obj *unstructured.Unstructured) error) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := newManifestDecoder(file)
+	for {
+		obj, err := decoder.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := fn(obj); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// serverManagedAnnotations are set by the cluster or by other tools, not
+// by the manifests
+var serverManagedAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"deployment.kubernetes.io/revision",
+}
+
+// versionLabels change with every deployment, whatever the manifests
+var versionLabels = []string{
+	"shipyard.version",
+	"shipyard.image-tag",
+	"shipyard.image-hash",
+	"shipyard.deployed-at",
+	"shipyard.rollback-from",
+	OwnerVersionLabel,
+}
+
+// ResourceDiff compares an object of the manifests with the live object
+type ResourceDiff struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Live      string // YAML of the live object, empty when it does not exist
+	Desired   string // YAML of the object once the manifest is applied, empty when it is pruned
+}
+
+// Changed reports whether applying the manifest would change the object
+func (d ResourceDiff) Changed() bool {
+	return d.Live != d.Desired
+}
+
+// DiffManifestsInDir compares the objects of the manifest files in a directory
+// with the live objects, adding labels to them as apply does. The objects
+// are applied with a server-side dry run, so that both sides have the
+// defaults of the cluster and the fields of other field managers.
+func (c *Client) DiffManifestsInDir(dir string, labels map[string]string) ([]ResourceDiff, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil // Directory doesn't exist, skip
+		}
+		return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+
+	var diffs []ResourceDiff
+	for _, file := range files {
+		if !isManifestFile(file.Name()) {
+			continue
+		}
+
+		filePath := filepath.Join(dir, file.Name())
+		fileDiffs, err := c.diffManifest(filePath, labels)
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff %s: %w", filePath, err)
+		}
+		diffs = append(diffs, fileDiffs...)
+	}
+
+	return diffs, nil
+}
+
+// diffManifest compares the objects of a single manifest file
+func (c *Client) diffManifest(filename string, labels map[string]string) ([]ResourceDiff, error) {
+	var diffs []ResourceDiff
+	err := eachManifestObject(filename, func(obj *unstructured.Unstructured) error {
+		diff, err := c.diffManifestObject(obj, labels)
+		if err != nil {
+			return fmt.Errorf("failed to diff %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		diffs = append(diffs, diff)
+		return nil
+	})
+	return diffs, err
+}
+
+// diffManifestObject compares a single object of a manifest with the live object
+func (c *Client) diffManifestObject(obj *unstructured.Unstructured, labels map[string]string) (ResourceDiff, error) {
+	setLabels(obj, labels)
+
+	resourceClient, err := c.resourceFor(obj)
+	if err != nil {
+		return ResourceDiff{}, err
+	}
+
+	diff := ResourceDiff{
+		Kind:      obj.GetKind(),
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+	}
+
+	existing, err := resourceClient.Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		// The object would be created
+		desired := stripServerFields(obj)
+		maskSecretData(nil, desired)
+		diff.Desired, err = objectYAML(desired)
+		return diff, err
+	} else if err != nil {
+		return ResourceDiff{}, err
+	}
+
+	updated, err := c.applyObject(resourceClient, obj, true)
+	if err != nil {
+		return ResourceDiff{}, fmt.Errorf("dry run of %s %s failed: %w", obj.GetKind(), obj.GetName(), err)
+	}
+
+	live := stripServerFields(existing)
+	desired := stripServerFields(updated)
+	maskSecretData(live, desired)
+
+	if diff.Live, err = objectYAML(live); err != nil {
+		return ResourceDiff{}, err
+	}
+	diff.Desired, err = objectYAML(desired)
+	return diff, err
+}
+
+// stripServerFields returns a copy of an object without the fields managed
+// by the cluster: status, metadata such as uid and resourceVersion, and the
+// labels that change with every deployment
+func stripServerFields(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	stripped := obj.DeepCopy()
+	delete(stripped.Object, "status")
+
+	for _, field := range []string{"uid", "resourceVersion", "generation", "creationTimestamp",
+		"managedFields", "selfLink", "deletionTimestamp", "deletionGracePeriodSeconds"} {
+		unstructured.RemoveNestedField(stripped.Object, "metadata", field)
+	}
+
+	annotations := stripped.GetAnnotations()
+	for _, key := range serverManagedAnnotations {
+		delete(annotations, key)
+	}
+	if len(annotations) == 0 {
+		unstructured.RemoveNestedField(stripped.Object, "metadata", "annotations")
+	} else {
+		stripped.SetAnnotations(annotations)
+	}
+
+	labels := stripped.GetLabels()
+	for _, key := range versionLabels {
+		delete(labels, key)
+	}
+	if len(labels) == 0 {
+		unstructured.RemoveNestedField(stripped.Object, "metadata", "labels")
+	} else {
+		stripped.SetLabels(labels)
+	}
+
+	return stripped
+}
+
+// maskSecretData replaces the values of Secrets so they are never printed,
+// telling apart those that would change
+func maskSecretData(live, desired *unstructured.Unstructured) {
+	if desired.GetKind() != "Secret" {
+		return
+	}
+
+	var liveData map[string]interface{}
+	if live != nil {
+		liveData, _, _ = unstructured.NestedMap(live.Object, "data")
+	}
+	desiredData, _, _ := unstructured.NestedMap(desired.Object, "data")
+
+	for key, value := range desiredData {
+		liveValue, found := liveData[key]
+		if found && liveValue != value {
+			liveData[key] = "*** (before)"
+			desiredData[key] = "*** (after)"
+			continue
+		}
+		desiredData[key] = "***"
+		if found {
+			liveData[key] = "***"
+		}
+	}
+	for key := range liveData {
+		if _, found := desiredData[key]; !found {
+			liveData[key] = "***"
+		}
+	}
+
+	if liveData != nil {
+		unstructured.SetNestedMap(live.Object, liveData, "data")
+	}
+	if desiredData != nil {
+		unstructured.SetNestedMap(desired.Object, desiredData, "data")
+	}
+}
+
+// objectYAML encodes an object as YAML with sorted keys
+func objectYAML(obj *unstructured.Unstructured) (string, error) {
+	data, err := sigsyaml.Marshal(obj.Object)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return string(data), nil
+}
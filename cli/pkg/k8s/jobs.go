@@ -0,0 +1,285 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shipyard/cli/pkg/config"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// podStartFailures are the reasons for which a waiting container will not
+// start without a change to the manifests
+var podStartFailures = map[string]bool{
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+// defaultJobStartTimeout bounds the wait for the pods of a job without a timeout
+const defaultJobStartTimeout = 5 * time.Minute
+
+// GetCronJob returns a specific cronjob
+func (c *Client) GetCronJob(name, namespace string) (*batchv1.CronJob, error) {
+	return c.clientset.BatchV1().CronJobs(namespace).Get(
+		context.TODO(), name, metav1.GetOptions{})
+}
+
+// ListJobs returns the jobs matching a label selector, most recent first
+func (c *Client) ListJobs(namespace, labelSelector string) ([]batchv1.Job, error) {
+	jobs, err := c.clientset.BatchV1().Jobs(namespace).List(
+		context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(jobs.Items, func(i, j int) bool {
+		return jobs.Items[j].CreationTimestamp.Before(&jobs.Items[i].CreationTimestamp)
+	})
+	return jobs.Items, nil
+}
+
+// RunCronJob creates a job from the template of a cronjob, like
+// kubectl create job --from=cronjob/<name>
+func (c *Client) RunCronJob(name, namespace string) (*batchv1.Job, error) {
+	cronJob, err := c.GetCronJob(name, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := map[string]string{"cronjob.kubernetes.io/instantiate": "manual"}
+	for key, value := range cronJob.Spec.JobTemplate.Annotations {
+		annotations[key] = value
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: name + "-manual-",
+			Namespace:    namespace,
+			Labels:       cronJob.Spec.JobTemplate.Labels,
+			Annotations:  annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(cronJob, batchv1.SchemeGroupVersion.WithKind("CronJob")),
+			},
+		},
+		Spec: cronJob.Spec.JobTemplate.Spec,
+	}
+
+	return c.clientset.BatchV1().Jobs(namespace).Create(
+		context.TODO(), job, metav1.CreateOptions{})
+}
+
+// StreamJobLogs writes the logs of the pods of a job, oldest first. With
+// follow, it waits up to timeout for the pods to start (5 minutes when
+// timeout is 0) and streams their logs until they exit.
+func (c *Client) StreamJobLogs(jobName, namespace string, follow bool, timeout time.Duration, out io.Writer) error {
+	var pods []corev1.Pod
+
+	if timeout <= 0 {
+		timeout = defaultJobStartTimeout
+	}
+	err := wait.PollImmediate(2*time.Second, timeout, func() (bool, error) {
+		list, err := c.clientset.CoreV1().Pods(namespace).List(
+			context.TODO(), metav1.ListOptions{
+				LabelSelector: fmt.Sprintf("job-name=%s", jobName),
+			})
+		if err != nil {
+			return false, err
+		}
+		pods = list.Items
+
+		if !follow {
+			return true, nil
+		}
+		for _, pod := range pods {
+			if pod.Status.Phase != corev1.PodPending {
+				return true, nil
+			}
+			// Pods that cannot start would be waited for until the timeout
+			for _, containerStatus := range pod.Status.ContainerStatuses {
+				if waiting := containerStatus.State.Waiting; waiting != nil && podStartFailures[waiting.Reason] {
+					return false, fmt.Errorf("pod %s cannot start: %s - %s", pod.Name, waiting.Reason, waiting.Message)
+				}
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find pods of job %s: %w", jobName, err)
+	}
+
+	if len(pods) == 0 {
+		return fmt.Errorf("no pods found for job %s", jobName)
+	}
+
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].CreationTimestamp.Before(&pods[j].CreationTimestamp)
+	})
+
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodPending && !follow {
+			continue
+		}
+		if len(pods) > 1 {
+			fmt.Fprintf(out, "==> %s <==\n", pod.Name)
+		}
+
+		req := c.clientset.CoreV1().Pods(namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Follow: follow})
+		stream, err := req.Stream(context.TODO())
+		if err != nil {
+			return fmt.Errorf("failed to get logs of pod %s: %w", pod.Name, err)
+		}
+		_, err = io.Copy(out, stream)
+		stream.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read logs of pod %s: %w", pod.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// WaitForJob waits for a job to succeed or fail
+func (c *Client) WaitForJob(name, namespace string, timeout time.Duration) (*batchv1.Job, error) {
+	var job *batchv1.Job
+
+	err := wait.PollImmediate(2*time.Second, timeout, func() (bool, error) {
+		var err error
+		job, err = c.clientset.BatchV1().Jobs(namespace).Get(
+			context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return JobStatus(job) != "Running", nil
+	})
+
+	return job, err
+}
+
+// RunRelease runs the release Job of an app and waits for it to finish,
+// streaming its logs. Only the shared manifests, the registry secrets and the
+// release manifests are applied, so a failing release leaves the running
+// version untouched.
+func (c *Client) RunRelease(appName, namespace, jobName string, timeout time.Duration) error {
+	appsDir, err := config.GetAppsDir()
+	if err != nil {
+		return fmt.Errorf("failed to get apps directory: %w", err)
+	}
+	appDir := filepath.Join(appsDir, appName)
+
+	sharedDir, err := config.GetSharedDir()
+	if err != nil {
+		return fmt.Errorf("failed to get shared directory: %w", err)
+	}
+	if err := c.applyManifestsFromDir(sharedDir, nil); err != nil {
+		return fmt.Errorf("failed to apply shared manifests: %w", err)
+	}
+
+	registrySecrets, err := filepath.Glob(filepath.Join(appDir, "registry-secret*.yaml"))
+	if err != nil {
+		return fmt.Errorf("failed to list registry secrets: %w", err)
+	}
+	for _, file := range registrySecrets {
+		if err := c.applyManifest(file, OwnerLabels(appName, namespace, "")); err != nil {
+			return fmt.Errorf("failed to apply %s: %w", file, err)
+		}
+	}
+	if err := c.CopyRegistrySecretsFromDefault(namespace); err != nil {
+		fmt.Printf("⚠️  Warning: failed to copy registry secrets: %v\n", err)
+	}
+
+	// The release directory is manifests.ReleaseDir
+	if err := c.applyManifestsFromDir(filepath.Join(appDir, "release"), nil); err != nil {
+		return fmt.Errorf("failed to apply release job: %w", err)
+	}
+
+	fmt.Printf("📋 Release logs:\n")
+	if err := c.StreamJobLogs(jobName, namespace, true, timeout, os.Stdout); err != nil {
+		// Stop the release rather than letting it run after the deploy gave up
+		c.deleteJob(jobName, namespace)
+		return err
+	}
+
+	job, err := c.WaitForJob(jobName, namespace, timeout+time.Minute)
+	if err != nil {
+		c.deleteJob(jobName, namespace)
+		return fmt.Errorf("release job did not finish: %w", err)
+	}
+
+	if JobStatus(job) == "Failed" {
+		return fmt.Errorf("release command failed: %s", c.jobFailureReason(job))
+	}
+	return nil
+}
+
+// jobFailureReason describes why a job failed, with the exit code of its
+// last container when available
+func (c *Client) jobFailureReason(job *batchv1.Job) string {
+	var reasons []string
+
+	pods, err := c.clientset.CoreV1().Pods(job.Namespace).List(
+		context.TODO(), metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("job-name=%s", job.Name),
+		})
+	if err == nil {
+		for _, pod := range pods.Items {
+			for _, containerStatus := range pod.Status.ContainerStatuses {
+				if terminated := containerStatus.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+					reason := fmt.Sprintf("exited with code %d (%s)", terminated.ExitCode, terminated.Reason)
+					if terminated.Message != "" {
+						reason += ": " + strings.TrimSpace(terminated.Message)
+					}
+					reasons = append(reasons, reason)
+				}
+			}
+		}
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			reasons = append(reasons, fmt.Sprintf("%s - %s", condition.Reason, condition.Message))
+		}
+	}
+
+	if len(reasons) == 0 {
+		return "unknown reason"
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// deleteJob deletes a job and its pods
+func (c *Client) deleteJob(name, namespace string) {
+	propagation := metav1.DeletePropagationBackground
+	err := c.clientset.BatchV1().Jobs(namespace).Delete(
+		context.TODO(), name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil {
+		fmt.Printf("⚠️  Warning: failed to delete job %s: %v\n", name, err)
+	}
+}
+
+// JobStatus summarizes the state of a job: Running, Succeeded or Failed
+func JobStatus(job *batchv1.Job) string {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return "Succeeded"
+		case batchv1.JobFailed:
+			return "Failed"
+		}
+	}
+	return "Running"
+}
@@ -0,0 +1,42 @@
+package k8s
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStreamJobLogsTimeout(t *testing.T) {
+	pod := func(name string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: name, Labels: map[string]string{"job-name": name + "-job"}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	client := &Client{clientset: fake.NewSimpleClientset(
+		pod("pending", corev1.PodPending),
+		pod("running", corev1.PodRunning),
+	)}
+
+	// The wait for pods to start is bounded by the timeout given
+	start := time.Now()
+	err := client.StreamJobLogs("pending-job", "shop", true, 3*time.Second, &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("StreamJobLogs() succeeded on a pending pod, want a timeout")
+	}
+	if elapsed := time.Since(start); elapsed < 3*time.Second || elapsed > 30*time.Second {
+		t.Errorf("StreamJobLogs() gave up after %v, want about 3s", elapsed)
+	}
+
+	var out bytes.Buffer
+	if err := client.StreamJobLogs("running-job", "shop", true, 3*time.Second, &out); err != nil {
+		t.Fatalf("StreamJobLogs() failed: %v", err)
+	}
+	if out.Len() == 0 {
+		t.Error("StreamJobLogs() wrote no logs")
+	}
+}
@@ -0,0 +1,265 @@
+package k8s
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// Ownership labels set on every object applied from the manifests of an app.
+// Prune deletes the objects of the app that the latest deploy did not apply.
+const (
+	OwnerLabel        = "shipyard.owner"
+	OwnerVersionLabel = "shipyard.owner-version"
+)
+
+// keptKinds are never pruned: their data would be lost with them
+var keptKinds = map[string]bool{
+	"Namespace":             true,
+	"PersistentVolumeClaim": true,
+}
+
+// OwnerLabels returns the ownership labels of the objects applied for a
+// version of an app deployed to a namespace. Without a version, only the
+// owner is set.
+func OwnerLabels(appName, namespace, version string) map[string]string {
+	labels := map[string]string{OwnerLabel: ownerValue(appName, namespace)}
+	if version != "" {
+		labels[OwnerVersionLabel] = version
+	}
+	return labels
+}
+
+// ownerValue identifies an app in the cluster: app names are only unique
+// within the database of an operator, so the namespace is part of it. Values
+// too long for a label are shortened with a hash.
+func ownerValue(appName, namespace string) string {
+	value := namespace + "." + appName
+	if len(value) <= validation.LabelValueMaxLength {
+		return value
+	}
+	sum := fmt.Sprintf("%x", sha256.Sum256([]byte(value)))
+	return strings.TrimRight(value[:validation.LabelValueMaxLength-11], "-.") + "-" + sum[:10]
+}
+
+// setLabels adds labels to the metadata of an object
+func setLabels(obj *unstructured.Unstructured, labels map[string]string) {
+	if len(labels) == 0 {
+		return
+	}
+
+	merged := obj.GetLabels()
+	if merged == nil {
+		merged = make(map[string]string)
+	}
+	for key, value := range labels {
+		merged[key] = value
+	}
+	obj.SetLabels(merged)
+}
+
+// PruneApp deletes the objects of an app that the given version did not
+// apply, such as the Secret of removed secrets or the autoscaler of a process
+// that no longer scales. Only the namespace of the app and cluster-scoped
+// objects are searched. Namespaces and PersistentVolumeClaims are kept and
+// reported.
+func (c *Client) PruneApp(appName, namespace, version string) error {
+	if version == "" {
+		return fmt.Errorf("no version to prune %s against", appName)
+	}
+
+	// Objects without a version label were not applied by a versioned deploy
+	selector := fmt.Sprintf("%s=%s,%s,%s!=%s", OwnerLabel, ownerValue(appName, namespace),
+		OwnerVersionLabel, OwnerVersionLabel, version)
+	objects, err := c.ownedObjects(namespace, selector)
+	if err != nil {
+		return err
+	}
+
+	pruned := 0
+	for _, owned := range objects {
+		if keptKinds[owned.object.GetKind()] {
+			fmt.Printf("ℹ️  Keeping %s %s, no longer used by %s: delete it when its data is not needed\n",
+				owned.object.GetKind(), objectName(owned.object), appName)
+			continue
+		}
+
+		resourceClient := c.dynamicClient.Resource(owned.resource).Namespace(owned.object.GetNamespace())
+		propagation := metav1.DeletePropagationBackground
+		err := resourceClient.Delete(context.TODO(), owned.object.GetName(), metav1.DeleteOptions{
+			PropagationPolicy: &propagation,
+		})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to prune %s %s: %w", owned.object.GetKind(), objectName(owned.object), err)
+		}
+		fmt.Printf("🗑️  Pruned %s %s\n", owned.object.GetKind(), objectName(owned.object))
+		pruned++
+	}
+
+	if pruned == 0 {
+		fmt.Printf("✅ Nothing to prune for %s\n", appName)
+	}
+	return nil
+}
+
+// DiffPrunedObjects returns the objects of an app that a deploy would prune:
+// those missing from the diffs of its manifests. Their Desired side is empty.
+func (c *Client) DiffPrunedObjects(appName, namespace string, diffs []ResourceDiff) ([]ResourceDiff, error) {
+	objects, err := c.ownedObjects(namespace, fmt.Sprintf("%s=%s,%s", OwnerLabel, ownerValue(appName, namespace), OwnerVersionLabel))
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := make(map[string]bool)
+	for _, diff := range diffs {
+		rendered[diff.Kind+"/"+diff.Namespace+"/"+diff.Name] = true
+	}
+
+	var pruned []ResourceDiff
+	for _, owned := range objects {
+		obj := owned.object
+		if keptKinds[obj.GetKind()] || rendered[obj.GetKind()+"/"+obj.GetNamespace()+"/"+obj.GetName()] {
+			continue
+		}
+
+		live := stripServerFields(obj)
+		maskSecretData(nil, live)
+		liveYAML, err := objectYAML(live)
+		if err != nil {
+			return nil, err
+		}
+		pruned = append(pruned, ResourceDiff{
+			Kind:      obj.GetKind(),
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+			Live:      liveYAML,
+		})
+	}
+
+	return pruned, nil
+}
+
+// ownedObject is an object carrying ownership labels, with its resource
+type ownedObject struct {
+	resource schema.GroupVersionResource
+	object   *unstructured.Unstructured
+}
+
+// ownedObjects lists the objects matching a selector of ownership labels in
+// every resource that can be deleted: in the namespace for namespaced ones,
+// cluster-wide for the others. Objects created by controllers, such as the
+// Endpoints of a Service which copy its labels, are left out: they go away
+// with their owner.
+func (c *Client) ownedObjects(namespace, selector string) ([]ownedObject, error) {
+	resources, err := c.deletableResources()
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []ownedObject
+	seen := make(map[types.UID]bool)
+	for _, resource := range resources {
+		var resourceClient dynamic.ResourceInterface = c.dynamicClient.Resource(resource.GroupVersionResource)
+		if resource.namespaced {
+			resourceClient = c.dynamicClient.Resource(resource.GroupVersionResource).Namespace(namespace)
+		}
+		list, err := resourceClient.List(context.TODO(), metav1.ListOptions{
+			LabelSelector: selector,
+		})
+		if errors.IsForbidden(err) || errors.IsNotFound(err) || errors.IsMethodNotSupported(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", resource.Resource, err)
+		}
+
+		for i := range list.Items {
+			obj := &list.Items[i]
+			// The same object can be served by several groups
+			if seen[obj.GetUID()] || len(obj.GetOwnerReferences()) > 0 || obj.GetDeletionTimestamp() != nil {
+				continue
+			}
+			seen[obj.GetUID()] = true
+			objects = append(objects, ownedObject{resource: resource.GroupVersionResource, object: obj})
+		}
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		a, b := objects[i].object, objects[j].object
+		if a.GetKind() != b.GetKind() {
+			return a.GetKind() < b.GetKind()
+		}
+		return objectName(a) < objectName(b)
+	})
+	return objects, nil
+}
+
+// deletableResource is a resource that can be listed and deleted
+type deletableResource struct {
+	schema.GroupVersionResource
+	namespaced bool
+}
+
+// deletableResources returns the resources of the cluster that can be listed
+// and deleted, in their preferred version
+func (c *Client) deletableResources() ([]deletableResource, error) {
+	lists, err := c.discovery.ServerPreferredResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		// Groups served by unavailable API services are skipped
+		return nil, fmt.Errorf("failed to discover resources: %w", err)
+	}
+
+	var resources []deletableResource
+	for _, list := range lists {
+		groupVersion, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, resource := range list.APIResources {
+			if strings.Contains(resource.Name, "/") || !hasVerbs(resource.Verbs, "list", "delete") {
+				continue
+			}
+			resources = append(resources, deletableResource{
+				GroupVersionResource: groupVersion.WithResource(resource.Name),
+				namespaced:           resource.Namespaced,
+			})
+		}
+	}
+	return resources, nil
+}
+
+// hasVerbs reports whether a resource supports all the verbs
+func hasVerbs(verbs metav1.Verbs, required ...string) bool {
+	for _, verb := range required {
+		found := false
+		for _, supported := range verbs {
+			if supported == verb {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// objectName returns the namespace/name of an object, or its name when it is
+// cluster scoped
+func objectName(obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
@@ -0,0 +1,263 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// DefaultRolloutTimeout is how long apply waits for the deployments of an app
+const DefaultRolloutTimeout = 5 * time.Minute
+
+// crashLoopRestarts is the number of restarts after which a container in
+// CrashLoopBackOff fails the rollout, leaving room for crashes at startup
+const crashLoopRestarts = 3
+
+// revisionAnnotation holds the revision of a deployment and of its ReplicaSets
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+// fatalWaitingReasons are the waiting states of containers that a rollout
+// does not recover from without a new deploy
+var fatalWaitingReasons = map[string]bool{
+	"ImagePullBackOff":           true,
+	"ErrImageNeverPull":          true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+}
+
+// RolloutError is the reason a deployment did not roll out
+type RolloutError struct {
+	Deployment string
+	Namespace  string
+	Reason     string // CrashLoopBackOff, ImagePullBackOff, ProgressDeadlineExceeded, Timeout...
+	Pod        string // Pod and container of the failure, when a container failed
+	Container  string
+	Message    string
+}
+
+func (e *RolloutError) Error() string {
+	message := fmt.Sprintf("%s: deployment %s/%s", e.Reason, e.Namespace, e.Deployment)
+	if e.Container != "" {
+		message += fmt.Sprintf(", container %s of pod %s", e.Container, e.Pod)
+	}
+	if e.Message != "" {
+		message += ": " + e.Message
+	}
+	return message
+}
+
+// SetRolloutTimeout sets how long apply waits for the deployments of an app
+// to roll out
+func (c *Client) SetRolloutTimeout(timeout time.Duration) {
+	c.rolloutTimeout = timeout
+}
+
+// waitForDeployment waits for the rollout of a deployment, as kubectl rollout
+// status does: the controller has observed the latest spec, every replica
+// runs it, the old ones are gone and the new ones are available. Pods that
+// cannot start, such as those of an image that cannot be pulled, fail it
+// early with a RolloutError, as does the progress deadline of the deployment.
+func (c *Client) waitForDeployment(name, namespace string, timeout time.Duration) error {
+	lastStatus := ""
+	lastEventsCount := 0
+	reported := make(map[string]bool)
+
+	err := wait.PollImmediate(2*time.Second, timeout, func() (bool, error) {
+		deployment, err := c.clientset.AppsV1().Deployments(namespace).Get(
+			context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			fmt.Printf("❌ Error getting deployment: %v\n", err)
+			return false, err
+		}
+
+		done, status, err := rolloutStatus(deployment)
+		if err != nil {
+			return false, err
+		}
+		if status != lastStatus {
+			fmt.Printf("🔄 %s\n", status)
+			lastStatus = status
+		}
+		if done {
+			fmt.Printf("✅ Deployment %s is ready!\n", name)
+			return true, nil
+		}
+
+		// Show recent events related to this deployment
+		events, err := c.clientset.CoreV1().Events(namespace).List(
+			context.TODO(), metav1.ListOptions{
+				FieldSelector: fmt.Sprintf("involvedObject.name=%s", name),
+			})
+		if err == nil && len(events.Items) > lastEventsCount {
+			for i := lastEventsCount; i < len(events.Items); i++ {
+				event := events.Items[i]
+				if time.Since(event.CreationTimestamp.Time) < 30*time.Second {
+					fmt.Printf("📋 Event: %s - %s\n", event.Reason, event.Message)
+				}
+			}
+			lastEventsCount = len(events.Items)
+		}
+
+		return false, c.checkRolloutPods(deployment, reported)
+	})
+
+	if err == wait.ErrWaitTimeout {
+		return &RolloutError{
+			Deployment: name,
+			Namespace:  namespace,
+			Reason:     "Timeout",
+			Message:    fmt.Sprintf("not rolled out after %s (%s)", timeout, lastStatus),
+		}
+	}
+	return err
+}
+
+// rolloutStatus reports whether the rollout of a deployment is complete,
+// with a description of its progress. A deployment past its progress
+// deadline fails with a RolloutError.
+func rolloutStatus(deployment *appsv1.Deployment) (bool, string, error) {
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return false, "Waiting for the controller to observe the new spec", nil
+	}
+
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			return false, "", &RolloutError{
+				Deployment: deployment.Name,
+				Namespace:  deployment.Namespace,
+				Reason:     condition.Reason,
+				Message:    condition.Message,
+			}
+		}
+	}
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+
+	status := deployment.Status
+	switch {
+	case status.UpdatedReplicas < replicas:
+		return false, fmt.Sprintf("Replicas: %d/%d updated", status.UpdatedReplicas, replicas), nil
+	case status.Replicas > status.UpdatedReplicas:
+		return false, fmt.Sprintf("Replicas: %d old pending termination", status.Replicas-status.UpdatedReplicas), nil
+	case status.AvailableReplicas < status.UpdatedReplicas:
+		return false, fmt.Sprintf("Replicas: %d/%d updated available", status.AvailableReplicas, status.UpdatedReplicas), nil
+	}
+	return true, fmt.Sprintf("Replicas: %d/%d ready", status.AvailableReplicas, replicas), nil
+}
+
+// checkRolloutPods reports the progress of the pods of the new ReplicaSet of
+// a deployment, and fails with a RolloutError when one of their containers
+// cannot start. The pods of the previous version are left alone. Messages
+// are only printed once, in reported.
+func (c *Client) checkRolloutPods(deployment *appsv1.Deployment, reported map[string]bool) error {
+	pods, err := c.newReplicaSetPods(deployment)
+	if err != nil || len(pods) == 0 {
+		return nil // The new ReplicaSet may not be created yet
+	}
+
+	report := func(format string, args ...interface{}) {
+		message := fmt.Sprintf(format, args...)
+		if !reported[message] {
+			fmt.Print(message)
+			reported[message] = true
+		}
+	}
+
+	for _, pod := range pods {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+				report("⏳ Pod %s: %s - %s\n", pod.Name, condition.Reason, condition.Message)
+			}
+		}
+
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, containerStatus := range statuses {
+			failure := &RolloutError{
+				Deployment: deployment.Name,
+				Namespace:  deployment.Namespace,
+				Pod:        pod.Name,
+				Container:  containerStatus.Name,
+			}
+
+			if waiting := containerStatus.State.Waiting; waiting != nil {
+				switch {
+				case fatalWaitingReasons[waiting.Reason]:
+					failure.Reason = waiting.Reason
+					failure.Message = waiting.Message
+					return failure
+				case waiting.Reason == "CrashLoopBackOff" && containerStatus.RestartCount >= crashLoopRestarts:
+					fmt.Printf("💥 Container %s of pod %s keeps crashing\n", containerStatus.Name, pod.Name)
+					c.showRecentLogs(pod.Namespace, pod.Name, containerStatus.Name, 10, true)
+					failure.Reason = waiting.Reason
+					failure.Message = fmt.Sprintf("restarted %d times", containerStatus.RestartCount)
+					if terminated := containerStatus.LastTerminationState.Terminated; terminated != nil {
+						failure.Message += fmt.Sprintf(", last exited with code %d (%s)", terminated.ExitCode, terminated.Reason)
+					}
+					return failure
+				case waiting.Reason != "":
+					report("📦 Container %s: %s - %s\n", containerStatus.Name, waiting.Reason, waiting.Message)
+				}
+			}
+
+			if containerStatus.State.Running != nil && !containerStatus.Ready && !reported[pod.Name+"/"+containerStatus.Name] {
+				// Show the first logs of starting containers
+				fmt.Printf("🚀 Container %s starting...\n", containerStatus.Name)
+				c.showRecentLogs(pod.Namespace, pod.Name, containerStatus.Name, 5, false)
+				reported[pod.Name+"/"+containerStatus.Name] = true
+			}
+		}
+	}
+
+	return nil
+}
+
+// newReplicaSetPods returns the pods of the ReplicaSet of the current
+// revision of a deployment
+func (c *Client) newReplicaSetPods(deployment *appsv1.Deployment) ([]corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	replicaSets, err := c.clientset.AppsV1().ReplicaSets(deployment.Namespace).List(
+		context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	revision := deployment.Annotations[revisionAnnotation]
+	for _, replicaSet := range replicaSets.Items {
+		if !metav1.IsControlledBy(&replicaSet, deployment) || replicaSet.Annotations[revisionAnnotation] != revision {
+			continue
+		}
+
+		pods, err := c.clientset.CoreV1().Pods(deployment.Namespace).List(
+			context.TODO(), metav1.ListOptions{
+				LabelSelector: fmt.Sprintf("%s,%s=%s", selector.String(),
+					appsv1.DefaultDeploymentUniqueLabelKey, replicaSet.Labels[appsv1.DefaultDeploymentUniqueLabelKey]),
+			})
+		if err != nil {
+			return nil, err
+		}
+		return pods.Items, nil
+	}
+
+	return nil, nil
+}
+
+// remainingTimeout returns the time left before a deadline, at least a
+// second so that a late deployment is still checked once
+func remainingTimeout(deadline time.Time) time.Duration {
+	if remaining := time.Until(deadline); remaining > time.Second {
+		return remaining
+	}
+	return time.Second
+}
@@ -3,6 +3,7 @@ package manifests
 import (
 	"fmt"
 	"io/ioutil"
+	"path/filepath"
 	"regexp"
 	"strings"
 
@@ -22,6 +23,18 @@ type Config struct {
 	Secrets   map[string]string `yaml:"secrets,omitempty"`
 	Addons    []string        `yaml:"addons,omitempty"`
 	Domains   []string        `yaml:"domains,omitempty"`
+	Monitoring *MonitoringConfig `yaml:"monitoring,omitempty"`
+	Processes map[string]ProcessConfig `yaml:"processes,omitempty"`
+	Jobs      map[string]JobConfig     `yaml:"jobs,omitempty"`
+	Release   *ReleaseConfig           `yaml:"release,omitempty"`
+	Volumes   []VolumeConfig           `yaml:"volumes,omitempty"`
+	Files     map[string]string        `yaml:"files,omitempty"` // local path => mount path
+
+	// Overlays merged over the config by LoadConfigForEnv, by environment name
+	Environments map[string]map[string]interface{} `yaml:"environments,omitempty" json:"-"`
+
+	dir       string              // directory of the config file, local paths are relative to it
+	positions map[string]position // keys of the config files by path, to locate problems
 }
 
 type AppConfig struct {
@@ -29,6 +42,8 @@ type AppConfig struct {
 	Image     string `yaml:"image"`
 	Port      int    `yaml:"port,omitempty"`
 	Namespace string `yaml:"namespace,omitempty"`
+
+	Environment string `yaml:"-"` // set by LoadConfigForEnv
 }
 
 // GetNamespace returns the namespace to use (app name if not specified)
@@ -112,15 +127,145 @@ type CICDConfig struct {
 	Namespace   string `yaml:"namespace,omitempty"`
 }
 
+// ProcessConfig describes a process type of the app (web, worker, ...). All
+// processes run the app image with the same env; unset fields fall back to
+// the app-level settings.
+type ProcessConfig struct {
+	Command   Command         `yaml:"command,omitempty"`
+	Port      int             `yaml:"port,omitempty"`     // defaults to app.port
+	Replicas  int             `yaml:"replicas,omitempty"` // fixed number of replicas, without autoscaling
+	Resources ResourcesConfig `yaml:"resources,omitempty"`
+	Scaling   *ScalingConfig  `yaml:"scaling,omitempty"`
+	Service   *bool           `yaml:"service,omitempty"` // defaults to true for the process receiving ingress
+	Ingress   *bool           `yaml:"ingress,omitempty"` // defaults to true for the web process
+}
+
+// JobConfig describes a batch job of the app, run from the app image with
+// the same env on a cron schedule or on demand with shipyard jobs run
+type JobConfig struct {
+	Schedule          string          `yaml:"schedule,omitempty"`           // cron schedule; without one the job only runs on demand
+	Command           Command         `yaml:"command,omitempty"`
+	ConcurrencyPolicy string          `yaml:"concurrency_policy,omitempty"` // Allow, Forbid or Replace (default: Forbid)
+	SuccessfulHistory *int            `yaml:"successful_history,omitempty"` // finished runs kept (default: 3)
+	FailedHistory     *int            `yaml:"failed_history,omitempty"`     // failed runs kept (default: 1)
+	Timeout           string          `yaml:"timeout,omitempty"`            // e.g. 30m, unlimited by default
+	Resources         ResourcesConfig `yaml:"resources,omitempty"`
+}
+
+// ReleaseConfig describes the release command (e.g. database migrations), run
+// as a Kubernetes Job with the new image and config before the new version is
+// applied. A failing release command fails the deployment.
+type ReleaseConfig struct {
+	Command   Command         `yaml:"command"`
+	Timeout   string          `yaml:"timeout,omitempty"` // default: 10m
+	Resources ResourcesConfig `yaml:"resources,omitempty"`
+}
+
+// UnmarshalYAML accepts the release: bin/rails db:migrate shorthand
+func (r *ReleaseConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var command Command
+	if err := unmarshal(&command); err == nil {
+		*r = ReleaseConfig{Command: command}
+		return nil
+	}
+
+	type plain ReleaseConfig
+	return unmarshal((*plain)(r))
+}
+
+// VolumeConfig describes a persistent volume mounted into a process of the app
+type VolumeConfig struct {
+	Name         string `yaml:"name"`
+	MountPath    string `yaml:"mount_path"`
+	Size         string `yaml:"size"`
+	StorageClass string `yaml:"storage_class,omitempty"` // cluster default when empty
+	AccessMode   string `yaml:"access_mode,omitempty"`   // default: ReadWriteOnce
+	Process      string `yaml:"process,omitempty"`       // default: the process receiving ingress
+}
+
+// Command is a container command, written as a list or as a single string
+// split on whitespace
+type Command []string
+
+// UnmarshalYAML accepts both command: [bundle, exec, sidekiq] and command: bundle exec sidekiq
+func (c *Command) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var line string
+	if err := unmarshal(&line); err == nil {
+		*c = strings.Fields(line)
+		return nil
+	}
+
+	var args []string
+	if err := unmarshal(&args); err != nil {
+		return fmt.Errorf("command must be a string or a list of strings")
+	}
+	*c = args
+	return nil
+}
+
+// MonitoringConfig holds the monitoring settings synced into the database on deploy
+type MonitoringConfig struct {
+	Enabled      *bool   `yaml:"enabled,omitempty"`
+	HealthPath   string  `yaml:"health_path,omitempty"`
+	Interval     string  `yaml:"interval,omitempty"`      // health check interval, e.g. 15s
+	Timeout      string  `yaml:"timeout,omitempty"`       // health check timeout, e.g. 5s
+	CPU          float64 `yaml:"cpu,omitempty"`           // percentage
+	Memory       float64 `yaml:"memory,omitempty"`        // percentage
+	ErrorRate    float64 `yaml:"error_rate,omitempty"`    // percentage
+	ResponseTime int     `yaml:"response_time,omitempty"` // milliseconds
+	Metrics      *bool   `yaml:"metrics,omitempty"`       // scrape Prometheus metrics from the pods
+	MetricsPath  string  `yaml:"metrics_path,omitempty"`
+	MetricsPort  int     `yaml:"metrics_port,omitempty"`
+}
+
 // LoadConfig loads and parses the paas.yaml configuration file
 func LoadConfig(filename string) (*Config, error) {
+	return LoadConfigForEnv(filename, "")
+}
+
+// LoadConfigForEnv loads the paas.yaml configuration file with the overlay of
+// an environment merged over it, see applyEnvironment. An empty env loads the
+// base config.
+func LoadConfigForEnv(filename, env string) (*Config, error) {
 	data, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file %s: %w", filename, err)
 	}
 
+	positions, problems := checkConfigDocument(filename, data)
+
+	if env != "" {
+		// Keys of the environment override those of the base config
+		prefix := "environments." + env + "."
+		for path, pos := range positions {
+			if strings.HasPrefix(path, prefix) {
+				positions[strings.TrimPrefix(path, prefix)] = pos
+			}
+		}
+
+		envFile := EnvironmentConfigFile(filename, env)
+		if envData, err := ioutil.ReadFile(envFile); err == nil {
+			envPositions, envProblems := checkConfigDocument(envFile, envData)
+			problems = append(problems, envProblems...)
+			for path, pos := range envPositions {
+				positions[path] = pos
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	if env != "" {
+		data, err = applyEnvironment(filename, data, env)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file %s: %w", filename, err)
 	}
 
@@ -139,10 +284,18 @@ func LoadConfig(filename string) (*Config, error) {
 	}
 	if config.Scaling.Max == 0 {
 		config.Scaling.Max = 10
+		// Volumes written by a single pod rule out autoscaling
+		if config.hasSingleWriterVolume() {
+			config.Scaling.Max = config.Scaling.Min
+		}
 	}
 	if config.Scaling.TargetCPU == 0 {
 		config.Scaling.TargetCPU = 70
 	}
 
+	config.App.Environment = env
+	config.dir = filepath.Dir(filename)
+	config.positions = positions
+
 	return &config, nil
 }
\ No newline at end of file
@@ -0,0 +1,141 @@
+package manifests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+const cronJobTemplate = `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: {{ .Job.Resource }}
+  namespace: {{ .App.GetNamespace }}
+  labels:
+    app: {{ .Job.Resource }}
+    managed-by: shipyard
+    shipyard.app: {{ .App.GetDNSName }}
+    shipyard.job: {{ .Job.Name }}
+    {{- if .Version }}
+    shipyard.version: "{{ .Version.Version }}"
+    shipyard.image-tag: "{{ .Version.ImageTag }}"
+    {{- end }}
+spec:
+  schedule: "{{ .Job.Schedule }}"
+  {{- if .Job.Suspend }}
+  suspend: true
+  {{- end }}
+  concurrencyPolicy: {{ .Job.ConcurrencyPolicy }}
+  successfulJobsHistoryLimit: {{ .Job.SuccessfulHistory }}
+  failedJobsHistoryLimit: {{ .Job.FailedHistory }}
+  jobTemplate:
+    metadata:
+      labels:
+        app: {{ .Job.Resource }}
+        managed-by: shipyard
+        shipyard.app: {{ .App.GetDNSName }}
+        shipyard.job: {{ .Job.Name }}
+    spec:
+      {{- if .Job.ActiveDeadlineSeconds }}
+      activeDeadlineSeconds: {{ .Job.ActiveDeadlineSeconds }}
+      {{- end }}
+      template:
+        metadata:
+          labels:
+            app: {{ .Job.Resource }}
+            shipyard.app: {{ .App.GetDNSName }}
+            shipyard.job: {{ .Job.Name }}
+          {{- if .FilesHash }}
+          annotations:
+            shipyard.files-hash: "{{ .FilesHash }}"
+          {{- end }}
+        spec:
+          restartPolicy: Never
+          {{- if .ImagePullSecrets }}
+          imagePullSecrets:
+          {{- range .ImagePullSecrets }}
+          - name: {{ . }}
+          {{- end }}
+          {{- end }}
+          {{- if .ConfigFiles }}
+          volumes:
+          - name: shipyard-files
+            configMap:
+              name: {{ .FilesConfigMap }}
+          {{- end }}
+          containers:
+          - name: {{ .Job.Resource }}
+            image: {{ .App.Image }}
+            {{- if .Job.Command }}
+            command:
+            {{- range .Job.Command }}
+            - {{ printf "%q" . }}
+            {{- end }}
+            {{- end }}
+            env:
+            {{- range $key, $value := .Env }}
+            - name: {{ $key }}
+              value: "{{ $value }}"
+            {{- end }}
+            {{- if .Secrets }}
+            envFrom:
+            - secretRef:
+                name: {{ .App.GetDNSName }}-secrets
+            {{- end }}
+            {{- if .ConfigFiles }}
+            volumeMounts:
+            {{- range .ConfigFiles }}
+            - name: shipyard-files
+              mountPath: {{ .MountPath }}
+              subPath: {{ .Key }}
+              readOnly: true
+            {{- end }}
+            {{- end }}
+            resources:
+              requests:
+                cpu: {{ .Job.Resources.CPU }}
+                memory: {{ .Job.Resources.Memory }}
+              limits:
+                cpu: {{ .Job.Resources.CPU }}
+                memory: {{ .Job.Resources.Memory }}
+`
+
+// generateCronJob creates the cronjob-<job>.yaml file of a job
+func (g *Generator) generateCronJob(appDir string, job Job) error {
+	tmpl, err := template.New("cronjob").Parse(cronJobTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse cronjob template: %w", err)
+	}
+
+	filePath := filepath.Join(appDir, fmt.Sprintf("cronjob-%s.yaml", job.Name))
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create cronjob file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	templateData := struct {
+		*Config
+		Job              Job
+		Version          *DeploymentVersion
+		ImagePullSecrets []string
+		ConfigFiles      []ConfigFile
+		FilesConfigMap   string
+		FilesHash        string
+	}{
+		Config:           g.config,
+		Job:              job,
+		Version:          g.version,
+		ImagePullSecrets: g.imagePullSecrets,
+		ConfigFiles:      g.configFiles,
+		FilesConfigMap:   g.config.App.GetDNSName() + "-files",
+		FilesHash:        configFilesHash(g.configFiles),
+	}
+
+	if err := tmpl.Execute(file, templateData); err != nil {
+		return fmt.Errorf("failed to execute cronjob template: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package manifests
+
+import (
+	"reflect"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+func TestGenerateCronJobs(t *testing.T) {
+	objects := renderApp(t, `app:
+  name: shop
+  image: ghcr.io/company/shop:v1
+  port: 3000
+resources:
+  cpu: 250m
+  memory: 256Mi
+env:
+  LOG_LEVEL: info
+secrets:
+  DATABASE_URL: postgres://shop
+jobs:
+  cleanup:
+    schedule: "0 3 * * *"
+    command: [bin/cleanup, --older-than, 30d]
+  report:
+    schedule: "@weekly"
+    command: bin/report
+    concurrency_policy: Replace
+    successful_history: 5
+    failed_history: 0
+    timeout: 30m
+    resources:
+      memory: 1Gi
+  reindex:
+    command: bin/reindex
+`)
+
+	if got, want := renderedKeys(objects, "CronJob"), []string{"CronJob/shop-cleanup", "CronJob/shop-reindex", "CronJob/shop-report"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("rendered %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		job               string
+		schedule          string
+		suspend           bool
+		command           []string
+		concurrencyPolicy batchv1.ConcurrencyPolicy
+		successfulHistory int32
+		failedHistory     int32
+		deadline          *int64
+		memory            string
+	}{
+		{
+			job:               "cleanup",
+			schedule:          "0 3 * * *",
+			command:           []string{"bin/cleanup", "--older-than", "30d"},
+			concurrencyPolicy: batchv1.ForbidConcurrent,
+			successfulHistory: 3,
+			failedHistory:     1,
+			memory:            "256Mi",
+		},
+		{
+			job:               "report",
+			schedule:          "@weekly",
+			command:           []string{"bin/report"},
+			concurrencyPolicy: batchv1.ReplaceConcurrent,
+			successfulHistory: 5,
+			failedHistory:     0,
+			deadline:          int64Ptr(1800),
+			memory:            "1Gi",
+		},
+		{
+			job:               "reindex",
+			schedule:          onDemandSchedule,
+			suspend:           true,
+			command:           []string{"bin/reindex"},
+			concurrencyPolicy: batchv1.ForbidConcurrent,
+			successfulHistory: 3,
+			failedHistory:     1,
+			memory:            "256Mi",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.job, func(t *testing.T) {
+			var cronJob batchv1.CronJob
+			renderedObject(t, objects, "CronJob/shop-"+test.job, &cronJob)
+
+			spec := cronJob.Spec
+			if spec.Schedule != test.schedule {
+				t.Errorf("schedule = %q, want %q", spec.Schedule, test.schedule)
+			}
+			if suspended := spec.Suspend != nil && *spec.Suspend; suspended != test.suspend {
+				t.Errorf("suspend = %v, want %v", suspended, test.suspend)
+			}
+			if spec.ConcurrencyPolicy != test.concurrencyPolicy {
+				t.Errorf("concurrencyPolicy = %s, want %s", spec.ConcurrencyPolicy, test.concurrencyPolicy)
+			}
+			if *spec.SuccessfulJobsHistoryLimit != test.successfulHistory || *spec.FailedJobsHistoryLimit != test.failedHistory {
+				t.Errorf("history limits = %d/%d, want %d/%d", *spec.SuccessfulJobsHistoryLimit, *spec.FailedJobsHistoryLimit,
+					test.successfulHistory, test.failedHistory)
+			}
+			if !reflect.DeepEqual(spec.JobTemplate.Spec.ActiveDeadlineSeconds, test.deadline) {
+				t.Errorf("activeDeadlineSeconds = %v, want %v", spec.JobTemplate.Spec.ActiveDeadlineSeconds, test.deadline)
+			}
+
+			pod := spec.JobTemplate.Spec.Template
+			wantLabels := map[string]string{"app": "shop-" + test.job, "shipyard.app": "shop", "shipyard.job": test.job}
+			if !reflect.DeepEqual(pod.Labels, wantLabels) {
+				t.Errorf("pod labels = %v, want %v", pod.Labels, wantLabels)
+			}
+			if pod.Spec.RestartPolicy != "Never" {
+				t.Errorf("restartPolicy = %s, want Never", pod.Spec.RestartPolicy)
+			}
+
+			// Jobs run the app image with the env and secrets of the app
+			container := pod.Spec.Containers[0]
+			if container.Image != "ghcr.io/company/shop:v1" {
+				t.Errorf("image = %s, want the app image", container.Image)
+			}
+			if !reflect.DeepEqual(container.Command, test.command) {
+				t.Errorf("command = %q, want %q", container.Command, test.command)
+			}
+			if len(container.Env) != 1 || container.Env[0].Name != "LOG_LEVEL" || container.Env[0].Value != "info" {
+				t.Errorf("env = %v, want LOG_LEVEL=info", container.Env)
+			}
+			if len(container.EnvFrom) != 1 || container.EnvFrom[0].SecretRef.Name != "shop-secrets" {
+				t.Errorf("envFrom = %v, want the shop-secrets secret", container.EnvFrom)
+			}
+			if got := container.Resources.Limits.Memory().String(); got != test.memory {
+				t.Errorf("memory limit = %s, want %s", got, test.memory)
+			}
+		})
+	}
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
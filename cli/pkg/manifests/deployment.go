@@ -7,14 +7,19 @@ import (
 	"text/template"
 )
 
+// deploymentTemplate renders the Deployment of a process. Autoscaled processes
+// leave spec.replicas to their HorizontalPodAutoscaler, so deploys never
+// reset it.
 const deploymentTemplate = `apiVersion: apps/v1
 kind: Deployment
 metadata:
-  name: {{ .App.GetDNSName }}
+  name: {{ .Process.Resource }}
   namespace: {{ .App.GetNamespace }}
   labels:
-    app: {{ .App.GetDNSName }}
+    app: {{ .Process.Resource }}
     managed-by: shipyard
+    shipyard.app: {{ .App.GetDNSName }}
+    shipyard.process: {{ .Process.Name }}
     {{- if .Version }}
     shipyard.version: "{{ .Version.Version }}"
     shipyard.image-tag: "{{ .Version.ImageTag }}"
@@ -25,14 +30,26 @@ metadata:
     {{- end }}
     {{- end }}
 spec:
-  replicas: {{ .Scaling.Min }}
+  {{- if not .Process.Autoscaled }}
+  replicas: {{ .Process.Scaling.Min }}
+  {{- end }}
+  {{- if .Process.Recreate }}
+  strategy:
+    type: Recreate
+  {{- end }}
   selector:
     matchLabels:
-      app: {{ .App.GetDNSName }}
+      app: {{ .Process.Resource }}
   template:
     metadata:
       labels:
-        app: {{ .App.GetDNSName }}
+        app: {{ .Process.Resource }}
+        shipyard.app: {{ .App.GetDNSName }}
+        shipyard.process: {{ .Process.Name }}
+      {{- if .FilesHash }}
+      annotations:
+        shipyard.files-hash: "{{ .FilesHash }}"
+      {{- end }}
     spec:
       {{- if .ImagePullSecrets }}
       imagePullSecrets:
@@ -40,11 +57,35 @@ spec:
       - name: {{ . }}
       {{- end }}
       {{- end }}
+      {{- if or .Process.Volumes .ConfigFiles }}
+      volumes:
+      {{- range .Process.Volumes }}
+      - name: {{ .Name }}
+        persistentVolumeClaim:
+          claimName: {{ .Claim }}
+          {{- if .ReadOnly }}
+          readOnly: true
+          {{- end }}
+      {{- end }}
+      {{- if .ConfigFiles }}
+      - name: shipyard-files
+        configMap:
+          name: {{ .FilesConfigMap }}
+      {{- end }}
+      {{- end }}
       containers:
-      - name: {{ .App.GetDNSName }}
+      - name: {{ .Process.Resource }}
         image: {{ .App.Image }}
+        {{- if .Process.Command }}
+        command:
+        {{- range .Process.Command }}
+        - {{ printf "%q" . }}
+        {{- end }}
+        {{- end }}
+        {{- if .Process.Port }}
         ports:
-        - containerPort: {{ .App.Port }}
+        - containerPort: {{ .Process.Port }}
+        {{- end }}
         env:
         {{- range $key, $value := .Env }}
         - name: {{ $key }}
@@ -57,23 +98,40 @@ spec:
         {{- end }}
         resources:
           requests:
-            cpu: {{ .Resources.CPU }}
-            memory: {{ .Resources.Memory }}
+            cpu: {{ .Process.Resources.CPU }}
+            memory: {{ .Process.Resources.Memory }}
           limits:
-            cpu: {{ .Resources.CPU }}
-            memory: {{ .Resources.Memory }}
+            cpu: {{ .Process.Resources.CPU }}
+            memory: {{ .Process.Resources.Memory }}
+        {{- if or .Process.Volumes .ConfigFiles }}
+        volumeMounts:
+        {{- range .Process.Volumes }}
+        - name: {{ .Name }}
+          mountPath: {{ .MountPath }}
+          {{- if .ReadOnly }}
+          readOnly: true
+          {{- end }}
+        {{- end }}
+        {{- range .ConfigFiles }}
+        - name: shipyard-files
+          mountPath: {{ .MountPath }}
+          subPath: {{ .Key }}
+          readOnly: true
+        {{- end }}
+        {{- end }}
+        {{- if .Process.Ingress }}
         {{- if .Health.Liveness.Path }}
         livenessProbe:
           httpGet:
             path: {{ .Health.Liveness.Path }}
-            port: {{ if .Health.Liveness.Port }}{{ .Health.Liveness.Port }}{{ else }}{{ .App.Port }}{{ end }}
+            port: {{ if .Health.Liveness.Port }}{{ .Health.Liveness.Port }}{{ else }}{{ .Process.Port }}{{ end }}
           initialDelaySeconds: {{ if .Health.Liveness.InitialDelaySeconds }}{{ .Health.Liveness.InitialDelaySeconds }}{{ else }}30{{ end }}
           periodSeconds: {{ if .Health.Liveness.PeriodSeconds }}{{ .Health.Liveness.PeriodSeconds }}{{ else }}10{{ end }}
         {{- else }}
         livenessProbe:
           httpGet:
             path: /
-            port: {{ .App.Port }}
+            port: {{ .Process.Port }}
           initialDelaySeconds: 30
           periodSeconds: 10
         {{- end }}
@@ -81,49 +139,51 @@ spec:
         readinessProbe:
           httpGet:
             path: {{ .Health.Readiness.Path }}
-            port: {{ if .Health.Readiness.Port }}{{ .Health.Readiness.Port }}{{ else }}{{ .App.Port }}{{ end }}
+            port: {{ if .Health.Readiness.Port }}{{ .Health.Readiness.Port }}{{ else }}{{ .Process.Port }}{{ end }}
           initialDelaySeconds: {{ if .Health.Readiness.InitialDelaySeconds }}{{ .Health.Readiness.InitialDelaySeconds }}{{ else }}5{{ end }}
           periodSeconds: {{ if .Health.Readiness.PeriodSeconds }}{{ .Health.Readiness.PeriodSeconds }}{{ else }}5{{ end }}
         {{- else }}
         readinessProbe:
           httpGet:
             path: /
-            port: {{ .App.Port }}
+            port: {{ .Process.Port }}
           initialDelaySeconds: 5
           periodSeconds: 5
         {{- end }}
+        {{- end }}
 ---
-{{- if gt .Scaling.Max .Scaling.Min }}
+{{- if .Process.Autoscaled }}
 apiVersion: autoscaling/v2
 kind: HorizontalPodAutoscaler
 metadata:
-  name: {{ .App.GetDNSName }}-hpa
+  name: {{ .Process.Resource }}-hpa
   namespace: {{ .App.GetNamespace }}
 spec:
   scaleTargetRef:
     apiVersion: apps/v1
     kind: Deployment
-    name: {{ .App.GetDNSName }}
-  minReplicas: {{ .Scaling.Min }}
-  maxReplicas: {{ .Scaling.Max }}
+    name: {{ .Process.Resource }}
+  minReplicas: {{ .Process.Scaling.Min }}
+  maxReplicas: {{ .Process.Scaling.Max }}
   metrics:
   - type: Resource
     resource:
       name: cpu
       target:
         type: Utilization
-        averageUtilization: {{ .Scaling.TargetCPU }}
+        averageUtilization: {{ .Process.Scaling.TargetCPU }}
 {{- end }}
 `
 
-// generateDeployment creates the deployment.yaml file for an application
-func (g *Generator) generateDeployment(appDir string) error {
+// generateDeployment creates the deployment file of a process: deployment.yaml
+// for the process receiving ingress, deployment-<process>.yaml for the others
+func (g *Generator) generateDeployment(appDir string, process Process) error {
 	tmpl, err := template.New("deployment").Parse(deploymentTemplate)
 	if err != nil {
 		return fmt.Errorf("failed to parse deployment template: %w", err)
 	}
 
-	filePath := filepath.Join(appDir, "deployment.yaml")
+	filePath := filepath.Join(appDir, processFileName("deployment", process))
 	file, err := os.Create(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to create deployment file %s: %w", filePath, err)
@@ -133,12 +193,20 @@ func (g *Generator) generateDeployment(appDir string) error {
 	// Create template data with version info
 	templateData := struct {
 		*Config
+		Process          Process
 		Version          *DeploymentVersion
 		ImagePullSecrets []string
+		ConfigFiles      []ConfigFile
+		FilesConfigMap   string
+		FilesHash        string
 	}{
 		Config:           g.config,
+		Process:          process,
 		Version:          g.version,
 		ImagePullSecrets: g.imagePullSecrets,
+		ConfigFiles:      g.configFiles,
+		FilesConfigMap:   g.config.App.GetDNSName() + "-files",
+		FilesHash:        configFilesHash(g.configFiles),
 	}
 
 	if err := tmpl.Execute(file, templateData); err != nil {
@@ -146,4 +214,13 @@ func (g *Generator) generateDeployment(appDir string) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// processFileName returns the manifest file name of a process, e.g.
+// deployment.yaml or deployment-worker.yaml
+func processFileName(kind string, process Process) string {
+	if process.Ingress {
+		return kind + ".yaml"
+	}
+	return fmt.Sprintf("%s-%s.yaml", kind, process.Name)
+}
@@ -0,0 +1,250 @@
+package manifests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// environmentNamePattern matches environment names, which suffix the app
+// name and namespace
+var environmentNamePattern = regexp.MustCompile(`^[a-z]([-a-z0-9]*[a-z0-9])?$`)
+
+// EnvironmentConfigFile returns the overlay file of an environment, next to
+// the base config file: paas.staging.yaml for paas.yaml
+func EnvironmentConfigFile(filename, env string) string {
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + "." + env + ext
+}
+
+// EnvironmentAppName returns the name an app is deployed under in an
+// environment, when the environment does not set app.name
+func EnvironmentAppName(name, env string) string {
+	if env == "" {
+		return name
+	}
+	return name + "-" + env
+}
+
+// ListEnvironments returns the environments defined for a config file, in
+// its environments block or by an overlay file next to it
+func ListEnvironments(filename string) ([]string, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", filename, err)
+	}
+
+	var base struct {
+		Environments map[string]interface{} `yaml:"environments"`
+	}
+	if err := yaml.Unmarshal(data, &base); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", filename, err)
+	}
+
+	seen := make(map[string]bool)
+	for name := range base.Environments {
+		seen[name] = true
+	}
+
+	matches, err := filepath.Glob(EnvironmentConfigFile(filename, "*"))
+	if err != nil {
+		return nil, err
+	}
+	ext := filepath.Ext(filename)
+	prefix := strings.TrimSuffix(filename, ext) + "."
+	for _, match := range matches {
+		name := strings.TrimSuffix(strings.TrimPrefix(match, prefix), ext)
+		if environmentNamePattern.MatchString(name) {
+			seen[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// applyEnvironment merges the environments.<env> block of a config document,
+// then the overlay file of the environment, over the base document. Mappings
+// are merged key by key; lists and values replace those of the base.
+//
+// Environments run side by side: unless the overlay sets them, the app name
+// and namespace get the environment as suffix, and domains are not inherited
+// since a hostname belongs to a single app.
+func applyEnvironment(filename string, data []byte, env string) ([]byte, error) {
+	if !environmentNamePattern.MatchString(env) {
+		return nil, fmt.Errorf("invalid environment name %q: use lowercase letters, digits and hyphens", env)
+	}
+
+	var base map[interface{}]interface{}
+	if err := yaml.Unmarshal(data, &base); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", filename, err)
+	}
+	if base == nil {
+		base = make(map[interface{}]interface{})
+	}
+
+	overlays, err := environmentOverlays(filename, base, env)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(base, "environments")
+	merged := base
+	for _, overlay := range overlays {
+		merged = mergeYAMLMaps(merged, overlay)
+	}
+
+	app, _ := merged["app"].(map[interface{}]interface{})
+	if app == nil {
+		app = make(map[interface{}]interface{})
+		merged["app"] = app
+	}
+	if !overlaysSet(overlays, "app", "name") {
+		if name, ok := app["name"].(string); ok && name != "" {
+			app["name"] = EnvironmentAppName(name, env)
+		}
+	}
+	if !overlaysSet(overlays, "app", "namespace") {
+		if namespace, ok := app["namespace"].(string); ok && namespace != "" {
+			app["namespace"] = EnvironmentAppName(namespace, env)
+		}
+	}
+	if !overlaysSet(overlays, "domains") {
+		delete(merged, "domains")
+	}
+
+	return yaml.Marshal(merged)
+}
+
+// environmentOverlays returns the environments.<env> block of a parsed config
+// document and the content of the overlay file of env, in merge order
+func environmentOverlays(filename string, base map[interface{}]interface{}, env string) ([]map[interface{}]interface{}, error) {
+	var overlays []map[interface{}]interface{}
+
+	environments, _ := base["environments"].(map[interface{}]interface{})
+	if block, ok := environments[env]; ok {
+		overlay, isMap := block.(map[interface{}]interface{})
+		if block != nil && !isMap {
+			return nil, fmt.Errorf("environments.%s in %s must be a mapping", env, filename)
+		}
+		overlays = append(overlays, overlay)
+	}
+
+	envFile := EnvironmentConfigFile(filename, env)
+	envData, err := ioutil.ReadFile(envFile)
+	if err == nil {
+		var overlay map[interface{}]interface{}
+		if err := yaml.Unmarshal(envData, &overlay); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", envFile, err)
+		}
+		overlays = append(overlays, overlay)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read config file %s: %w", envFile, err)
+	}
+
+	if len(overlays) == 0 {
+		return nil, fmt.Errorf("environment %s is not defined: add environments.%s to %s or create %s%s",
+			env, env, filename, envFile, availableEnvironments(environments))
+	}
+
+	for _, overlay := range overlays {
+		delete(overlay, "environments")
+	}
+	return overlays, nil
+}
+
+// PromoteConfig returns the config to deploy in env, or in the base config
+// when env is empty, when promoting the recorded config of a version from
+// another environment. The recorded config is deployed as it was tested;
+// only what belongs to the target comes from env, loaded from filename: the
+// app name and namespace, the domains, and the env values and secrets.
+func PromoteConfig(filename string, recorded *Config, env string) (*Config, error) {
+	target, err := LoadConfigForEnv(filename, env)
+	if err != nil {
+		return nil, err
+	}
+
+	config := *recorded
+	config.App.Name = target.App.Name
+	config.App.Namespace = target.App.Namespace
+	config.App.Environment = target.App.Environment
+	config.Domains = target.Domains
+	config.Env = target.Env
+	config.Secrets = target.Secrets
+	config.Environments = nil
+
+	// Local files are read from the project, like on a deploy
+	config.dir = target.dir
+	config.positions = target.positions
+
+	return &config, nil
+}
+
+// mergeYAMLMaps returns base with the keys of overlay merged over it,
+// recursively for mappings. A null value in the overlay clears the key.
+func mergeYAMLMaps(base, overlay map[interface{}]interface{}) map[interface{}]interface{} {
+	merged := make(map[interface{}]interface{}, len(base)+len(overlay))
+	for key, value := range base {
+		merged[key] = value
+	}
+
+	for key, value := range overlay {
+		baseMap, baseIsMap := merged[key].(map[interface{}]interface{})
+		overlayMap, overlayIsMap := value.(map[interface{}]interface{})
+		if baseIsMap && overlayIsMap {
+			merged[key] = mergeYAMLMaps(baseMap, overlayMap)
+			continue
+		}
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+
+	return merged
+}
+
+// overlaysSet reports whether one of the overlays sets the key at path
+func overlaysSet(overlays []map[interface{}]interface{}, path ...string) bool {
+	for _, overlay := range overlays {
+		node := overlay
+		for i, key := range path {
+			value, ok := node[key]
+			if !ok {
+				break
+			}
+			if i == len(path)-1 {
+				return true
+			}
+			if node, ok = value.(map[interface{}]interface{}); !ok {
+				break
+			}
+		}
+	}
+	return false
+}
+
+// availableEnvironments lists the environments of a config block for error messages
+func availableEnvironments(environments map[interface{}]interface{}) string {
+	if len(environments) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(environments))
+	for name := range environments {
+		names = append(names, fmt.Sprint(name))
+	}
+	sort.Strings(names)
+	return fmt.Sprintf(" (defined: %s)", strings.Join(names, ", "))
+}
@@ -0,0 +1,94 @@
+package manifests
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPromoteConfig(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "paas.yaml")
+	paas := `app:
+  name: shop
+  image: ghcr.io/company/shop:working-tree
+  port: 3000
+env:
+  LOG_LEVEL: info
+secrets:
+  DATABASE_URL: postgres://base
+resources:
+  cpu: 100m
+processes:
+  web: {}
+environments:
+  production:
+    domains: [shop.example.com]
+    env:
+      LOG_LEVEL: warn
+    secrets:
+      DATABASE_URL: postgres://production
+`
+	if err := os.WriteFile(filename, []byte(paas), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The config recorded by the staging release differs from the working tree
+	recorded := &Config{
+		App:       AppConfig{Name: "shop-staging", Image: "ghcr.io/company/shop:v2.2.0", Port: 8080, Namespace: "shop-staging"},
+		Resources: ResourcesConfig{CPU: "250m", Memory: "512Mi"},
+		Env:       map[string]string{"LOG_LEVEL": "debug", "STAGING_BANNER": "1"},
+		Secrets:   map[string]string{"DATABASE_URL": "postgres://staging"},
+		Domains:   []string{"staging.shop.example.com"},
+		Processes: map[string]ProcessConfig{
+			"web":    {},
+			"worker": {Command: Command{"bin/worker"}, Replicas: 2},
+		},
+		Jobs:    map[string]JobConfig{"cleanup": {Schedule: "@daily", Command: Command{"bin/cleanup"}}},
+		Release: &ReleaseConfig{Command: Command{"bin/migrate"}, Timeout: "20m"},
+		Volumes: []VolumeConfig{{Name: "data", MountPath: "/data", Size: "1Gi"}},
+	}
+
+	config, err := PromoteConfig(filename, recorded, "production")
+	if err != nil {
+		t.Fatalf("PromoteConfig() failed: %v", err)
+	}
+
+	// Identity, domains, env and secrets are those of the target
+	if config.App.Name != "shop-production" || config.App.GetNamespace() != "shop-production" {
+		t.Errorf("app = %s in %s, want shop-production in shop-production", config.App.Name, config.App.GetNamespace())
+	}
+	if !reflect.DeepEqual(config.Domains, []string{"shop.example.com"}) {
+		t.Errorf("domains = %v, want the target's", config.Domains)
+	}
+	if !reflect.DeepEqual(config.Env, map[string]string{"LOG_LEVEL": "warn"}) {
+		t.Errorf("env = %v, want the target's", config.Env)
+	}
+	if config.Secrets["DATABASE_URL"] != "postgres://production" {
+		t.Errorf("secrets = %v, want the target's", config.Secrets)
+	}
+
+	// Everything else is the recorded config
+	if config.App.Image != recorded.App.Image || config.App.Port != 8080 {
+		t.Errorf("app = %+v, want the recorded image and port", config.App)
+	}
+	if config.Resources != recorded.Resources {
+		t.Errorf("resources = %+v, want %+v", config.Resources, recorded.Resources)
+	}
+	for name, value := range map[string][2]interface{}{
+		"processes": {config.Processes, recorded.Processes},
+		"jobs":      {config.Jobs, recorded.Jobs},
+		"release":   {config.Release, recorded.Release},
+		"volumes":   {config.Volumes, recorded.Volumes},
+	} {
+		if !reflect.DeepEqual(value[0], value[1]) {
+			t.Errorf("%s = %+v, want the recorded %+v", name, value[0], value[1])
+		}
+	}
+
+	// The recorded config is left untouched
+	if recorded.App.Name != "shop-staging" || recorded.Env["LOG_LEVEL"] != "debug" {
+		t.Errorf("recorded config was modified: %+v", recorded)
+	}
+}
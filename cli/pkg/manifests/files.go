@@ -0,0 +1,186 @@
+package manifests
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// filesVolume is the name of the pod volume holding the config files
+const filesVolume = "shipyard-files"
+
+// maxConfigMapSize is the size limit of a ConfigMap enforced by the API server
+const maxConfigMapSize = 1024 * 1024
+
+// configMapKeyInvalidChars matches the characters not allowed in ConfigMap keys
+var configMapKeyInvalidChars = regexp.MustCompile(`[^-._a-zA-Z0-9]`)
+
+// ConfigFile is a local file of the project mounted into the containers of the app
+type ConfigFile struct {
+	Key       string // key in the ConfigMap
+	LocalPath string
+	MountPath string
+	Content   string // base64 encoded when Binary
+	Binary    bool
+}
+
+// GetConfigFiles reads the files of the app, sorted by mount path. Local
+// paths are relative to the directory of paas.yaml.
+func (c *Config) GetConfigFiles() ([]ConfigFile, error) {
+	files := make([]ConfigFile, 0, len(c.Files))
+	keys := make(map[string]string)
+	total := 0
+
+	for localPath, mountPath := range c.Files {
+		if !strings.HasPrefix(mountPath, "/") {
+			return nil, fmt.Errorf("file %s: mount path %q must be absolute", localPath, mountPath)
+		}
+
+		path := localPath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.dir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", localPath, err)
+		}
+		total += len(data)
+
+		key := configMapKeyInvalidChars.ReplaceAllString(strings.TrimPrefix(filepath.ToSlash(filepath.Clean(localPath)), "/"), "_")
+		if other, ok := keys[key]; ok {
+			return nil, fmt.Errorf("files %s and %s map to the same key %s", other, localPath, key)
+		}
+		keys[key] = localPath
+
+		file := ConfigFile{
+			Key:       key,
+			LocalPath: localPath,
+			MountPath: mountPath,
+			Content:   string(data),
+		}
+		if !utf8.Valid(data) {
+			file.Content = base64.StdEncoding.EncodeToString(data)
+			file.Binary = true
+		}
+		files = append(files, file)
+	}
+
+	if total > maxConfigMapSize {
+		return nil, fmt.Errorf("files total %d bytes, more than the %d bytes a ConfigMap can hold", total, maxConfigMapSize)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].MountPath < files[j].MountPath
+	})
+	for i := 1; i < len(files); i++ {
+		if files[i].MountPath == files[i-1].MountPath {
+			return nil, fmt.Errorf("files %s and %s are both mounted on %s", files[i-1].LocalPath, files[i].LocalPath, files[i].MountPath)
+		}
+	}
+
+	return files, nil
+}
+
+// configFilesHash returns a short hash of the mounted files, so that changing
+// a file changes the pod template and triggers a rollout
+func configFilesHash(files []ConfigFile) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	hash := sha256.New()
+	for _, file := range files {
+		fmt.Fprintf(hash, "%s\x00%s\x00%s\x00", file.Key, file.MountPath, file.Content)
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+const configMapTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Name }}
+  namespace: {{ .App.GetNamespace }}
+  labels:
+    app: {{ .App.GetDNSName }}
+    managed-by: shipyard
+    shipyard.app: {{ .App.GetDNSName }}
+  annotations:
+    shipyard.files-hash: "{{ .FilesHash }}"
+{{- if .TextFiles }}
+data:
+{{- range .TextFiles }}
+  {{ .Key }}: {{ printf "%q" .Content }}
+{{- end }}
+{{- end }}
+{{- if .BinaryFiles }}
+binaryData:
+{{- range .BinaryFiles }}
+  {{ .Key }}: {{ .Content }}
+{{- end }}
+{{- end }}
+`
+
+// renderConfigMap renders the ConfigMap holding the config files
+func (g *Generator) renderConfigMap(file *os.File, name string) error {
+	tmpl, err := template.New("configmap").Parse(configMapTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse configmap template: %w", err)
+	}
+
+	var textFiles, binaryFiles []ConfigFile
+	for _, configFile := range g.configFiles {
+		if configFile.Binary {
+			binaryFiles = append(binaryFiles, configFile)
+		} else {
+			textFiles = append(textFiles, configFile)
+		}
+	}
+
+	templateData := struct {
+		*Config
+		Name        string
+		FilesHash   string
+		TextFiles   []ConfigFile
+		BinaryFiles []ConfigFile
+	}{
+		Config:      g.config,
+		Name:        name,
+		FilesHash:   configFilesHash(g.configFiles),
+		TextFiles:   textFiles,
+		BinaryFiles: binaryFiles,
+	}
+
+	if err := tmpl.Execute(file, templateData); err != nil {
+		return fmt.Errorf("failed to execute configmap template: %w", err)
+	}
+
+	return nil
+}
+
+// generateConfigMap creates configmap.yaml with the config files of the app,
+// and removes it from apps without files
+func (g *Generator) generateConfigMap(appDir string) error {
+	filePath := filepath.Join(appDir, "configmap.yaml")
+	if len(g.configFiles) == 0 {
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", filePath, err)
+		}
+		return nil
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create configmap file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	return g.renderConfigMap(file, g.config.App.GetDNSName()+"-files")
+}
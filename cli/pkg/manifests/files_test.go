@@ -0,0 +1,200 @@
+package manifests
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// filesConfig mounts files into every process, job and the release
+const filesConfig = `app:
+  name: shop
+  image: ghcr.io/company/shop:v1
+  port: 3000
+processes:
+  web: {}
+  worker:
+    command: bin/worker
+jobs:
+  cleanup:
+    schedule: "@daily"
+    command: bin/cleanup
+release: bin/migrate
+files:
+  config/nginx.conf: /etc/nginx/nginx.conf
+  config/front matter.md: /app/pages/home.md
+  logo.png: /app/public/logo.png
+`
+
+// writeFilesConfig writes filesConfig and the files it mounts, with the
+// given content for nginx.conf, and returns the config file
+func writeFilesConfig(t *testing.T, nginx string) string {
+	t.Helper()
+	filename := writeConfig(t, filesConfig)
+	dir := filepath.Dir(filename)
+	files := map[string]string{
+		"config/nginx.conf":      nginx,
+		"config/front matter.md": "---\ntitle: \"Home\"\n---\nWelcome\n",
+		"logo.png":               "\x89PNG\r\n\x1a\n\x00\xff",
+	}
+	for path, content := range files {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filename
+}
+
+func TestGenerateConfigMap(t *testing.T) {
+	objects := renderConfigFile(t, writeFilesConfig(t, "worker_processes 2;\n"))
+
+	var configMap corev1.ConfigMap
+	renderedObject(t, objects, "ConfigMap/shop-files", &configMap)
+
+	wantData := map[string]string{
+		"config_nginx.conf":      "worker_processes 2;\n",
+		"config_front_matter.md": "---\ntitle: \"Home\"\n---\nWelcome\n",
+	}
+	if !reflect.DeepEqual(configMap.Data, wantData) {
+		t.Errorf("data = %q, want %q", configMap.Data, wantData)
+	}
+	if got := string(configMap.BinaryData["logo.png"]); got != "\x89PNG\r\n\x1a\n\x00\xff" {
+		t.Errorf("binaryData logo.png = %q, want the file content", got)
+	}
+	hash := configMap.Annotations["shipyard.files-hash"]
+	if hash == "" {
+		t.Fatal("configmap has no shipyard.files-hash annotation")
+	}
+
+	wantMounts := map[string]string{
+		"/app/pages/home.md":    "config_front_matter.md",
+		"/app/public/logo.png":  "logo.png",
+		"/etc/nginx/nginx.conf": "config_nginx.conf",
+	}
+
+	// Every pod of the app mounts the files, the release from its own ConfigMap
+	type filesPod struct {
+		spec      corev1.PodTemplateSpec
+		configMap string
+	}
+	pods := make(map[string]filesPod)
+	for _, name := range []string{"shop", "shop-worker"} {
+		var deployment appsv1.Deployment
+		renderedObject(t, objects, "Deployment/"+name, &deployment)
+		pods["Deployment/"+name] = filesPod{deployment.Spec.Template, "shop-files"}
+	}
+	var cronJob batchv1.CronJob
+	renderedObject(t, objects, "CronJob/shop-cleanup", &cronJob)
+	pods["CronJob/shop-cleanup"] = filesPod{cronJob.Spec.JobTemplate.Spec.Template, "shop-files"}
+	var release batchv1.Job
+	renderedObject(t, objects, "Job/shop-release", &release)
+	pods["Job/shop-release"] = filesPod{release.Spec.Template, "shop-release-files"}
+
+	for name, pod := range pods {
+		var volumeConfigMap string
+		for _, volume := range pod.spec.Spec.Volumes {
+			if volume.Name == filesVolume && volume.ConfigMap != nil {
+				volumeConfigMap = volume.ConfigMap.Name
+			}
+		}
+		if volumeConfigMap != pod.configMap {
+			t.Errorf("%s: %s volume from configmap %q, want %q", name, filesVolume, volumeConfigMap, pod.configMap)
+		}
+
+		mounts := make(map[string]string)
+		for _, mount := range pod.spec.Spec.Containers[0].VolumeMounts {
+			if mount.Name != filesVolume {
+				continue
+			}
+			mounts[mount.MountPath] = mount.SubPath
+			if !mount.ReadOnly {
+				t.Errorf("%s: %s is mounted read-write", name, mount.MountPath)
+			}
+		}
+		if !reflect.DeepEqual(mounts, wantMounts) {
+			t.Errorf("%s: mounts = %v, want %v", name, mounts, wantMounts)
+		}
+	}
+
+	// The release ConfigMap holds the same files
+	var releaseFiles corev1.ConfigMap
+	renderedObject(t, objects, "ConfigMap/shop-release-files", &releaseFiles)
+	if !reflect.DeepEqual(releaseFiles.Data, wantData) {
+		t.Errorf("release data = %q, want %q", releaseFiles.Data, wantData)
+	}
+
+	// Pods restart when a file changes
+	for _, name := range []string{"Deployment/shop", "Deployment/shop-worker", "CronJob/shop-cleanup"} {
+		if got := pods[name].spec.Annotations["shipyard.files-hash"]; got != hash {
+			t.Errorf("%s: files hash = %q, want %q", name, got, hash)
+		}
+	}
+	changed := renderConfigFile(t, writeFilesConfig(t, "worker_processes 4;\n"))
+	var changedDeployment appsv1.Deployment
+	renderedObject(t, changed, "Deployment/shop", &changedDeployment)
+	if got := changedDeployment.Spec.Template.Annotations["shipyard.files-hash"]; got == hash || got == "" {
+		t.Errorf("files hash = %q after changing nginx.conf, want a new hash", got)
+	}
+}
+
+func TestGetConfigFilesErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files string
+		err   string
+	}{
+		{
+			name:  "relative mount path",
+			files: "  config/nginx.conf: etc/nginx.conf\n",
+			err:   `mount path "etc/nginx.conf" must be absolute`,
+		},
+		{
+			name:  "missing file",
+			files: "  config/missing.conf: /etc/missing.conf\n",
+			err:   "failed to read file config/missing.conf",
+		},
+		{
+			name:  "same mount path",
+			files: "  config/nginx.conf: /etc/nginx.conf\n  config/front matter.md: /etc/nginx.conf\n",
+			err:   "are both mounted on /etc/nginx.conf",
+		},
+		{
+			name:  "same key",
+			files: "  config/nginx.conf: /etc/nginx.conf\n  config_nginx.conf: /etc/other.conf\n",
+			err:   "map to the same key config_nginx.conf",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filename := writeFilesConfig(t, "worker_processes 2;\n")
+			dir := filepath.Dir(filename)
+			if err := os.WriteFile(filepath.Join(dir, "config_nginx.conf"), []byte("other"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			content := filesConfig[:strings.Index(filesConfig, "files:\n")] + "files:\n" + test.files
+			if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := LoadConfig(filename)
+			if err != nil {
+				t.Fatalf("LoadConfig() failed: %v", err)
+			}
+			_, err = config.GetConfigFiles()
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("GetConfigFiles() error = %v, want %q", err, test.err)
+			}
+		})
+	}
+}
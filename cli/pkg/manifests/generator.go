@@ -16,8 +16,14 @@ type Generator struct {
 	outputDir        string
 	version          *DeploymentVersion // Add version tracking
 	imagePullSecrets []string           // Registry secrets for private images
+	configFiles      []ConfigFile       // Project files mounted into the containers
+	dryRun           bool               // Render only: leave the database untouched
+	redactSecrets    bool               // Replace the values of secrets in the manifests
 }
 
+// redactedValue replaces the values of secrets in rendered manifests
+const redactedValue = "<redacted>"
+
 // NewGenerator creates a new manifest generator
 func NewGenerator(cfg *Config) *Generator {
 	// Get manifests directory from global config
@@ -54,10 +60,25 @@ func NewGeneratorWithVersion(cfg *Config, version *DeploymentVersion) *Generator
 }
 
 
+// NewRenderGenerator creates a manifest generator writing to outputDir for a
+// dry run: the database is read but never changed and registries are
+// selected without prompting. With redactSecrets, the values of secrets are
+// left out of the manifests.
+func NewRenderGenerator(cfg *Config, version *DeploymentVersion, outputDir string, redactSecrets bool) *Generator {
+	return &Generator{
+		config:           cfg,
+		outputDir:        outputDir,
+		version:          version,
+		imagePullSecrets: []string{},
+		dryRun:           true,
+		redactSecrets:    redactSecrets,
+	}
+}
+
 // GenerateAppManifests creates all manifests for an application
 func (g *Generator) GenerateAppManifests() error {
 	// Handle CI/CD mode - check if this is initial deployment or update
-	if g.config.CICD.Enabled {
+	if g.config.CICD.Enabled && !g.dryRun {
 		return g.handleCICDDeployment()
 	}
 	
@@ -108,9 +129,40 @@ func (g *Generator) generateStandardManifests() error {
 	}
 	g.imagePullSecrets = imagePullSecrets
 
-	// Generate deployment.yaml
-	if err := g.generateDeployment(appDir); err != nil {
-		return fmt.Errorf("failed to generate deployment: %w", err)
+	processes, err := g.config.GetProcesses()
+	if err != nil {
+		return err
+	}
+
+	jobs, err := g.config.GetJobs()
+	if err != nil {
+		return err
+	}
+
+	// Remove the files of processes and jobs that were removed
+	if err := removeStaleFiles(appDir, processes, jobs); err != nil {
+		return err
+	}
+
+	// Generate configmap.yaml for the files mounted into the containers
+	g.configFiles, err = g.config.GetConfigFiles()
+	if err != nil {
+		return err
+	}
+	if err := g.generateConfigMap(appDir); err != nil {
+		return fmt.Errorf("failed to generate configmap: %w", err)
+	}
+
+	// Generate claims.yaml for the persistent volumes
+	if err := g.generateVolumeClaims(appDir); err != nil {
+		return fmt.Errorf("failed to generate volume claims: %w", err)
+	}
+
+	// Generate a deployment for every process
+	for _, process := range processes {
+		if err := g.generateDeployment(appDir, process); err != nil {
+			return fmt.Errorf("failed to generate deployment for process %s: %w", process.Name, err)
+		}
 	}
 
 	// Generate secrets.yaml (with base64 encoded values)
@@ -118,9 +170,58 @@ func (g *Generator) generateStandardManifests() error {
 		return fmt.Errorf("failed to generate secrets: %w", err)
 	}
 
-	// Generate service.yaml
-	if err := g.generateService(appDir); err != nil {
-		return fmt.Errorf("failed to generate service: %w", err)
+	// Generate a service for every process exposing one
+	for _, process := range processes {
+		if !process.Service {
+			continue
+		}
+		if err := g.generateService(appDir, process); err != nil {
+			return fmt.Errorf("failed to generate service for process %s: %w", process.Name, err)
+		}
+	}
+
+	// Generate a cronjob for every job
+	for _, job := range jobs {
+		if err := g.generateCronJob(appDir, job); err != nil {
+			return fmt.Errorf("failed to generate cronjob for job %s: %w", job.Name, err)
+		}
+	}
+
+	// Generate release/job.yaml for the release command
+	if err := g.generateRelease(appDir); err != nil {
+		return fmt.Errorf("failed to generate release job: %w", err)
+	}
+
+	return nil
+}
+
+// removeStaleFiles deletes the deployment, service and cronjob files that no
+// process or job generates anymore
+func removeStaleFiles(appDir string, processes []Process, jobs []Job) error {
+	current := make(map[string]bool)
+	for _, process := range processes {
+		current[processFileName("deployment", process)] = true
+		if process.Service {
+			current[processFileName("service", process)] = true
+		}
+	}
+	for _, job := range jobs {
+		current[fmt.Sprintf("cronjob-%s.yaml", job.Name)] = true
+	}
+
+	for _, pattern := range []string{"deployment*.yaml", "service*.yaml", "cronjob-*.yaml"} {
+		files, err := filepath.Glob(filepath.Join(appDir, pattern))
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if current[filepath.Base(file)] {
+				continue
+			}
+			if err := os.Remove(file); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", file, err)
+			}
+		}
 	}
 
 	return nil
@@ -128,6 +229,10 @@ func (g *Generator) generateStandardManifests() error {
 
 // UpdateIngressManifests updates shared ingress files by domain (now uses database)
 func (g *Generator) UpdateIngressManifests() error {
+	if g.dryRun {
+		return g.renderIngress()
+	}
+
 	// Use new database-based ingress generation
 	return g.UpdateIngressFromDatabase(g.config.App.Name)
 }
@@ -137,42 +242,54 @@ func CreateK8sClient() (*k8s.Client, error) {
 	return k8s.NewClient()
 }
 
-// DeleteManifestsFromDirectory deletes all Kubernetes resources from manifest files in a directory
-func DeleteManifestsFromDirectory(client *k8s.Client, directory string) error {
+// DeleteManifestsFromDirectory deletes all Kubernetes resources from manifest
+// files in a directory. With keepVolumes, the PersistentVolumeClaims of the
+// app and their data are left in the cluster.
+func DeleteManifestsFromDirectory(client *k8s.Client, directory string, keepVolumes bool) error {
 	if client == nil {
 		return fmt.Errorf("client is nil")
 	}
 	
-	// Extract app name from directory path
-	appName := filepath.Base(directory)
-	
-	// Use the client's delete method
-	return client.DeleteManifests(appName)
+	if keepVolumes {
+		return client.DeleteManifestsInDir(directory, VolumeClaimsFile)
+	}
+	return client.DeleteManifestsInDir(directory)
 }
 
 // updateDeploymentForCICD replaces the real image with ${IMAGE_TAG} placeholder
+// in the deployment of every process and the cronjob of every job
 func (g *Generator) updateDeploymentForCICD() error {
 	appDir := filepath.Join(g.outputDir, "apps", g.config.App.Name)
-	deploymentFile := filepath.Join(appDir, "deployment.yaml")
-	
-	// Read current deployment file
-	content, err := os.ReadFile(deploymentFile)
+	deploymentFiles, err := filepath.Glob(filepath.Join(appDir, "deployment*.yaml"))
 	if err != nil {
-		return fmt.Errorf("failed to read deployment file: %w", err)
+		return fmt.Errorf("failed to list deployment files: %w", err)
 	}
-	
-	// Replace the image line with ${IMAGE_TAG}
-	updatedContent := strings.ReplaceAll(string(content), 
-		fmt.Sprintf("image: %s", g.config.App.Image),
-		"image: ${IMAGE_TAG}")
-	
-	// Write updated content back
-	err = os.WriteFile(deploymentFile, []byte(updatedContent), 0644)
+	cronJobFiles, err := filepath.Glob(filepath.Join(appDir, "cronjob-*.yaml"))
 	if err != nil {
-		return fmt.Errorf("failed to write updated deployment file: %w", err)
+		return fmt.Errorf("failed to list cronjob files: %w", err)
 	}
+	deploymentFiles = append(deploymentFiles, cronJobFiles...)
 	
-	fmt.Printf("🔄 Updated deployment.yaml: %s → ${IMAGE_TAG}\n", g.config.App.Image)
+	for _, deploymentFile := range deploymentFiles {
+		// Read current deployment file
+		content, err := os.ReadFile(deploymentFile)
+		if err != nil {
+			return fmt.Errorf("failed to read deployment file: %w", err)
+		}
+		
+		// Replace the image line with ${IMAGE_TAG}
+		updatedContent := strings.ReplaceAll(string(content), 
+			fmt.Sprintf("image: %s", g.config.App.Image),
+			"image: ${IMAGE_TAG}")
+		
+		// Write updated content back
+		err = os.WriteFile(deploymentFile, []byte(updatedContent), 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write updated deployment file: %w", err)
+		}
+		
+		fmt.Printf("🔄 Updated %s: %s → ${IMAGE_TAG}\n", filepath.Base(deploymentFile), g.config.App.Image)
+	}
 	return nil
 }
 
@@ -0,0 +1,267 @@
+package manifests
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// renderApp renders the manifests of a config as shipyard render does, with
+// a home directory of its own, and returns the objects by kind/name
+func renderApp(t *testing.T, content string) map[string]*unstructured.Unstructured {
+	t.Helper()
+	return renderConfigFile(t, writeConfig(t, content))
+}
+
+// renderConfigFile renders the manifests of a config file, for configs
+// reading other files of their directory
+func renderConfigFile(t *testing.T, filename string) map[string]*unstructured.Unstructured {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	config, err := LoadConfig(filename)
+	if err != nil {
+		t.Fatalf("LoadConfig() failed: %v", err)
+	}
+
+	outputDir := t.TempDir()
+	generator := NewRenderGenerator(config, nil, outputDir, false)
+	if err := generator.GenerateAppManifests(); err != nil {
+		t.Fatalf("GenerateAppManifests() failed: %v", err)
+	}
+	if err := generator.UpdateIngressManifests(); err != nil {
+		t.Fatalf("UpdateIngressManifests() failed: %v", err)
+	}
+
+	objects := make(map[string]*unstructured.Unstructured)
+	err = filepath.WalkDir(outputDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		decoder := yaml.NewYAMLOrJSONDecoder(file, 4096)
+		for {
+			var object map[string]interface{}
+			if err := decoder.Decode(&object); errors.Is(err, io.EOF) {
+				return nil
+			} else if err != nil {
+				t.Fatalf("failed to decode %s: %v", path, err)
+			}
+			if len(object) == 0 {
+				continue
+			}
+			obj := &unstructured.Unstructured{Object: object}
+			objects[obj.GetKind()+"/"+obj.GetName()] = obj
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return objects
+}
+
+// renderedObject converts the rendered object kind/name into a typed object
+func renderedObject(t *testing.T, objects map[string]*unstructured.Unstructured, key string, into interface{}) {
+	t.Helper()
+	obj, ok := objects[key]
+	if !ok {
+		t.Fatalf("%s not rendered, got %v", key, renderedKeys(objects))
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, into); err != nil {
+		t.Fatalf("failed to convert %s: %v", key, err)
+	}
+}
+
+// renderedKeys returns the kind/name of the rendered objects of the given
+// kinds, sorted, or of all objects without kinds
+func renderedKeys(objects map[string]*unstructured.Unstructured, kinds ...string) []string {
+	var keys []string
+	for key, obj := range objects {
+		if len(kinds) == 0 || contains(kinds, obj.GetKind()) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestGenerateProcesses(t *testing.T) {
+	objects := renderApp(t, `app:
+  name: shop
+  image: ghcr.io/company/shop:v1
+  port: 3000
+resources:
+  cpu: 250m
+  memory: 256Mi
+scaling:
+  min: 1
+  max: 3
+  target_cpu: 70
+domains:
+  - shop.example.com
+processes:
+  web:
+    command: [bin/server, --port, "3000"]
+  worker:
+    command: bin/worker --queue default
+    replicas: 2
+    resources:
+      memory: 1Gi
+  metrics:
+    command: bin/metrics
+    replicas: 1
+    service: true
+    port: 9100
+`)
+
+	wantKeys := []string{
+		"Deployment/shop",
+		"Deployment/shop-metrics",
+		"Deployment/shop-worker",
+		"HorizontalPodAutoscaler/shop-hpa",
+		"Ingress/example.com-ingress",
+		"Service/shop",
+		"Service/shop-metrics",
+		"Service/shop-proxy",
+	}
+	if got := renderedKeys(objects, "Deployment", "HorizontalPodAutoscaler", "Service", "Ingress"); !reflect.DeepEqual(got, wantKeys) {
+		t.Errorf("rendered %v, want %v", got, wantKeys)
+	}
+
+	tests := []struct {
+		name     string
+		process  string
+		command  []string
+		replicas *int32 // nil when left to the autoscaler
+		port     int32
+		memory   string
+	}{
+		{name: "shop", process: "web", command: []string{"bin/server", "--port", "3000"}, port: 3000, memory: "256Mi"},
+		{name: "shop-worker", process: "worker", command: []string{"bin/worker", "--queue", "default"}, replicas: int32Ptr(2), memory: "1Gi"},
+		{name: "shop-metrics", process: "metrics", command: []string{"bin/metrics"}, replicas: int32Ptr(1), port: 9100, memory: "256Mi"},
+	}
+	for _, test := range tests {
+		t.Run(test.process, func(t *testing.T) {
+			var deployment appsv1.Deployment
+			renderedObject(t, objects, "Deployment/"+test.name, &deployment)
+
+			wantLabels := map[string]string{"app": test.name, "shipyard.app": "shop", "shipyard.process": test.process}
+			if got := deployment.Spec.Template.Labels; !reflect.DeepEqual(got, wantLabels) {
+				t.Errorf("pod labels = %v, want %v", got, wantLabels)
+			}
+			if got := deployment.Spec.Selector.MatchLabels; !reflect.DeepEqual(got, map[string]string{"app": test.name}) {
+				t.Errorf("selector = %v, want app: %s", got, test.name)
+			}
+			if got := deployment.Labels["shipyard.process"]; got != test.process {
+				t.Errorf("shipyard.process label = %q, want %q", got, test.process)
+			}
+			if !reflect.DeepEqual(deployment.Spec.Replicas, test.replicas) {
+				t.Errorf("replicas = %v, want %v", deref(deployment.Spec.Replicas), deref(test.replicas))
+			}
+
+			container := deployment.Spec.Template.Spec.Containers[0]
+			if !reflect.DeepEqual(container.Command, test.command) {
+				t.Errorf("command = %q, want %q", container.Command, test.command)
+			}
+			var port int32
+			if len(container.Ports) > 0 {
+				port = container.Ports[0].ContainerPort
+			}
+			if port != test.port {
+				t.Errorf("container port = %d, want %d", port, test.port)
+			}
+			if got := container.Resources.Limits.Memory().String(); got != test.memory {
+				t.Errorf("memory limit = %s, want %s", got, test.memory)
+			}
+			if got := container.Resources.Limits.Cpu().String(); got != "250m" {
+				t.Errorf("cpu limit = %s, want 250m", got)
+			}
+
+			// Only the process receiving ingress is probed on its port
+			if probed := container.ReadinessProbe != nil; probed != (test.process == "web") {
+				t.Errorf("readiness probe set = %v, want %v", probed, test.process == "web")
+			}
+		})
+	}
+
+	// The ingress routes the domains to the service of the web process
+	var proxy corev1.Service
+	renderedObject(t, objects, "Service/shop-proxy", &proxy)
+	if want := "shop.shop.svc.cluster.local"; proxy.Spec.ExternalName != want {
+		t.Errorf("ingress proxy points to %s, want %s", proxy.Spec.ExternalName, want)
+	}
+	var service corev1.Service
+	renderedObject(t, objects, "Service/shop", &service)
+	if got := service.Spec.Selector; !reflect.DeepEqual(got, map[string]string{"app": "shop"}) {
+		t.Errorf("service selector = %v, want app: shop", got)
+	}
+}
+
+func TestGenerateRemovesStaleProcesses(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	outputDir := t.TempDir()
+
+	render := func(content string) []string {
+		config, err := LoadConfig(writeConfig(t, content))
+		if err != nil {
+			t.Fatalf("LoadConfig() failed: %v", err)
+		}
+		if err := NewRenderGenerator(config, nil, outputDir, false).GenerateAppManifests(); err != nil {
+			t.Fatalf("GenerateAppManifests() failed: %v", err)
+		}
+		files, err := filepath.Glob(filepath.Join(outputDir, "apps", "shop", "*-*.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, file := range files {
+			files[i] = filepath.Base(file)
+		}
+		return files
+	}
+
+	base := `app:
+  name: shop
+  image: ghcr.io/company/shop:v1
+  port: 3000
+processes:
+  web: {}
+`
+	got := render(base + `  worker:
+    command: bin/worker
+    service: true
+`)
+	if want := []string{"deployment-worker.yaml", "service-worker.yaml"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("rendered %v, want %v", got, want)
+	}
+
+	if got := render(base); len(got) != 0 {
+		t.Errorf("files of the removed worker process left: %v", got)
+	}
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func deref(i *int32) interface{} {
+	if i == nil {
+		return nil
+	}
+	return *i
+}
@@ -126,6 +126,13 @@ func (g *Generator) generateIngressFileFromDomains(ingressFile, baseDomain strin
 		appPort = g.config.App.Port
 	}
 
+	// The process receiving ingress may listen on its own port
+	if g.config != nil {
+		if processes, err := g.config.GetProcesses(); err == nil && processes[0].Ingress && processes[0].Port > 0 {
+			appPort = processes[0].Port
+		}
+	}
+
 	// Enhance domain data with normalized names
 	enhancedDomains := make([]struct {
 		domains.Domain
@@ -198,6 +205,40 @@ func (g *Generator) UpdateIngressFromDatabase(appName string) error {
 	return g.GenerateIngressFromDatabase()
 }
 
+// renderIngress generates the ingress files of the base domains of the
+// config, as UpdateIngressFromDatabase would once the domains of the config
+// are synced, without changing the database
+func (g *Generator) renderIngress() error {
+	if len(g.config.Domains) == 0 {
+		return nil
+	}
+
+	domainManager, err := domains.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create domain manager: %w", err)
+	}
+	defer domainManager.Close()
+
+	planned, err := domainManager.PlanDomainsFromConfig(g.config.App.Name, g.config.Domains)
+	if err != nil {
+		return err
+	}
+
+	sharedDir := filepath.Join(g.outputDir, "shared")
+	if err := os.MkdirAll(sharedDir, 0755); err != nil {
+		return fmt.Errorf("failed to create shared directory %s: %w", sharedDir, err)
+	}
+
+	for _, baseDomain := range sortedKeys(planned) {
+		ingressFile := filepath.Join(sharedDir, fmt.Sprintf("%s.yaml", baseDomain))
+		if err := g.generateIngressFileFromDomains(ingressFile, baseDomain, planned[baseDomain]); err != nil {
+			return fmt.Errorf("failed to generate ingress for %s: %w", baseDomain, err)
+		}
+		fmt.Printf("🌐 Generated ingress: %s (%d domains)\n", ingressFile, len(planned[baseDomain]))
+	}
+
+	return nil
+}
 
 // CleanupIngressFiles removes ingress files for base domains that no longer have domains
 func (g *Generator) CleanupIngressFiles() error {
@@ -0,0 +1,195 @@
+package manifests
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// onDemandSchedule is the schedule of jobs that only run with shipyard jobs
+// run: their CronJob is suspended, the API still requires a schedule
+const onDemandSchedule = "@yearly"
+
+// defaultReleaseTimeout bounds release commands without a timeout
+const defaultReleaseTimeout = 10 * time.Minute
+
+// maxCronJobNameLength leaves room for the suffix the CronJob controller
+// appends to the names of the Jobs it creates
+const maxCronJobNameLength = 52
+
+// Job is a batch job of the app resolved against the app-level settings
+type Job struct {
+	Name                  string // job name, e.g. cleanup
+	Resource              string // name of its CronJob
+	Schedule              string
+	Suspend               bool // runs on demand only
+	Command               []string
+	ConcurrencyPolicy     string
+	SuccessfulHistory     int
+	FailedHistory         int
+	ActiveDeadlineSeconds int64 // 0 when the job has no timeout
+	Resources             ResourcesConfig
+}
+
+// GetJobs returns the jobs of the app sorted by name
+func (c *Config) GetJobs() ([]Job, error) {
+	names := make([]string, 0, len(c.Jobs))
+	for name := range c.Jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	jobs := make([]Job, 0, len(names))
+	for _, name := range names {
+		if !processNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid job name %q: use lowercase letters, digits and hyphens", name)
+		}
+
+		config := c.Jobs[name]
+		job := Job{
+			Name:              name,
+			Resource:          c.App.GetDNSName() + "-" + name,
+			Schedule:          strings.TrimSpace(config.Schedule),
+			Command:           config.Command,
+			ConcurrencyPolicy: config.ConcurrencyPolicy,
+			SuccessfulHistory: 3,
+			FailedHistory:     1,
+			Resources:         config.Resources,
+		}
+
+		if len(job.Resource) > maxCronJobNameLength {
+			return nil, fmt.Errorf("job %s: name %s is longer than %d characters", name, job.Resource, maxCronJobNameLength)
+		}
+
+		if job.Schedule == "" {
+			job.Schedule = onDemandSchedule
+			job.Suspend = true
+		} else if err := validateSchedule(job.Schedule); err != nil {
+			return nil, fmt.Errorf("job %s: %w", name, err)
+		}
+
+		switch job.ConcurrencyPolicy {
+		case "":
+			job.ConcurrencyPolicy = "Forbid"
+		case "Allow", "Forbid", "Replace":
+		default:
+			return nil, fmt.Errorf("job %s: invalid concurrency_policy %q (use Allow, Forbid or Replace)", name, job.ConcurrencyPolicy)
+		}
+
+		if config.SuccessfulHistory != nil {
+			job.SuccessfulHistory = *config.SuccessfulHistory
+		}
+		if config.FailedHistory != nil {
+			job.FailedHistory = *config.FailedHistory
+		}
+		if job.SuccessfulHistory < 0 || job.FailedHistory < 0 {
+			return nil, fmt.Errorf("job %s: history limits cannot be negative", name)
+		}
+
+		if config.Timeout != "" {
+			timeout, err := time.ParseDuration(config.Timeout)
+			if err != nil || timeout < time.Second {
+				return nil, fmt.Errorf("job %s: invalid timeout %q (e.g. 30m, 2h)", name, config.Timeout)
+			}
+			job.ActiveDeadlineSeconds = int64(timeout.Seconds())
+		}
+
+		if job.Resources.CPU == "" {
+			job.Resources.CPU = c.Resources.CPU
+		}
+		if job.Resources.Memory == "" {
+			job.Resources.Memory = c.Resources.Memory
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// GetJob returns a job of the app by name
+func (c *Config) GetJob(name string) (*Job, error) {
+	jobs, err := c.GetJobs()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, job := range jobs {
+		if job.Name == name {
+			return &job, nil
+		}
+		names = append(names, job.Name)
+	}
+
+	return nil, fmt.Errorf("app %s has no job %s (jobs: %v)", c.App.Name, name, names)
+}
+
+// GetRelease returns the release command of the app as a job, or nil when the
+// app has none
+func (c *Config) GetRelease() (*Job, error) {
+	if c.Release == nil {
+		return nil, nil
+	}
+	if len(c.Release.Command) == 0 {
+		return nil, fmt.Errorf("release: command is required")
+	}
+
+	timeout := defaultReleaseTimeout
+	if c.Release.Timeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(c.Release.Timeout)
+		if err != nil || timeout < time.Second {
+			return nil, fmt.Errorf("release: invalid timeout %q (e.g. 10m, 1h)", c.Release.Timeout)
+		}
+	}
+
+	release := &Job{
+		Name:                  "release",
+		Resource:              c.App.GetDNSName() + "-release",
+		Command:               c.Release.Command,
+		ActiveDeadlineSeconds: int64(timeout.Seconds()),
+		Resources:             c.Release.Resources,
+	}
+	if release.Resources.CPU == "" {
+		release.Resources.CPU = c.Resources.CPU
+	}
+	if release.Resources.Memory == "" {
+		release.Resources.Memory = c.Resources.Memory
+	}
+
+	return release, nil
+}
+
+// Timeout returns how long a run of the job may take, 0 when unlimited
+func (j Job) Timeout() time.Duration {
+	return time.Duration(j.ActiveDeadlineSeconds) * time.Second
+}
+
+// ReleaseJobName returns the name of the Job running the release command of
+// a version
+func ReleaseJobName(config *Config, version *DeploymentVersion) string {
+	name := config.App.GetDNSName() + "-release"
+	if version != nil {
+		name += "-" + strings.ToLower(version.Version)
+	}
+	return name
+}
+
+// validateSchedule checks that a schedule has the five fields of the cron
+// format, or is one of the @ macros understood by Kubernetes
+func validateSchedule(schedule string) error {
+	if strings.HasPrefix(schedule, "@") {
+		switch schedule {
+		case "@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight", "@hourly":
+			return nil
+		}
+		return fmt.Errorf("unknown schedule %q", schedule)
+	}
+
+	if fields := strings.Fields(schedule); len(fields) != 5 {
+		return fmt.Errorf("invalid schedule %q: expected 5 fields (minute hour day month weekday)", schedule)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package manifests
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// DefaultProcess is the process of apps without a processes section
+const DefaultProcess = "web"
+
+// processNamePattern matches valid process names (DNS-1123 labels)
+var processNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Process is a process type of the app resolved against the app-level settings
+type Process struct {
+	Name      string // process name, e.g. worker
+	Resource  string // name of its Deployment, Service and HPA
+	Command   []string
+	Port      int // 0 when the process has no Service
+	Resources ResourcesConfig
+	Scaling   ScalingConfig
+	Service   bool
+	Ingress   bool // receives the traffic of the app's domains
+	Volumes   []Volume
+	Recreate  bool // stops the old pods before starting new ones
+}
+
+// Autoscaled reports whether the process gets a HorizontalPodAutoscaler
+func (p Process) Autoscaled() bool {
+	return p.Scaling.Max > p.Scaling.Min
+}
+
+// GetProcesses returns the processes of the app, the one receiving ingress
+// first and the others by name. Without a processes section the app runs a
+// single web process.
+//
+// The process receiving ingress is the one with ingress: true, or else the
+// web process. Its Deployment and Service are named after the app so that
+// domains, monitoring and logs keep working; the others are named
+// <app>-<process>.
+func (c *Config) GetProcesses() ([]Process, error) {
+	if len(c.Processes) == 0 {
+		processes := []Process{{
+			Name:      DefaultProcess,
+			Resource:  c.App.GetDNSName(),
+			Port:      c.App.Port,
+			Resources: c.Resources,
+			Scaling:   c.Scaling,
+			Service:   true,
+			Ingress:   true,
+		}}
+		if err := c.attachVolumes(processes); err != nil {
+			return nil, err
+		}
+		return processes, nil
+	}
+
+	ingress := ""
+	for name, process := range c.Processes {
+		if process.Ingress != nil && *process.Ingress {
+			if ingress != "" {
+				return nil, fmt.Errorf("processes %s and %s both set ingress: only one process can receive the app's traffic", ingress, name)
+			}
+			ingress = name
+		}
+	}
+	if web, ok := c.Processes[DefaultProcess]; ok && ingress == "" && (web.Ingress == nil || *web.Ingress) {
+		ingress = DefaultProcess
+	}
+
+	names := make([]string, 0, len(c.Processes))
+	for name := range c.Processes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == ingress) != (names[j] == ingress) {
+			return names[i] == ingress
+		}
+		return names[i] < names[j]
+	})
+
+	processes := make([]Process, 0, len(names))
+	for _, name := range names {
+		if !processNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid process name %q: use lowercase letters, digits and hyphens", name)
+		}
+
+		config := c.Processes[name]
+		process := Process{
+			Name:      name,
+			Resource:  c.App.GetDNSName() + "-" + name,
+			Command:   config.Command,
+			Resources: config.Resources,
+			Scaling:   c.Scaling,
+			Ingress:   name == ingress,
+		}
+		if process.Ingress {
+			process.Resource = c.App.GetDNSName()
+		}
+
+		process.Service = process.Ingress
+		if config.Service != nil {
+			process.Service = *config.Service
+		}
+		if process.Ingress && !process.Service {
+			return nil, fmt.Errorf("process %s receives ingress and needs a service", name)
+		}
+		if process.Service {
+			process.Port = config.Port
+			if process.Port == 0 {
+				process.Port = c.App.Port
+			}
+		}
+
+		if process.Resources.CPU == "" {
+			process.Resources.CPU = c.Resources.CPU
+		}
+		if process.Resources.Memory == "" {
+			process.Resources.Memory = c.Resources.Memory
+		}
+
+		switch {
+		case config.Scaling != nil:
+			if config.Scaling.Min != 0 {
+				process.Scaling.Min = config.Scaling.Min
+			}
+			if config.Scaling.Max != 0 {
+				process.Scaling.Max = config.Scaling.Max
+			}
+			if config.Scaling.TargetCPU != 0 {
+				process.Scaling.TargetCPU = config.Scaling.TargetCPU
+			}
+		case config.Replicas > 0:
+			process.Scaling.Min = config.Replicas
+			process.Scaling.Max = config.Replicas
+		}
+		if process.Scaling.Max < process.Scaling.Min {
+			return nil, fmt.Errorf("process %s: scaling.max (%d) is lower than scaling.min (%d)", name, process.Scaling.Max, process.Scaling.Min)
+		}
+
+		processes = append(processes, process)
+	}
+
+	if err := c.attachVolumes(processes); err != nil {
+		return nil, err
+	}
+
+	return processes, nil
+}
+
+// GetProcess returns a process of the app by name
+func (c *Config) GetProcess(name string) (*Process, error) {
+	processes, err := c.GetProcesses()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, process := range processes {
+		if process.Name == name {
+			return &process, nil
+		}
+		names = append(names, process.Name)
+	}
+
+	return nil, fmt.Errorf("app %s has no process %s (processes: %v)", c.App.Name, name, names)
+}
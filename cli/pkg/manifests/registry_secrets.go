@@ -1,7 +1,6 @@
 package manifests
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -41,10 +40,28 @@ func (g *Generator) GenerateRegistrySecrets(appDir string) ([]string, error) {
 
 	var selectedRegistries []*registry.Registry
 	
-	// Always use interactive selection
-	selectedRegistries, err = manager.SelectRegistriesInteractive(g.config.App.Image)
+	// Use interactive selection, or the registry of the image on a dry run
+	if g.dryRun {
+		selectedRegistries, err = manager.SelectRegistriesAuto(g.config.App.Image)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select registries: %w", err)
+		}
+	} else {
+		selectedRegistries, err = manager.SelectRegistriesInteractive(g.config.App.Image)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select registries interactively: %w", err)
+		}
+	}
+
+	// Remove the secrets of the registries selected by an earlier deploy
+	staleFiles, err := filepath.Glob(filepath.Join(appDir, "registry-secret*.yaml"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to select registries interactively: %w", err)
+		return nil, fmt.Errorf("failed to list registry secrets: %w", err)
+	}
+	for _, file := range staleFiles {
+		if err := os.Remove(file); err != nil {
+			return nil, fmt.Errorf("failed to remove %s: %w", file, err)
+		}
 	}
 
 	if len(selectedRegistries) == 0 {
@@ -88,7 +105,7 @@ func (g *Generator) generateSingleRegistrySecret(appDir string, selectedRegistry
 	}
 
 	// Base64 encode for Kubernetes secret
-	dockerConfigB64 := base64.StdEncoding.EncodeToString(dockerConfigBytes)
+	dockerConfigB64 := g.secretData(dockerConfigBytes)
 
 	// Generate unique secret name
 	var secretName string
@@ -0,0 +1,173 @@
+package manifests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// ReleaseDir is the subdirectory of the app manifests holding the release
+// Job. It is applied by the deploy before the other manifests, never with them.
+const ReleaseDir = "release"
+
+// The release Job reads its secrets and files from its own Secret and
+// ConfigMap, so that a failing release leaves those of the running version
+// untouched
+const releaseTemplate = `{{- if .SecretsBase64 }}
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ .Job.Resource }}-secrets
+  namespace: {{ .App.GetNamespace }}
+  labels:
+    app: {{ .Job.Resource }}
+    managed-by: shipyard
+    shipyard.app: {{ .App.GetDNSName }}
+type: Opaque
+data:
+{{- range $key, $value := .SecretsBase64 }}
+  {{ $key }}: {{ $value }}
+{{- end }}
+---
+{{- end }}
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{ .RunName }}
+  namespace: {{ .App.GetNamespace }}
+  labels:
+    app: {{ .Job.Resource }}
+    managed-by: shipyard
+    shipyard.app: {{ .App.GetDNSName }}
+    shipyard.job: {{ .Job.Name }}
+    {{- if .Version }}
+    shipyard.version: "{{ .Version.Version }}"
+    shipyard.image-tag: "{{ .Version.ImageTag }}"
+    {{- end }}
+spec:
+  backoffLimit: 0
+  activeDeadlineSeconds: {{ .Job.ActiveDeadlineSeconds }}
+  ttlSecondsAfterFinished: 86400
+  template:
+    metadata:
+      labels:
+        app: {{ .Job.Resource }}
+        shipyard.app: {{ .App.GetDNSName }}
+        shipyard.job: {{ .Job.Name }}
+    spec:
+      restartPolicy: Never
+      {{- if .ImagePullSecrets }}
+      imagePullSecrets:
+      {{- range .ImagePullSecrets }}
+      - name: {{ . }}
+      {{- end }}
+      {{- end }}
+      {{- if .ConfigFiles }}
+      volumes:
+      - name: shipyard-files
+        configMap:
+          name: {{ .FilesConfigMap }}
+      {{- end }}
+      containers:
+      - name: {{ .Job.Resource }}
+        image: {{ .App.Image }}
+        command:
+        {{- range .Job.Command }}
+        - {{ printf "%q" . }}
+        {{- end }}
+        env:
+        {{- range $key, $value := .Env }}
+        - name: {{ $key }}
+          value: "{{ $value }}"
+        {{- end }}
+        {{- if .SecretsBase64 }}
+        envFrom:
+        - secretRef:
+            name: {{ .Job.Resource }}-secrets
+        {{- end }}
+        {{- if .ConfigFiles }}
+        volumeMounts:
+        {{- range .ConfigFiles }}
+        - name: shipyard-files
+          mountPath: {{ .MountPath }}
+          subPath: {{ .Key }}
+          readOnly: true
+        {{- end }}
+        {{- end }}
+        resources:
+          requests:
+            cpu: {{ .Job.Resources.CPU }}
+            memory: {{ .Job.Resources.Memory }}
+          limits:
+            cpu: {{ .Job.Resources.CPU }}
+            memory: {{ .Job.Resources.Memory }}
+`
+
+// generateRelease creates release/job.yaml for apps with a release command,
+// and removes it from apps without one
+func (g *Generator) generateRelease(appDir string) error {
+	releaseDir := filepath.Join(appDir, ReleaseDir)
+
+	release, err := g.config.GetRelease()
+	if err != nil {
+		return err
+	}
+	if release == nil {
+		return os.RemoveAll(releaseDir)
+	}
+
+	if err := os.MkdirAll(releaseDir, 0755); err != nil {
+		return fmt.Errorf("failed to create release directory %s: %w", releaseDir, err)
+	}
+
+	tmpl, err := template.New("release").Parse(releaseTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse release template: %w", err)
+	}
+
+	filePath := filepath.Join(releaseDir, "job.yaml")
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create release file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	if len(g.configFiles) > 0 {
+		if err := g.renderConfigMap(file, release.Resource+"-files"); err != nil {
+			return err
+		}
+		fmt.Fprintln(file, "---")
+	}
+
+	secretsBase64 := make(map[string]string)
+	for key, value := range g.config.Secrets {
+		secretsBase64[key] = g.secretData([]byte(value))
+	}
+
+	templateData := struct {
+		*Config
+		Job              *Job
+		RunName          string
+		SecretsBase64    map[string]string
+		Version          *DeploymentVersion
+		ImagePullSecrets []string
+		ConfigFiles      []ConfigFile
+		FilesConfigMap   string
+	}{
+		Config:           g.config,
+		Job:              release,
+		RunName:          ReleaseJobName(g.config, g.version),
+		SecretsBase64:    secretsBase64,
+		Version:          g.version,
+		ImagePullSecrets: g.imagePullSecrets,
+		ConfigFiles:      g.configFiles,
+		FilesConfigMap:   release.Resource + "-files",
+	}
+
+	if err := tmpl.Execute(file, templateData); err != nil {
+		return fmt.Errorf("failed to execute release template: %w", err)
+	}
+
+	return nil
+}
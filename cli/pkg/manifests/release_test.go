@@ -0,0 +1,143 @@
+package manifests
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGenerateRelease(t *testing.T) {
+	base := `app:
+  name: shop
+  image: ghcr.io/company/shop:v1
+  port: 3000
+resources:
+  cpu: 250m
+  memory: 256Mi
+env:
+  LOG_LEVEL: info
+secrets:
+  DATABASE_URL: postgres://shop
+`
+
+	tests := []struct {
+		name     string
+		release  string
+		command  []string
+		deadline int64
+		memory   string
+	}{
+		{
+			name:     "shorthand",
+			release:  "release: bin/rails db:migrate\n",
+			command:  []string{"bin/rails", "db:migrate"},
+			deadline: 600,
+			memory:   "256Mi",
+		},
+		{
+			name: "timeout and resources",
+			release: `release:
+  command: [bin/migrate, --verbose]
+  timeout: 20m
+  resources:
+    memory: 1Gi
+`,
+			command:  []string{"bin/migrate", "--verbose"},
+			deadline: 1200,
+			memory:   "1Gi",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objects := renderApp(t, base+test.release)
+
+			var job batchv1.Job
+			renderedObject(t, objects, "Job/shop-release", &job)
+
+			if job.Namespace != "shop" {
+				t.Errorf("namespace = %s, want shop", job.Namespace)
+			}
+			if job.Spec.BackoffLimit == nil || *job.Spec.BackoffLimit != 0 {
+				t.Errorf("backoffLimit = %v, want 0: a failed release must not be retried", job.Spec.BackoffLimit)
+			}
+			if job.Spec.ActiveDeadlineSeconds == nil || *job.Spec.ActiveDeadlineSeconds != test.deadline {
+				t.Errorf("activeDeadlineSeconds = %v, want %d", job.Spec.ActiveDeadlineSeconds, test.deadline)
+			}
+
+			pod := job.Spec.Template
+			wantLabels := map[string]string{"app": "shop-release", "shipyard.app": "shop", "shipyard.job": "release"}
+			if !reflect.DeepEqual(pod.Labels, wantLabels) {
+				t.Errorf("pod labels = %v, want %v", pod.Labels, wantLabels)
+			}
+
+			container := pod.Spec.Containers[0]
+			if container.Image != "ghcr.io/company/shop:v1" {
+				t.Errorf("image = %s, want the app image", container.Image)
+			}
+			if !reflect.DeepEqual(container.Command, test.command) {
+				t.Errorf("command = %q, want %q", container.Command, test.command)
+			}
+			if got := container.Resources.Limits.Memory().String(); got != test.memory {
+				t.Errorf("memory limit = %s, want %s", got, test.memory)
+			}
+
+			// The release reads the new secrets from a Secret of its own
+			if len(container.EnvFrom) != 1 || container.EnvFrom[0].SecretRef.Name != "shop-release-secrets" {
+				t.Errorf("envFrom = %v, want the shop-release-secrets secret", container.EnvFrom)
+			}
+			var secret corev1.Secret
+			renderedObject(t, objects, "Secret/shop-release-secrets", &secret)
+			if got := string(secret.Data["DATABASE_URL"]); got != "postgres://shop" {
+				t.Errorf("DATABASE_URL = %q, want postgres://shop", got)
+			}
+		})
+	}
+}
+
+func TestGenerateReleaseRemoved(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	outputDir := t.TempDir()
+	releaseFile := filepath.Join(outputDir, "apps", "shop", ReleaseDir, "job.yaml")
+
+	base := `app:
+  name: shop
+  image: ghcr.io/company/shop:v1
+  port: 3000
+`
+	for _, test := range []struct {
+		content string
+		want    bool
+	}{
+		{content: base + "release: bin/migrate\n", want: true},
+		{content: base, want: false},
+	} {
+		config, err := LoadConfig(writeConfig(t, test.content))
+		if err != nil {
+			t.Fatalf("LoadConfig() failed: %v", err)
+		}
+		if err := NewRenderGenerator(config, nil, outputDir, false).GenerateAppManifests(); err != nil {
+			t.Fatalf("GenerateAppManifests() failed: %v", err)
+		}
+		_, err = os.Stat(releaseFile)
+		if exists := err == nil; exists != test.want {
+			t.Errorf("release job rendered = %v, want %v", exists, test.want)
+		}
+	}
+}
+
+func TestReleaseJobName(t *testing.T) {
+	config := &Config{App: AppConfig{Name: "Shop_App"}}
+
+	if got, want := ReleaseJobName(config, nil), "shop-app-release"; got != want {
+		t.Errorf("ReleaseJobName(nil) = %s, want %s", got, want)
+	}
+	version := &DeploymentVersion{Version: "V1703123456"}
+	if got, want := ReleaseJobName(config, version), "shop-app-release-v1703123456"; got != want {
+		t.Errorf("ReleaseJobName(%s) = %s, want %s", version.Version, got, want)
+	}
+}
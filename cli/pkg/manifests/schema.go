@@ -0,0 +1,121 @@
+package manifests
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// schemaConstraints are the constraints of fields beyond their type, by
+// struct and field name
+var schemaConstraints = map[string]map[string]interface{}{
+	"AppConfig.Port":               portSchema(),
+	"ProbeConfig.Port":             portSchema(),
+	"ProcessConfig.Port":           portSchema(),
+	"ServiceConfig.ExternalPort":   portSchema(),
+	"MonitoringConfig.MetricsPort": portSchema(),
+	"ProbeConfig.Path":             {"pattern": "^/"},
+	"ScalingConfig.Min":            {"minimum": 0},
+	"ScalingConfig.TargetCPU":      {"minimum": 1, "maximum": 100},
+	"ProcessConfig.Replicas":       {"minimum": 0},
+	"ServiceConfig.Type":           {"enum": serviceTypes},
+	"VolumeConfig.AccessMode":      {"enum": []string{"ReadWriteOnce", "ReadWriteOncePod", "ReadWriteMany", "ReadOnlyMany"}},
+	"JobConfig.ConcurrencyPolicy":  {"enum": []string{"Allow", "Forbid", "Replace"}},
+}
+
+// schemaRequired are the required fields of the base config, by struct.
+// Environment overlays have no required fields.
+var schemaRequired = map[string][]string{
+	"Config":       {"app"},
+	"AppConfig":    {"name", "image"},
+	"VolumeConfig": {"name", "mount_path", "size"},
+}
+
+// ConfigSchema returns the JSON Schema of paas.yaml, for editors to complete
+// and check config files. Environment overlays are described by the overlay
+// definition.
+func ConfigSchema() ([]byte, error) {
+	schema := schemaForType(configType, true)
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "Shipyard paas.yaml"
+	schema["definitions"] = map[string]interface{}{
+		"overlay": schemaForType(configType, false),
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// schemaForType describes a type decoded by yaml.v2 from paas.yaml
+func schemaForType(t reflect.Type, required bool) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	stringList := map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}
+	switch t {
+	case commandType:
+		return map[string]interface{}{"oneOf": []interface{}{map[string]interface{}{"type": "string"}, stringList}}
+	case releaseConfigType:
+		return map[string]interface{}{"oneOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			stringList,
+			schemaForStruct(t, required),
+		}}
+	case environmentsType:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": map[string]interface{}{"$ref": "#/definitions/overlay"},
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return schemaForStruct(t, required)
+	case reflect.Map:
+		values := schemaForType(t.Elem(), required)
+		if t.Elem().Kind() == reflect.String {
+			// Values such as env vars may be written as numbers or booleans,
+			// and overlays remove them with null
+			types := []string{"string", "number", "boolean"}
+			if !required {
+				types = append(types, "null")
+			}
+			values = map[string]interface{}{"type": types}
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": schemaForType(t.Elem(), required)}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	}
+	return map[string]interface{}{}
+}
+
+func schemaForStruct(t reflect.Type, required bool) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for name, field := range yamlFields(t) {
+		property := schemaForType(field.Type, required)
+		for key, value := range schemaConstraints[t.Name()+"."+field.Name] {
+			property[key] = value
+		}
+		properties[name] = property
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if fields := schemaRequired[t.Name()]; required && len(fields) > 0 {
+		schema["required"] = fields
+	}
+	return schema
+}
+
+func portSchema() map[string]interface{} {
+	return map[string]interface{}{"minimum": 1, "maximum": 65535}
+}
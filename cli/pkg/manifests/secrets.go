@@ -27,17 +27,21 @@ data:
 
 // generateSecrets creates the secrets.yaml file with base64 encoded values
 func (g *Generator) generateSecrets(appDir string) error {
-	// Skip if no secrets defined
+	filePath := filepath.Join(appDir, "secrets.yaml")
+
+	// Skip if no secrets defined, removing those of an earlier deploy
 	if len(g.config.Secrets) == 0 {
 		fmt.Printf("ℹ️  No secrets defined for %s, skipping secrets.yaml\n", g.config.App.Name)
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", filePath, err)
+		}
 		return nil
 	}
 
 	// Convert secrets to base64
 	secretsBase64 := make(map[string]string)
 	for key, value := range g.config.Secrets {
-		encoded := base64.StdEncoding.EncodeToString([]byte(value))
-		secretsBase64[key] = encoded
+		secretsBase64[key] = g.secretData([]byte(value))
 	}
 
 	// Create template data with base64 encoded secrets
@@ -54,7 +58,6 @@ func (g *Generator) generateSecrets(appDir string) error {
 		return fmt.Errorf("failed to parse secrets template: %w", err)
 	}
 
-	filePath := filepath.Join(appDir, "secrets.yaml")
 	file, err := os.Create(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to create secrets file %s: %w", filePath, err)
@@ -66,4 +69,13 @@ func (g *Generator) generateSecrets(appDir string) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// secretData returns a value base64 encoded for the data of a Secret, or
+// redacted when rendering for display
+func (g *Generator) secretData(value []byte) string {
+	if g.redactSecrets {
+		return redactedValue
+	}
+	return base64.StdEncoding.EncodeToString(value)
+}
@@ -10,40 +10,51 @@ import (
 const serviceTemplate = `apiVersion: v1
 kind: Service
 metadata:
-  name: {{ .App.GetDNSName }}
+  name: {{ .Process.Resource }}
   namespace: {{ .App.GetNamespace }}
   labels:
-    app: {{ .App.GetDNSName }}
+    app: {{ .Process.Resource }}
     managed-by: shipyard
+    shipyard.app: {{ .App.GetDNSName }}
+    shipyard.process: {{ .Process.Name }}
 spec:
-  type: {{ if .Service.Type }}{{ .Service.Type }}{{ else }}ClusterIP{{ end }}
+  type: {{ if and .Process.Ingress .Service.Type }}{{ .Service.Type }}{{ else }}ClusterIP{{ end }}
   ports:
-  - port: {{ .App.Port }}
-    targetPort: {{ .App.Port }}
+  - port: {{ .Process.Port }}
+    targetPort: {{ .Process.Port }}
     protocol: TCP
     name: http
-    {{- if and .Service.ExternalPort (eq .Service.Type "NodePort") }}
+    {{- if and .Process.Ingress .Service.ExternalPort (eq .Service.Type "NodePort") }}
     nodePort: {{ .Service.ExternalPort }}
     {{- end }}
   selector:
-    app: {{ .App.GetDNSName }}
+    app: {{ .Process.Resource }}
 `
 
-// generateService creates the service.yaml file for an application
-func (g *Generator) generateService(appDir string) error {
+// generateService creates the service file of a process: service.yaml for the
+// process receiving ingress, service-<process>.yaml for the others
+func (g *Generator) generateService(appDir string, process Process) error {
 	tmpl, err := template.New("service").Parse(serviceTemplate)
 	if err != nil {
 		return fmt.Errorf("failed to parse service template: %w", err)
 	}
 
-	filePath := filepath.Join(appDir, "service.yaml")
+	filePath := filepath.Join(appDir, processFileName("service", process))
 	file, err := os.Create(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to create service file %s: %w", filePath, err)
 	}
 	defer file.Close()
 
-	if err := tmpl.Execute(file, g.config); err != nil {
+	templateData := struct {
+		*Config
+		Process Process
+	}{
+		Config:  g.config,
+		Process: process,
+	}
+
+	if err := tmpl.Execute(file, templateData); err != nil {
 		return fmt.Errorf("failed to execute service template: %w", err)
 	}
 
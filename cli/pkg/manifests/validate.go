@@ -0,0 +1,543 @@
+package manifests
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Problem is an error or a warning found in a config file
+type Problem struct {
+	File    string
+	Line    int // 0 when the problem cannot be located
+	Column  int
+	Path    string // e.g. scaling.max or processes.web.port
+	Message string
+	Warning bool
+}
+
+// String formats a problem as paas.yaml:12:5: scaling.max: message
+func (p Problem) String() string {
+	var b strings.Builder
+	if p.File != "" {
+		b.WriteString(p.File)
+		if p.Line > 0 {
+			fmt.Fprintf(&b, ":%d:%d", p.Line, p.Column)
+		}
+		b.WriteString(": ")
+	}
+	if p.Path != "" {
+		b.WriteString(p.Path + ": ")
+	}
+	b.WriteString(p.Message)
+	return b.String()
+}
+
+// ValidationError lists the errors found in a config file
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		lines[i] = problem.String()
+	}
+	if len(lines) == 1 {
+		return lines[0]
+	}
+	return fmt.Sprintf("%d errors:\n  %s", len(lines), strings.Join(lines, "\n  "))
+}
+
+// ValidationErrors returns the errors among problems as a *ValidationError,
+// or nil when there are only warnings
+func ValidationErrors(problems []Problem) error {
+	var errs []Problem
+	for _, problem := range problems {
+		if !problem.Warning {
+			errs = append(errs, problem)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: errs}
+}
+
+// AsValidationError returns the problems of err when it is a *ValidationError
+func AsValidationError(err error) ([]Problem, bool) {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Problems, true
+	}
+	return nil, false
+}
+
+// position locates a key in a config file
+type position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+var (
+	configType        = reflect.TypeOf(Config{})
+	commandType       = reflect.TypeOf(Command{})
+	releaseConfigType = reflect.TypeOf(ReleaseConfig{})
+	environmentsType  = reflect.TypeOf(map[string]map[string]interface{}{})
+)
+
+// documentChecker walks a config document parsed by yaml.v3, which keeps the
+// line and column of every node, along the Config type
+type documentChecker struct {
+	file      string
+	positions map[string]position
+	problems  []Problem
+}
+
+// checkConfigDocument reports the unknown fields and the values of the wrong
+// type of a config document with their line and column, which yaml.v2
+// ignores or reports without position. It also returns the position of every
+// key and list item by path.
+func checkConfigDocument(filename string, data []byte) (map[string]position, []Problem) {
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &root); err != nil {
+		return nil, []Problem{{File: filename, Message: err.Error()}}
+	}
+
+	checker := &documentChecker{file: filename, positions: make(map[string]position)}
+	if len(root.Content) > 0 {
+		checker.check(root.Content[0], configType, "")
+	}
+	return checker.positions, checker.problems
+}
+
+func (c *documentChecker) check(node *yamlv3.Node, t reflect.Type, path string) {
+	if node.Kind == yamlv3.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if node.Kind == yamlv3.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// Types decoding shorthands, see their UnmarshalYAML
+	switch t {
+	case commandType:
+		c.checkCommand(node, path)
+		return
+	case releaseConfigType:
+		if node.Kind != yamlv3.MappingNode {
+			c.checkCommand(node, path)
+			return
+		}
+	case environmentsType:
+		c.checkMap(node, configType, path)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yamlv3.MappingNode {
+			c.typeProblem(node, path, "a mapping")
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			keyPath := joinPath(path, key.Value)
+			c.positions[keyPath] = position{c.file, key.Line, key.Column}
+
+			field, ok := fields[key.Value]
+			if !ok {
+				message := fmt.Sprintf("unknown field %s", key.Value)
+				if suggestion := suggestField(key.Value, fields); suggestion != "" {
+					message += fmt.Sprintf(", did you mean %s?", suggestion)
+				}
+				c.problems = append(c.problems, Problem{File: c.file, Line: key.Line, Column: key.Column, Path: path, Message: message})
+				continue
+			}
+			c.check(value, field.Type, keyPath)
+		}
+	case reflect.Map:
+		c.checkMap(node, t.Elem(), path)
+	case reflect.Slice:
+		if node.Kind != yamlv3.SequenceNode {
+			c.typeProblem(node, path, "a list")
+			return
+		}
+		for i, item := range node.Content {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			c.positions[itemPath] = position{c.file, item.Line, item.Column}
+			c.check(item, t.Elem(), itemPath)
+		}
+	case reflect.String:
+		if node.Kind != yamlv3.ScalarNode {
+			c.typeProblem(node, path, "a string")
+		}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		if node.Kind != yamlv3.ScalarNode || node.Tag != "!!int" {
+			c.typeProblem(node, path, "an integer")
+		}
+	case reflect.Float32, reflect.Float64:
+		if node.Kind != yamlv3.ScalarNode || (node.Tag != "!!int" && node.Tag != "!!float") {
+			c.typeProblem(node, path, "a number")
+		}
+	case reflect.Bool:
+		if node.Kind != yamlv3.ScalarNode || !isYAMLBool(node) {
+			c.typeProblem(node, path, "true or false")
+		}
+	}
+}
+
+func (c *documentChecker) checkMap(node *yamlv3.Node, elem reflect.Type, path string) {
+	if node.Kind != yamlv3.MappingNode {
+		c.typeProblem(node, path, "a mapping")
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		keyPath := joinPath(path, key.Value)
+		c.positions[keyPath] = position{c.file, key.Line, key.Column}
+		c.check(value, elem, keyPath)
+	}
+}
+
+// checkCommand accepts a string or a list of strings
+func (c *documentChecker) checkCommand(node *yamlv3.Node, path string) {
+	switch node.Kind {
+	case yamlv3.ScalarNode:
+		return
+	case yamlv3.SequenceNode:
+		for _, item := range node.Content {
+			if item.Kind != yamlv3.ScalarNode {
+				c.typeProblem(item, path, "a string")
+			}
+		}
+	default:
+		c.typeProblem(node, path, "a string or a list of strings")
+	}
+}
+
+func (c *documentChecker) typeProblem(node *yamlv3.Node, path, expected string) {
+	c.problems = append(c.problems, Problem{
+		File:    c.file,
+		Line:    node.Line,
+		Column:  node.Column,
+		Path:    path,
+		Message: fmt.Sprintf("must be %s, not %s", expected, describeNode(node)),
+	})
+}
+
+// isYAMLBool accepts the booleans of YAML 1.1, as decoded by yaml.v2
+func isYAMLBool(node *yamlv3.Node) bool {
+	if node.Tag == "!!bool" {
+		return true
+	}
+	switch strings.ToLower(node.Value) {
+	case "yes", "no", "on", "off", "y", "n":
+		return true
+	}
+	return false
+}
+
+func describeNode(node *yamlv3.Node) string {
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		return "a mapping"
+	case yamlv3.SequenceNode:
+		return "a list"
+	default:
+		return fmt.Sprintf("%q", node.Value)
+	}
+}
+
+// yamlFields returns the fields of a struct by YAML key, as decoded by yaml.v2
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// suggestField returns the known field closest to an unknown one, if any is
+// close enough: target-cpu for target_cpu, or a typo of one or two letters
+func suggestField(name string, fields map[string]reflect.StructField) string {
+	normalize := func(s string) string {
+		return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(s))
+	}
+
+	known := make([]string, 0, len(fields))
+	for field := range fields {
+		known = append(known, field)
+	}
+	sort.Strings(known)
+
+	best, bestDistance := "", 3
+	for _, field := range known {
+		if normalize(field) == normalize(name) {
+			return field
+		}
+		if distance := editDistance(name, field); distance < bestDistance {
+			best, bestDistance = field, distance
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// serviceTypes are the service types accepted in service.type
+var serviceTypes = []string{"ClusterIP", "NodePort", "LoadBalancer"}
+
+// configValidator collects the problems found by Validate
+type configValidator struct {
+	config   *Config
+	problems []Problem
+}
+
+// Validate checks the values of the config: names, resource quantities,
+// ports, probe paths, scaling bounds, env var names and domains, then the
+// processes, jobs, volumes, release and files. Names that are not DNS-1035
+// compliant are warnings, since deploys normalize them.
+func (c *Config) Validate() []Problem {
+	v := &configValidator{config: c}
+
+	v.checkApp()
+	v.checkQuantities("resources", c.Resources)
+	v.checkScaling("scaling", c.Scaling)
+	v.checkService()
+	v.checkProbe("health.liveness", c.Health.Liveness)
+	v.checkProbe("health.readiness", c.Health.Readiness)
+	v.checkEnvNames("env", c.Env)
+	v.checkEnvNames("secrets", c.Secrets)
+	v.checkDomains()
+
+	for _, name := range sortedKeys(c.Processes) {
+		process := c.Processes[name]
+		path := "processes." + name
+		v.checkPort(path+".port", process.Port)
+		v.checkQuantities(path+".resources", process.Resources)
+		if process.Replicas < 0 {
+			v.errorf(path+".replicas", "cannot be negative")
+		}
+		if process.Scaling != nil {
+			v.checkScaling(path+".scaling", *process.Scaling)
+		}
+	}
+	for _, name := range sortedKeys(c.Jobs) {
+		v.checkQuantities("jobs."+name+".resources", c.Jobs[name].Resources)
+	}
+	if c.Release != nil {
+		v.checkQuantities("release.resources", c.Release.Resources)
+	}
+
+	// Checks of the resolved settings, shared with the manifests generation
+	if _, err := c.GetProcesses(); err != nil {
+		v.errorf("processes", "%v", err)
+	}
+	if _, err := c.GetJobs(); err != nil {
+		v.errorf("jobs", "%v", err)
+	}
+	if _, err := c.GetRelease(); err != nil {
+		v.errorf("release", "%v", err)
+	}
+	if _, err := c.GetVolumes(); err != nil {
+		v.errorf("volumes", "%v", err)
+	}
+	if _, err := c.GetConfigFiles(); err != nil {
+		v.errorf("files", "%v", err)
+	}
+
+	return v.problems
+}
+
+func (v *configValidator) checkApp() {
+	app := v.config.App
+
+	if app.Name == "" {
+		v.errorf("app.name", "is required")
+	} else if dnsName := app.GetDNSName(); dnsName != app.Name {
+		v.warnf("app.name", "%q is not a valid DNS-1035 name, resources will be named %s", app.Name, dnsName)
+	} else if errs := validation.IsDNS1035Label(dnsName); len(errs) > 0 {
+		v.errorf("app.name", "%s", strings.Join(errs, ", "))
+	}
+
+	if app.Image == "" {
+		v.errorf("app.image", "is required")
+	}
+
+	if app.Namespace != "" {
+		namespace := app.GetNamespace()
+		if namespace != app.Namespace {
+			v.warnf("app.namespace", "%q is not a valid DNS-1035 name, the namespace will be %s", app.Namespace, namespace)
+		} else if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			v.errorf("app.namespace", "%s", strings.Join(errs, ", "))
+		}
+	}
+
+	v.checkPort("app.port", app.Port)
+}
+
+func (v *configValidator) checkService() {
+	service := v.config.Service
+	if service.Type != "" && !contains(serviceTypes, service.Type) {
+		v.errorf("service.type", "invalid type %q (use %s)", service.Type, strings.Join(serviceTypes, ", "))
+	}
+	v.checkPort("service.externalPort", service.ExternalPort)
+}
+
+func (v *configValidator) checkProbe(path string, probe ProbeConfig) {
+	if probe.Path != "" && !strings.HasPrefix(probe.Path, "/") {
+		v.errorf(path+".path", "%q must start with /", probe.Path)
+	}
+	v.checkPort(path+".port", probe.Port)
+	if probe.InitialDelaySeconds < 0 {
+		v.errorf(path+".initialDelaySeconds", "cannot be negative")
+	}
+	if probe.PeriodSeconds < 0 {
+		v.errorf(path+".periodSeconds", "cannot be negative")
+	}
+}
+
+func (v *configValidator) checkPort(path string, port int) {
+	if port != 0 && (port < 1 || port > 65535) {
+		v.errorf(path, "invalid port %d (use 1 to 65535)", port)
+	}
+}
+
+func (v *configValidator) checkQuantities(path string, resources ResourcesConfig) {
+	if resources.CPU != "" {
+		if _, err := resource.ParseQuantity(resources.CPU); err != nil {
+			v.errorf(path+".cpu", "invalid quantity %q (e.g. 250m or 1)", resources.CPU)
+		}
+	}
+	if resources.Memory != "" {
+		if _, err := resource.ParseQuantity(resources.Memory); err != nil {
+			v.errorf(path+".memory", "invalid quantity %q (e.g. 256Mi or 1Gi)", resources.Memory)
+		}
+	}
+}
+
+func (v *configValidator) checkScaling(path string, scaling ScalingConfig) {
+	if scaling.Min < 0 {
+		v.errorf(path+".min", "cannot be negative")
+	}
+	if scaling.Max != 0 && scaling.Max < scaling.Min {
+		v.errorf(path+".max", "%d is lower than %s.min (%d)", scaling.Max, path, scaling.Min)
+	}
+	if scaling.TargetCPU != 0 && (scaling.TargetCPU < 1 || scaling.TargetCPU > 100) {
+		v.errorf(path+".target_cpu", "invalid percentage %d (use 1 to 100)", scaling.TargetCPU)
+	}
+}
+
+func (v *configValidator) checkEnvNames(path string, vars map[string]string) {
+	for _, name := range sortedKeys(vars) {
+		if errs := validation.IsEnvVarName(name); len(errs) > 0 {
+			v.errorf(path+"."+name, "invalid variable name: %s", strings.Join(errs, ", "))
+		}
+	}
+}
+
+func (v *configValidator) checkDomains() {
+	for i, domain := range v.config.Domains {
+		host := strings.TrimPrefix(domain, "*.")
+		if errs := validation.IsDNS1123Subdomain(host); len(errs) > 0 || !strings.Contains(host, ".") {
+			v.errorf(fmt.Sprintf("domains[%d]", i), "invalid domain %q: use a lowercase hostname such as app.example.com", domain)
+		}
+	}
+}
+
+func (v *configValidator) errorf(path, format string, args ...interface{}) {
+	v.add(path, fmt.Sprintf(format, args...), false)
+}
+
+func (v *configValidator) warnf(path, format string, args ...interface{}) {
+	v.add(path, fmt.Sprintf(format, args...), true)
+}
+
+// add records a problem, located at the key of path or of its closest parent
+func (v *configValidator) add(path, message string, warning bool) {
+	problem := Problem{Path: path, Message: message, Warning: warning}
+
+	for key := path; key != ""; key = parentPath(key) {
+		if pos, ok := v.config.positions[key]; ok {
+			problem.File, problem.Line, problem.Column = pos.File, pos.Line, pos.Column
+			break
+		}
+	}
+
+	v.problems = append(v.problems, problem)
+}
+
+// parentPath returns processes.web for processes.web.port and domains for domains[1]
+func parentPath(path string) string {
+	if i := strings.LastIndexAny(path, ".["); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
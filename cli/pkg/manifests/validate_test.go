@@ -0,0 +1,273 @@
+package manifests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// validConfig is a config without problems, extended by the test cases
+const validConfig = `app:
+  name: shop
+  image: ghcr.io/company/shop:v1
+  port: 3000
+resources:
+  cpu: 250m
+  memory: 256Mi
+scaling:
+  min: 1
+  max: 3
+  target_cpu: 70
+env:
+  LOG_LEVEL: info
+domains:
+  - shop.example.com
+  - "*.shop.example.com"
+processes:
+  web:
+    port: 3000
+  worker:
+    command: bin/worker
+    replicas: 2
+jobs:
+  cleanup:
+    schedule: "0 3 * * *"
+    command: [bin/cleanup]
+  report:
+    schedule: "@weekly"
+    command: bin/report
+release: bin/migrate
+`
+
+// wantProblem is a problem expected in a config, matched on its line, path
+// and part of its message
+type wantProblem struct {
+	line    int
+	path    string
+	message string
+	warning bool
+}
+
+// writeConfig writes a config file in a directory of its own
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "paas.yaml")
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func checkProblems(t *testing.T, filename string, got []Problem, want []wantProblem) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d problems, want %d: %v", len(got), len(want), got)
+	}
+	for i, problem := range got {
+		w := want[i]
+		if problem.Line != w.line || problem.Path != w.path || !strings.Contains(problem.Message, w.message) || problem.Warning != w.warning {
+			t.Errorf("problem %d = %s (warning %v), want line %d, path %s, message containing %q (warning %v)",
+				i, problem, problem.Warning, w.line, w.path, w.message, w.warning)
+		}
+		if problem.File != filename {
+			t.Errorf("problem %d is in %s, want %s", i, problem.File, filename)
+		}
+	}
+}
+
+func TestLoadConfigRejectsUnknownFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   []wantProblem
+	}{
+		{
+			name:   "valid config",
+			config: validConfig,
+		},
+		{
+			name:   "unknown top-level field",
+			config: validConfig + "scalling:\n  min: 2\n",
+			want:   []wantProblem{{line: 31, path: "", message: "unknown field scalling, did you mean scaling?"}},
+		},
+		{
+			name:   "dashes instead of underscores",
+			config: strings.Replace(validConfig, "  target_cpu: 70", "  target-cpu: 70", 1),
+			want:   []wantProblem{{line: 11, path: "scaling", message: "unknown field target-cpu, did you mean target_cpu?"}},
+		},
+		{
+			name:   "unknown field without suggestion",
+			config: strings.Replace(validConfig, "    replicas: 2", "    replicas: 2\n    sidecar: envoy", 1),
+			want:   []wantProblem{{line: 23, path: "processes.worker", message: "unknown field sidecar"}},
+		},
+		{
+			name:   "unknown field in an environment",
+			config: validConfig + "environments:\n  staging:\n    domain: staging.example.com\n",
+			want:   []wantProblem{{line: 33, path: "environments.staging", message: "unknown field domain, did you mean domains?"}},
+		},
+		{
+			name:   "string instead of integer",
+			config: strings.Replace(validConfig, "  port: 3000\nresources", "  port: http\nresources", 1),
+			want:   []wantProblem{{line: 4, path: "app.port", message: `must be an integer, not "http"`}},
+		},
+		{
+			name:   "mapping instead of list",
+			config: strings.Replace(validConfig, "domains:\n  - shop.example.com\n  - \"*.shop.example.com\"", "domains:\n  main: shop.example.com", 1),
+			want:   []wantProblem{{line: 15, path: "domains", message: "must be a list, not a mapping"}},
+		},
+		{
+			name:   "list of lists as command",
+			config: strings.Replace(validConfig, "command: [bin/cleanup]", "command: [[bin/cleanup]]", 1),
+			want:   []wantProblem{{line: 26, path: "jobs.cleanup.command", message: "must be a string, not a list"}},
+		},
+		{
+			name:   "several problems",
+			config: strings.Replace(strings.Replace(validConfig, "  memory: 256Mi", "  memroy: 256Mi", 1), "  min: 1", "  min: one", 1),
+			want: []wantProblem{
+				{line: 7, path: "resources", message: "unknown field memroy, did you mean memory?"},
+				{line: 9, path: "scaling.min", message: "must be an integer"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filename := writeConfig(t, test.config)
+			config, err := LoadConfig(filename)
+			if len(test.want) == 0 {
+				if err != nil {
+					t.Fatalf("LoadConfig() failed: %v", err)
+				}
+				checkProblems(t, filename, config.Validate(), nil)
+				return
+			}
+
+			problems, ok := AsValidationError(err)
+			if !ok {
+				t.Fatalf("LoadConfig() error = %v, want a validation error", err)
+			}
+			checkProblems(t, filename, problems, test.want)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		replace [2]string // replaced in validConfig
+		want    []wantProblem
+	}{
+		{
+			name:    "port out of range",
+			replace: [2]string{"  port: 3000\nresources", "  port: 70000\nresources"},
+			want:    []wantProblem{{line: 4, path: "app.port", message: "invalid port 70000"}},
+		},
+		{
+			name:    "process port out of range",
+			replace: [2]string{"  web:\n    port: 3000", "  web:\n    port: 0\n  api:\n    port: -1"},
+			want:    []wantProblem{{line: 21, path: "processes.api.port", message: "invalid port -1"}},
+		},
+		{
+			name:    "invalid domain",
+			replace: [2]string{"  - \"*.shop.example.com\"", "  - Shop_Example"},
+			want:    []wantProblem{{line: 16, path: "domains[1]", message: `invalid domain "Shop_Example"`}},
+		},
+		{
+			name:    "invalid process name",
+			replace: [2]string{"  worker:", "  Worker_1:"},
+			want:    []wantProblem{{line: 17, path: "processes", message: `invalid process name "Worker_1"`}},
+		},
+		{
+			name:    "invalid job name",
+			replace: [2]string{"  cleanup:", "  clean.up:"},
+			want:    []wantProblem{{line: 23, path: "jobs", message: `invalid job name "clean.up"`}},
+		},
+		{
+			name:    "schedule with 4 fields",
+			replace: [2]string{`schedule: "0 3 * * *"`, `schedule: "0 3 * *"`},
+			want:    []wantProblem{{line: 23, path: "jobs", message: `job cleanup: invalid schedule "0 3 * *": expected 5 fields`}},
+		},
+		{
+			name:    "unknown schedule macro",
+			replace: [2]string{`schedule: "@weekly"`, `schedule: "@fortnightly"`},
+			want:    []wantProblem{{line: 23, path: "jobs", message: `job report: unknown schedule "@fortnightly"`}},
+		},
+		{
+			name:    "scaling bounds",
+			replace: [2]string{"  min: 1\n  max: 3", "  min: 5\n  max: 3"},
+			want: []wantProblem{
+				{line: 10, path: "scaling.max", message: "3 is lower than scaling.min (5)"},
+				{line: 17, path: "processes", message: "process web: scaling.max (3) is lower than scaling.min (5)"},
+			},
+		},
+		{
+			name:    "invalid quantity",
+			replace: [2]string{"  cpu: 250m", "  cpu: quarter"},
+			want:    []wantProblem{{line: 6, path: "resources.cpu", message: `invalid quantity "quarter"`}},
+		},
+		{
+			name:    "invalid env var name",
+			replace: [2]string{"  LOG_LEVEL: info", "  LOG-LEVEL: info\n  1ST: x"},
+			want: []wantProblem{
+				{line: 14, path: "env.1ST", message: "invalid variable name"},
+			},
+		},
+		{
+			name:    "app name normalized",
+			replace: [2]string{"  name: shop", "  name: Shop_App"},
+			want:    []wantProblem{{line: 2, path: "app.name", message: "resources will be named shop-app", warning: true}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content := validConfig
+			if test.replace[0] != "" {
+				if !strings.Contains(content, test.replace[0]) {
+					t.Fatalf("%q not found in the config", test.replace[0])
+				}
+				content = strings.Replace(content, test.replace[0], test.replace[1], 1)
+			}
+			filename := writeConfig(t, content)
+
+			config, err := LoadConfig(filename)
+			if err != nil {
+				problems, _ := AsValidationError(err)
+				checkProblems(t, filename, problems, test.want)
+				return
+			}
+			checkProblems(t, filename, config.Validate(), test.want)
+		})
+	}
+}
+
+func TestLoadConfigForEnvReportsOverlayFile(t *testing.T) {
+	filename := writeConfig(t, validConfig)
+	overlay := EnvironmentConfigFile(filename, "staging")
+	if err := os.WriteFile(overlay, []byte("app:\n  name: shop-staging\nscaling:\n  maxx: 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadConfigForEnv(filename, "staging")
+	problems, ok := AsValidationError(err)
+	if !ok {
+		t.Fatalf("LoadConfigForEnv() error = %v, want a validation error", err)
+	}
+	checkProblems(t, overlay, problems, []wantProblem{{line: 4, path: "scaling", message: "unknown field maxx, did you mean max?"}})
+}
+
+func TestProblemString(t *testing.T) {
+	for _, test := range []struct {
+		problem Problem
+		want    string
+	}{
+		{Problem{File: "paas.yaml", Line: 12, Column: 5, Path: "scaling.max", Message: "too low"}, "paas.yaml:12:5: scaling.max: too low"},
+		{Problem{File: "paas.yaml", Path: "files", Message: "missing"}, "paas.yaml: files: missing"},
+		{Problem{Message: "invalid"}, "invalid"},
+	} {
+		if got := test.problem.String(); got != test.want {
+			t.Errorf("String() = %q, want %q", got, test.want)
+		}
+	}
+}
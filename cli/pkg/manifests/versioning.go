@@ -22,6 +22,8 @@ type DeploymentVersion struct {
 	ConfigHash  string            `json:"config_hash"`
 	Status      string            `json:"status"` // pending, success, failed
 	RollbackTo  string            `json:"rollback_to,omitempty"`
+	PromotedFromApp string        `json:"promoted_from_app,omitempty"` // app of the source environment of a promotion
+	PromotedFrom    string        `json:"promoted_from,omitempty"`     // version promoted from
 	CompletedAt *time.Time        `json:"completed_at,omitempty"`
 	ErrorMessage string           `json:"error_message,omitempty"`
 }
@@ -97,8 +99,8 @@ func (vm *VersionManager) SaveVersion(version *DeploymentVersion) error {
 		INSERT INTO deployments (
 			app_id, version, image, image_tag, image_hash,
 			config_json, config_hash, status, rollback_to_version,
-			deployed_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+			promoted_from_app, promoted_from_version, deployed_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	result, err := vm.db.GetConnection().Exec(
 		query,
@@ -111,6 +113,8 @@ func (vm *VersionManager) SaveVersion(version *DeploymentVersion) error {
 		version.ConfigHash,
 		version.Status,
 		version.RollbackTo,
+		version.PromotedFromApp,
+		version.PromotedFrom,
 		version.Timestamp,
 	)
 	if err != nil {
@@ -134,6 +138,7 @@ func (vm *VersionManager) LoadVersionHistory() ([]*DeploymentVersion, error) {
 		SELECT 
 			id, version, image, image_tag, image_hash,
 			config_json, config_hash, status, rollback_to_version,
+			promoted_from_app, promoted_from_version,
 			deployed_at, completed_at, error_message
 		FROM deployment_history 
 		WHERE app_name = ?
@@ -151,6 +156,7 @@ func (vm *VersionManager) LoadVersionHistory() ([]*DeploymentVersion, error) {
 		var version DeploymentVersion
 		var configJSON string
 		var rollbackTo *string
+		var promotedFromApp, promotedFrom *string
 		var completedAt *time.Time
 		var errorMessage *string
 
@@ -164,6 +170,8 @@ func (vm *VersionManager) LoadVersionHistory() ([]*DeploymentVersion, error) {
 			&version.ConfigHash,
 			&version.Status,
 			&rollbackTo,
+			&promotedFromApp,
+			&promotedFrom,
 			&version.Timestamp,
 			&completedAt,
 			&errorMessage,
@@ -183,6 +191,12 @@ func (vm *VersionManager) LoadVersionHistory() ([]*DeploymentVersion, error) {
 		if rollbackTo != nil {
 			version.RollbackTo = *rollbackTo
 		}
+		if promotedFromApp != nil {
+			version.PromotedFromApp = *promotedFromApp
+		}
+		if promotedFrom != nil {
+			version.PromotedFrom = *promotedFrom
+		}
 		if completedAt != nil {
 			version.CompletedAt = completedAt
 		}
@@ -247,6 +261,7 @@ func (vm *VersionManager) GetLatestSuccessfulVersion() (*DeploymentVersion, erro
 		SELECT 
 			id, version, image, image_tag, image_hash,
 			config_json, config_hash, status, rollback_to_version,
+			promoted_from_app, promoted_from_version,
 			deployed_at, completed_at, error_message
 		FROM deployment_history 
 		WHERE app_name = ? AND status = 'success'
@@ -256,6 +271,7 @@ func (vm *VersionManager) GetLatestSuccessfulVersion() (*DeploymentVersion, erro
 	var version DeploymentVersion
 	var configJSON string
 	var rollbackTo *string
+	var promotedFromApp, promotedFrom *string
 	var completedAt *time.Time
 	var errorMessage *string
 
@@ -269,6 +285,8 @@ func (vm *VersionManager) GetLatestSuccessfulVersion() (*DeploymentVersion, erro
 		&version.ConfigHash,
 		&version.Status,
 		&rollbackTo,
+		&promotedFromApp,
+		&promotedFrom,
 		&version.Timestamp,
 		&completedAt,
 		&errorMessage,
@@ -291,6 +309,12 @@ func (vm *VersionManager) GetLatestSuccessfulVersion() (*DeploymentVersion, erro
 	if rollbackTo != nil {
 		version.RollbackTo = *rollbackTo
 	}
+	if promotedFromApp != nil {
+		version.PromotedFromApp = *promotedFromApp
+	}
+	if promotedFrom != nil {
+		version.PromotedFrom = *promotedFrom
+	}
 	if completedAt != nil {
 		version.CompletedAt = completedAt
 	}
@@ -307,6 +331,7 @@ func (vm *VersionManager) GetVersionByIdentifier(identifier string) (*Deployment
 		SELECT 
 			id, version, image, image_tag, image_hash,
 			config_json, config_hash, status, rollback_to_version,
+			promoted_from_app, promoted_from_version,
 			deployed_at, completed_at, error_message
 		FROM deployment_history 
 		WHERE app_name = ? AND (version = ? OR image_tag = ?)
@@ -316,6 +341,7 @@ func (vm *VersionManager) GetVersionByIdentifier(identifier string) (*Deployment
 	var version DeploymentVersion
 	var configJSON string
 	var rollbackTo *string
+	var promotedFromApp, promotedFrom *string
 	var completedAt *time.Time
 	var errorMessage *string
 
@@ -329,6 +355,8 @@ func (vm *VersionManager) GetVersionByIdentifier(identifier string) (*Deployment
 		&version.ConfigHash,
 		&version.Status,
 		&rollbackTo,
+		&promotedFromApp,
+		&promotedFrom,
 		&version.Timestamp,
 		&completedAt,
 		&errorMessage,
@@ -351,6 +379,12 @@ func (vm *VersionManager) GetVersionByIdentifier(identifier string) (*Deployment
 	if rollbackTo != nil {
 		version.RollbackTo = *rollbackTo
 	}
+	if promotedFromApp != nil {
+		version.PromotedFromApp = *promotedFromApp
+	}
+	if promotedFrom != nil {
+		version.PromotedFrom = *promotedFrom
+	}
 	if completedAt != nil {
 		version.CompletedAt = completedAt
 	}
@@ -367,6 +401,7 @@ func (vm *VersionManager) ListVersions(limit int) ([]*DeploymentVersion, error)
 		SELECT 
 			id, version, image, image_tag, image_hash,
 			config_json, config_hash, status, rollback_to_version,
+			promoted_from_app, promoted_from_version,
 			deployed_at, completed_at, error_message
 		FROM deployment_history 
 		WHERE app_name = ?
@@ -387,6 +422,7 @@ func (vm *VersionManager) ListVersions(limit int) ([]*DeploymentVersion, error)
 		var version DeploymentVersion
 		var configJSON string
 		var rollbackTo *string
+		var promotedFromApp, promotedFrom *string
 		var completedAt *time.Time
 		var errorMessage *string
 
@@ -400,6 +436,8 @@ func (vm *VersionManager) ListVersions(limit int) ([]*DeploymentVersion, error)
 			&version.ConfigHash,
 			&version.Status,
 			&rollbackTo,
+			&promotedFromApp,
+			&promotedFrom,
 			&version.Timestamp,
 			&completedAt,
 			&errorMessage,
@@ -416,6 +454,12 @@ func (vm *VersionManager) ListVersions(limit int) ([]*DeploymentVersion, error)
 		if rollbackTo != nil {
 			version.RollbackTo = *rollbackTo
 		}
+		if promotedFromApp != nil {
+			version.PromotedFromApp = *promotedFromApp
+		}
+		if promotedFrom != nil {
+			version.PromotedFrom = *promotedFrom
+		}
 		if completedAt != nil {
 			version.CompletedAt = completedAt
 		}
@@ -0,0 +1,226 @@
+package manifests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// VolumeClaimsFile holds the PersistentVolumeClaims of an app. It sorts
+// before the deployments so that claims exist when their pods are scheduled.
+const VolumeClaimsFile = "claims.yaml"
+
+// accessModes are the access modes of PersistentVolumeClaims, mapped to
+// whether a single pod may write to the volume
+var accessModes = map[string]bool{
+	"ReadWriteOnce":    true,
+	"ReadWriteOncePod": true,
+	"ReadWriteMany":    false,
+	"ReadOnlyMany":     false,
+}
+
+// Volume is a persistent volume of the app resolved against its defaults
+type Volume struct {
+	Name         string
+	Claim        string // name of its PersistentVolumeClaim
+	MountPath    string
+	Size         string
+	StorageClass string
+	AccessMode   string
+	ReadOnly     bool
+	Process      string // name of the process mounting it, empty for the one receiving ingress
+}
+
+// SingleWriter reports whether only one pod can write to the volume
+func (v Volume) SingleWriter() bool {
+	return accessModes[v.AccessMode]
+}
+
+// SingleWriterVolume returns the first volume of the process only one pod can
+// write to, which limits the process to a single replica
+func (p Process) SingleWriterVolume() (Volume, bool) {
+	for _, volume := range p.Volumes {
+		if volume.SingleWriter() {
+			return volume, true
+		}
+	}
+	return Volume{}, false
+}
+
+// GetVolumes returns the volumes of the app in declaration order
+func (c *Config) GetVolumes() ([]Volume, error) {
+	volumes := make([]Volume, 0, len(c.Volumes))
+	seen := make(map[string]bool)
+	mountPaths := make(map[string]string)
+
+	for i, config := range c.Volumes {
+		if !processNamePattern.MatchString(config.Name) {
+			return nil, fmt.Errorf("volumes[%d]: invalid name %q: use lowercase letters, digits and hyphens", i, config.Name)
+		}
+		if config.Name == filesVolume {
+			return nil, fmt.Errorf("volumes[%d]: name %s is reserved for files", i, config.Name)
+		}
+		if seen[config.Name] {
+			return nil, fmt.Errorf("volume %s is declared twice", config.Name)
+		}
+		seen[config.Name] = true
+
+		if !strings.HasPrefix(config.MountPath, "/") {
+			return nil, fmt.Errorf("volume %s: mount_path must be an absolute path", config.Name)
+		}
+		mountKey := config.Process + ":" + config.MountPath
+		if other, ok := mountPaths[mountKey]; ok {
+			return nil, fmt.Errorf("volumes %s and %s are both mounted on %s", other, config.Name, config.MountPath)
+		}
+		mountPaths[mountKey] = config.Name
+
+		if config.Size == "" {
+			return nil, fmt.Errorf("volume %s: size is required (e.g. 10Gi)", config.Name)
+		}
+		if _, err := resource.ParseQuantity(config.Size); err != nil {
+			return nil, fmt.Errorf("volume %s: invalid size %q (e.g. 10Gi)", config.Name, config.Size)
+		}
+
+		volume := Volume{
+			Name:         config.Name,
+			Claim:        c.App.GetDNSName() + "-" + config.Name,
+			MountPath:    config.MountPath,
+			Size:         config.Size,
+			StorageClass: config.StorageClass,
+			AccessMode:   config.AccessMode,
+			Process:      config.Process,
+		}
+		if volume.AccessMode == "" {
+			volume.AccessMode = "ReadWriteOnce"
+		}
+		if _, ok := accessModes[volume.AccessMode]; !ok {
+			return nil, fmt.Errorf("volume %s: invalid access_mode %q (use ReadWriteOnce, ReadWriteOncePod, ReadWriteMany or ReadOnlyMany)", config.Name, volume.AccessMode)
+		}
+		volume.ReadOnly = volume.AccessMode == "ReadOnlyMany"
+
+		volumes = append(volumes, volume)
+	}
+
+	return volumes, nil
+}
+
+// hasSingleWriterVolume reports whether a volume of the app is written by a single pod
+func (c *Config) hasSingleWriterVolume() bool {
+	for _, volume := range c.Volumes {
+		if volume.AccessMode == "" || accessModes[volume.AccessMode] {
+			return true
+		}
+	}
+	return false
+}
+
+// attachVolumes mounts the volumes of the app into their processes. Processes
+// writing to a single-writer volume are recreated on rollout, so that the old
+// pod releases the volume before the new one mounts it.
+func (c *Config) attachVolumes(processes []Process) error {
+	volumes, err := c.GetVolumes()
+	if err != nil {
+		return err
+	}
+
+	for _, volume := range volumes {
+		target := -1
+		for i, process := range processes {
+			if (volume.Process == "" && process.Ingress) || volume.Process == process.Name {
+				target = i
+				break
+			}
+		}
+		if target < 0 {
+			if volume.Process == "" {
+				return fmt.Errorf("volume %s: no process receives ingress, set the process mounting it", volume.Name)
+			}
+			return fmt.Errorf("volume %s: app %s has no process %s", volume.Name, c.App.Name, volume.Process)
+		}
+
+		process := &processes[target]
+		process.Volumes = append(process.Volumes, volume)
+
+		if volume.SingleWriter() {
+			if process.Autoscaled() || process.Scaling.Min > 1 {
+				return fmt.Errorf("volume %s is %s: process %s must run a single replica (set scaling.min and scaling.max to 1, or use access_mode: ReadWriteMany)",
+					volume.Name, volume.AccessMode, process.Name)
+			}
+			process.Recreate = true
+		}
+	}
+
+	return nil
+}
+
+const volumeClaimsTemplate = `{{- range $i, $volume := .Volumes }}
+{{- if $i }}
+---
+{{- end }}
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: {{ $volume.Claim }}
+  namespace: {{ $.App.GetNamespace }}
+  labels:
+    app: {{ $.App.GetDNSName }}
+    managed-by: shipyard
+    shipyard.app: {{ $.App.GetDNSName }}
+    shipyard.volume: {{ $volume.Name }}
+spec:
+  accessModes:
+  - {{ $volume.AccessMode }}
+  {{- if $volume.StorageClass }}
+  storageClassName: {{ $volume.StorageClass }}
+  {{- end }}
+  resources:
+    requests:
+      storage: {{ $volume.Size }}
+{{- end }}
+`
+
+// generateVolumeClaims creates claims.yaml with the PersistentVolumeClaims of
+// the app. Claims of removed volumes are left in the cluster with their data.
+func (g *Generator) generateVolumeClaims(appDir string) error {
+	volumes, err := g.config.GetVolumes()
+	if err != nil {
+		return err
+	}
+
+	filePath := filepath.Join(appDir, VolumeClaimsFile)
+	if len(volumes) == 0 {
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", filePath, err)
+		}
+		return nil
+	}
+
+	tmpl, err := template.New("claims").Parse(volumeClaimsTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse volume claims template: %w", err)
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create volume claims file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	templateData := struct {
+		*Config
+		Volumes []Volume
+	}{
+		Config:  g.config,
+		Volumes: volumes,
+	}
+
+	if err := tmpl.Execute(file, templateData); err != nil {
+		return fmt.Errorf("failed to execute volume claims template: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+package manifests
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGenerateVolumeClaims(t *testing.T) {
+	objects := renderApp(t, `app:
+  name: shop
+  image: ghcr.io/company/shop:v1
+  port: 3000
+scaling:
+  min: 1
+  max: 1
+processes:
+  web: {}
+  worker:
+    command: bin/worker
+    replicas: 3
+volumes:
+  - name: uploads
+    mount_path: /app/uploads
+    size: 10Gi
+    storage_class: fast
+  - name: cache
+    mount_path: /app/cache
+    size: 1Gi
+    access_mode: ReadWriteMany
+    process: worker
+  - name: assets
+    mount_path: /app/public
+    size: 500Mi
+    access_mode: ReadOnlyMany
+    process: worker
+`)
+
+	if got, want := renderedKeys(objects, "PersistentVolumeClaim"), []string{
+		"PersistentVolumeClaim/shop-assets",
+		"PersistentVolumeClaim/shop-cache",
+		"PersistentVolumeClaim/shop-uploads",
+	}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("rendered %v, want %v", got, want)
+	}
+
+	claims := []struct {
+		name         string
+		accessMode   corev1.PersistentVolumeAccessMode
+		storageClass string
+		size         string
+	}{
+		{name: "uploads", accessMode: corev1.ReadWriteOnce, storageClass: "fast", size: "10Gi"},
+		{name: "cache", accessMode: corev1.ReadWriteMany, size: "1Gi"},
+		{name: "assets", accessMode: corev1.ReadOnlyMany, size: "500Mi"},
+	}
+	for _, test := range claims {
+		var claim corev1.PersistentVolumeClaim
+		renderedObject(t, objects, "PersistentVolumeClaim/shop-"+test.name, &claim)
+
+		if !reflect.DeepEqual(claim.Spec.AccessModes, []corev1.PersistentVolumeAccessMode{test.accessMode}) {
+			t.Errorf("%s: accessModes = %v, want %s", test.name, claim.Spec.AccessModes, test.accessMode)
+		}
+		var storageClass string
+		if claim.Spec.StorageClassName != nil {
+			storageClass = *claim.Spec.StorageClassName
+		}
+		if storageClass != test.storageClass {
+			t.Errorf("%s: storageClassName = %q, want %q", test.name, storageClass, test.storageClass)
+		}
+		if got := claim.Spec.Resources.Requests.Storage().String(); got != test.size {
+			t.Errorf("%s: storage = %s, want %s", test.name, got, test.size)
+		}
+		if claim.Labels["shipyard.app"] != "shop" || claim.Labels["shipyard.volume"] != test.name {
+			t.Errorf("%s: labels = %v, want shipyard.app: shop and shipyard.volume: %s", test.name, claim.Labels, test.name)
+		}
+	}
+
+	deployments := []struct {
+		name     string
+		strategy appsv1.DeploymentStrategyType
+		mounts   map[string]string // volume name to mount path
+		readOnly []string
+	}{
+		// The single-writer volume recreates the web pod on rollout
+		{name: "shop", strategy: appsv1.RecreateDeploymentStrategyType, mounts: map[string]string{"uploads": "/app/uploads"}},
+		{name: "shop-worker", mounts: map[string]string{"cache": "/app/cache", "assets": "/app/public"}, readOnly: []string{"assets"}},
+	}
+	for _, test := range deployments {
+		var deployment appsv1.Deployment
+		renderedObject(t, objects, "Deployment/"+test.name, &deployment)
+
+		if deployment.Spec.Strategy.Type != test.strategy {
+			t.Errorf("%s: strategy = %q, want %q", test.name, deployment.Spec.Strategy.Type, test.strategy)
+		}
+
+		claims := make(map[string]string)
+		for _, volume := range deployment.Spec.Template.Spec.Volumes {
+			claims[volume.Name] = volume.PersistentVolumeClaim.ClaimName
+			if volume.PersistentVolumeClaim.ReadOnly != contains(test.readOnly, volume.Name) {
+				t.Errorf("%s: volume %s readOnly = %v", test.name, volume.Name, volume.PersistentVolumeClaim.ReadOnly)
+			}
+		}
+		mounts := make(map[string]string)
+		for _, mount := range deployment.Spec.Template.Spec.Containers[0].VolumeMounts {
+			mounts[mount.Name] = mount.MountPath
+			if mount.ReadOnly != contains(test.readOnly, mount.Name) {
+				t.Errorf("%s: mount %s readOnly = %v", test.name, mount.Name, mount.ReadOnly)
+			}
+		}
+		if !reflect.DeepEqual(mounts, test.mounts) {
+			t.Errorf("%s: mounts = %v, want %v", test.name, mounts, test.mounts)
+		}
+		for name := range test.mounts {
+			if claims[name] != "shop-"+name {
+				t.Errorf("%s: volume %s uses claim %q, want shop-%s", test.name, name, claims[name], name)
+			}
+		}
+	}
+}
+
+func TestAttachVolumes(t *testing.T) {
+	base := `app:
+  name: shop
+  image: ghcr.io/company/shop:v1
+  port: 3000
+processes:
+  web: {}
+  worker:
+    command: bin/worker
+`
+
+	tests := []struct {
+		name      string
+		content   string
+		recreate  map[string]bool // processes recreated on rollout
+		singleFor string          // process limited to one replica by a volume
+		err       string
+	}{
+		{
+			name: "single writer on the ingress process",
+			content: base + `volumes:
+  - name: data
+    mount_path: /data
+    size: 1Gi
+`,
+			recreate:  map[string]bool{"web": true},
+			singleFor: "web",
+		},
+		{
+			name: "shared volume",
+			content: base + `    replicas: 3
+volumes:
+  - name: data
+    mount_path: /data
+    size: 1Gi
+    access_mode: ReadWriteMany
+    process: worker
+`,
+			recreate: map[string]bool{},
+		},
+		{
+			name: "single writer on several replicas",
+			content: base + `    replicas: 2
+volumes:
+  - name: data
+    mount_path: /data
+    size: 1Gi
+    process: worker
+`,
+			err: "volume data is ReadWriteOnce: process worker must run a single replica",
+		},
+		{
+			name: "unknown process",
+			content: base + `volumes:
+  - name: data
+    mount_path: /data
+    size: 1Gi
+    process: scheduler
+`,
+			err: "app shop has no process scheduler",
+		},
+		{
+			name: "relative mount path",
+			content: base + `volumes:
+  - name: data
+    mount_path: data
+    size: 1Gi
+`,
+			err: "mount_path must be an absolute path",
+		},
+		{
+			name: "invalid access mode",
+			content: base + `volumes:
+  - name: data
+    mount_path: /data
+    size: 1Gi
+    access_mode: ReadWriteSome
+`,
+			err: `invalid access_mode "ReadWriteSome"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := LoadConfig(writeConfig(t, test.content))
+			if err != nil {
+				t.Fatalf("LoadConfig() failed: %v", err)
+			}
+
+			processes, err := config.GetProcesses()
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("GetProcesses() error = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetProcesses() failed: %v", err)
+			}
+
+			for _, process := range processes {
+				if process.Recreate != test.recreate[process.Name] {
+					t.Errorf("process %s: Recreate = %v, want %v", process.Name, process.Recreate, test.recreate[process.Name])
+				}
+				_, single := process.SingleWriterVolume()
+				if single != (process.Name == test.singleFor) {
+					t.Errorf("process %s: SingleWriterVolume() found = %v", process.Name, single)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package monitoring
+
+import (
+	"fmt"
+	"time"
+)
+
+// agentTick is how often the agent checks which apps are due for collection
+const agentTick = time.Second
+
+// discoveryInterval is how often the agent looks for apps in the cluster
+const discoveryInterval = time.Minute
+
+// Run collects metrics, health checks and alerts of every monitored app at
+// its health check interval, and optionally stores Kubernetes events, until
+// the collector is stopped. In a pod, the apps are also discovered from the
+// cluster.
+func (c *Collector) Run(watchEvents bool) error {
+	if watchEvents {
+		go func() {
+			for c.ctx.Err() == nil {
+				if err := c.WatchEvents(nil); err != nil {
+					fmt.Printf("Warning: failed to ingest events: %v\n", err)
+					c.sleep(eventRetryDelay)
+				}
+			}
+		}()
+	}
+
+	nextRun := make(map[int64]time.Time)
+	var discoveredAt time.Time
+	ticker := time.NewTicker(agentTick)
+	defer ticker.Stop()
+
+	for {
+		if c.k8s.InCluster() && time.Since(discoveredAt) >= discoveryInterval {
+			if err := c.discoverApps(); err != nil {
+				fmt.Printf("Warning: failed to discover apps: %v\n", err)
+			}
+			discoveredAt = time.Now()
+		}
+
+		c.collectDueApps(nextRun)
+
+		select {
+		case <-c.ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// collectDueApps collects the apps whose interval has elapsed since their
+// last collection. Apps deployed while the agent runs are picked up here.
+func (c *Collector) collectDueApps(nextRun map[int64]time.Time) {
+	apps, err := c.getAppsToMonitor("")
+	if err != nil {
+		fmt.Printf("Warning: failed to get apps: %v\n", err)
+		return
+	}
+
+	for _, app := range apps {
+		if c.ctx.Err() != nil {
+			return
+		}
+
+		now := time.Now()
+		if next, ok := nextRun[app.ID]; ok && now.Before(next) {
+			continue
+		}
+
+		config, err := c.getMonitoringConfig(app.ID)
+		if err != nil {
+			fmt.Printf("Warning: failed to get monitoring config for %s: %v\n", app.Name, err)
+			continue
+		}
+
+		interval := time.Duration(config.HealthCheckInterval) * time.Second
+		if interval <= 0 {
+			interval = 30 * time.Second
+		}
+		nextRun[app.ID] = now.Add(interval)
+
+		if !config.Enabled {
+			continue
+		}
+
+		if err := c.collectAppMetrics(app); err != nil {
+			fmt.Printf("Warning: failed to collect metrics for %s: %v\n", app.Name, err)
+			continue
+		}
+		fmt.Printf("[%s] 📊 Collected %s (next in %v)\n", now.Format("15:04:05"), app.Name, interval)
+	}
+}
+
+// discoverApps registers the apps deployed by shipyard that are missing from
+// the database, with their namespace. The database of an agent running in a
+// pod does not hold the apps deployed from the machines of the operators.
+// Apps found in several namespaces are registered with the first one.
+func (c *Collector) discoverApps() error {
+	managed, err := c.k8s.ListManagedApps()
+	if err != nil {
+		return fmt.Errorf("failed to list the apps of the cluster: %w", err)
+	}
+
+	known, err := loadDeployedApps(c.db)
+	if err != nil {
+		return err
+	}
+	byDNSName := make(map[string]deployedApp)
+	for _, app := range known {
+		byDNSName[app.DNSName] = app
+	}
+
+	seen := make(map[string]bool)
+	for _, app := range managed {
+		if seen[app.Name] {
+			continue
+		}
+		seen[app.Name] = true
+
+		existing, ok := byDNSName[app.Name]
+		if ok && existing.Namespace == app.Namespace {
+			continue
+		}
+
+		appID := existing.ID
+		if !ok {
+			if appID, err = c.db.GetOrCreateApp(app.Name); err != nil {
+				return err
+			}
+			fmt.Printf("🔎 Found app %s in namespace %s\n", app.Name, app.Namespace)
+		}
+
+		_, err := c.db.GetConnection().Exec(
+			"UPDATE apps SET namespace = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", app.Namespace, appID)
+		if err != nil {
+			return fmt.Errorf("failed to record the namespace of %s: %w", app.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,261 @@
+package monitoring
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultMetricTypes are the metric types aggregated when none are requested
+var DefaultMetricTypes = []MetricType{MetricTypeCPU, MetricTypeMemory, MetricTypePods}
+
+// maxBuckets is the maximum number of buckets returned for a period
+const maxBuckets = 60
+
+// bucketSizes are the bucket sizes that can be picked for a period
+var bucketSizes = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	3 * time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+}
+
+// MetricStats represents aggregated values of a metric type
+type MetricStats struct {
+	Avg   float64 `json:"avg"`
+	Max   float64 `json:"max"`
+	P95   float64 `json:"p95"`
+	Count int     `json:"data_points"`
+	Unit  string  `json:"unit,omitempty"`
+}
+
+// MetricsBucket represents the aggregated metrics of one time bucket
+type MetricsBucket struct {
+	Start time.Time                  `json:"start"`
+	Stats map[MetricType]MetricStats `json:"stats"`
+}
+
+// MetricsSeries represents the time-bucketed metrics of an application
+type MetricsSeries struct {
+	AppName    string                     `json:"app_name"`
+	Period     time.Duration              `json:"-"`
+	BucketSize time.Duration              `json:"-"`
+	Summary    map[MetricType]MetricStats `json:"summary"`
+	Buckets    []MetricsBucket            `json:"buckets"`
+}
+
+// BucketSizeFor picks the smallest bucket size giving at most maxBuckets buckets
+func BucketSizeFor(period time.Duration) time.Duration {
+	for _, size := range bucketSizes {
+		if period/size <= maxBuckets {
+			return size
+		}
+	}
+	return bucketSizes[len(bucketSizes)-1]
+}
+
+// ParseMetricTypes converts metric type names to MetricTypes
+func ParseMetricTypes(names []string) ([]MetricType, error) {
+	known := map[MetricType]bool{
+		MetricTypeCPU:      true,
+		MetricTypeMemory:   true,
+		MetricTypeNetwork:  true,
+		MetricTypeDisk:     true,
+		MetricTypePods:     true,
+		MetricTypeRequests: true,
+		MetricTypeErrors:   true,
+		MetricTypeLatency:  true,
+
+		MetricTypeLatencyP50: true,
+		MetricTypeLatencyP95: true,
+		MetricTypeLatencyP99: true,
+
+		MetricTypeCPUPercent:    true,
+		MetricTypeMemoryPercent: true,
+	}
+
+	var types []MetricType
+	for _, name := range names {
+		metricType := MetricType(strings.ToLower(strings.TrimSpace(name)))
+		if metricType == "" {
+			continue
+		}
+		if !known[metricType] {
+			return nil, fmt.Errorf("unknown metric type: %s", name)
+		}
+		types = append(types, metricType)
+	}
+
+	return types, nil
+}
+
+// GetMetricsSeries aggregates the stored metrics of the last period into
+// automatically sized buckets, for all or a specific app
+func (c *Collector) GetMetricsSeries(appName string, period time.Duration, types []MetricType) ([]MetricsSeries, error) {
+	if period <= 0 {
+		return nil, fmt.Errorf("period must be positive, got %v", period)
+	}
+	if len(types) == 0 {
+		types = DefaultMetricTypes
+	}
+
+	now := time.Now()
+	since := now.Add(-period)
+	bucketSize := BucketSizeFor(period)
+
+	query := `
+		SELECT a.name, m.metric_type, m.value, m.unit, m.timestamp
+		FROM metrics m
+		JOIN apps a ON a.id = m.app_id
+		WHERE m.timestamp > ?`
+
+	args := []interface{}{since}
+	if appName != "" {
+		query += " AND a.name = ?"
+		args = append(args, appName)
+	}
+
+	placeholders := make([]string, len(types))
+	for i, metricType := range types {
+		placeholders[i] = "?"
+		args = append(args, string(metricType))
+	}
+	query += fmt.Sprintf(" AND m.metric_type IN (%s) ORDER BY a.name, m.timestamp", strings.Join(placeholders, ", "))
+
+	rows, err := c.db.GetConnection().Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metrics: %w", err)
+	}
+	defer rows.Close()
+
+	// Collect raw values per app, bucket and type
+	type sample struct {
+		metricType MetricType
+		value      float64
+		timestamp  time.Time
+	}
+	samples := make(map[string][]sample)
+	units := make(map[MetricType]string)
+	var appNames []string
+
+	for rows.Next() {
+		var name, metricType string
+		var unit *string
+		var s sample
+
+		if err := rows.Scan(&name, &metricType, &s.value, &unit, &s.timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan metric row: %w", err)
+		}
+		s.metricType = MetricType(metricType)
+		if unit != nil && *unit != "" {
+			units[s.metricType] = *unit
+		}
+
+		if _, ok := samples[name]; !ok {
+			appNames = append(appNames, name)
+		}
+		samples[name] = append(samples[name], s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating metric rows: %w", err)
+	}
+
+	// Build every bucket of the period so gaps show up in charts
+	firstBucket := since.Truncate(bucketSize)
+	bucketCount := int(now.Sub(firstBucket)/bucketSize) + 1
+	if bucketCount < 1 {
+		bucketCount = 1
+	}
+
+	var series []MetricsSeries
+	for _, name := range appNames {
+		bucketValues := make([]map[MetricType][]float64, bucketCount)
+		totalValues := make(map[MetricType][]float64)
+
+		for _, s := range samples[name] {
+			index := int(s.timestamp.Sub(firstBucket) / bucketSize)
+			if index < 0 || index >= bucketCount {
+				continue
+			}
+			if bucketValues[index] == nil {
+				bucketValues[index] = make(map[MetricType][]float64)
+			}
+			bucketValues[index][s.metricType] = append(bucketValues[index][s.metricType], s.value)
+			totalValues[s.metricType] = append(totalValues[s.metricType], s.value)
+		}
+
+		appSeries := MetricsSeries{
+			AppName:    name,
+			Period:     period,
+			BucketSize: bucketSize,
+			Summary:    make(map[MetricType]MetricStats),
+			Buckets:    make([]MetricsBucket, bucketCount),
+		}
+
+		for _, metricType := range types {
+			stats := computeStats(totalValues[metricType])
+			stats.Unit = units[metricType]
+			appSeries.Summary[metricType] = stats
+		}
+
+		for i := range appSeries.Buckets {
+			bucket := MetricsBucket{
+				Start: firstBucket.Add(time.Duration(i) * bucketSize),
+				Stats: make(map[MetricType]MetricStats),
+			}
+			for _, metricType := range types {
+				stats := computeStats(bucketValues[i][metricType])
+				stats.Unit = units[metricType]
+				bucket.Stats[metricType] = stats
+			}
+			appSeries.Buckets[i] = bucket
+		}
+
+		series = append(series, appSeries)
+	}
+
+	return series, nil
+}
+
+// computeStats returns the average, maximum and 95th percentile of values
+func computeStats(values []float64) MetricStats {
+	if len(values) == 0 {
+		return MetricStats{}
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, value := range sorted {
+		sum += value
+	}
+
+	return MetricStats{
+		Avg:   sum / float64(len(sorted)),
+		Max:   sorted[len(sorted)-1],
+		P95:   percentile(sorted, 95),
+		Count: len(sorted),
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
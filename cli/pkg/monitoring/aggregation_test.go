@@ -0,0 +1,37 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetMetricsSeriesPeriod(t *testing.T) {
+	c := newTestCollector(t)
+	appID, err := c.db.GetOrCreateApp("web")
+	if err != nil {
+		t.Fatalf("GetOrCreateApp() failed: %v", err)
+	}
+	if _, err := c.db.GetConnection().Exec(
+		"INSERT INTO metrics (app_id, metric_type, value, unit, timestamp) VALUES (?, ?, ?, ?, ?)",
+		appID, string(MetricTypeCPU), 120.0, "m", time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("failed to insert metric: %v", err)
+	}
+
+	for _, period := range []time.Duration{0, -time.Hour} {
+		if _, err := c.GetMetricsSeries("web", period, nil); err == nil {
+			t.Errorf("GetMetricsSeries(%v) succeeded, want an error", period)
+		}
+	}
+
+	for _, period := range []time.Duration{time.Nanosecond, time.Minute, time.Hour} {
+		series, err := c.GetMetricsSeries("web", period, nil)
+		if err != nil {
+			t.Fatalf("GetMetricsSeries(%v) failed: %v", period, err)
+		}
+		for _, appSeries := range series {
+			if len(appSeries.Buckets) < 1 {
+				t.Errorf("GetMetricsSeries(%v) returned no bucket", period)
+			}
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package monitoring
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AppAlert is a stored alert together with the name of its app
+type AppAlert struct {
+	AppName string `json:"app_name"`
+	Alert
+}
+
+// ListAlerts returns open alerts (active or silenced), most severe first.
+// With activeOnly, silenced alerts are left out.
+func (c *Collector) ListAlerts(appName string, activeOnly bool) ([]AppAlert, error) {
+	if err := c.expireSilences(); err != nil {
+		return nil, err
+	}
+
+	where := "al.status IN ('active', 'suppressed')"
+	if activeOnly {
+		where = "al.status = 'active'"
+	}
+
+	return c.queryAlerts(where, appName, nil, alertsBySeverity)
+}
+
+// GetAlertsHistory returns all alerts created during the last period, most recent first
+func (c *Collector) GetAlertsHistory(appName string, period time.Duration) ([]AppAlert, error) {
+	if err := c.expireSilences(); err != nil {
+		return nil, err
+	}
+
+	return c.queryAlerts("al.created_at > ?", appName, []interface{}{time.Now().Add(-period)}, "al.created_at DESC")
+}
+
+// ResolveAlertByID marks an open alert as resolved
+func (c *Collector) ResolveAlertByID(alertID int64) error {
+	query := `
+		UPDATE alerts
+		SET status = 'resolved', resolved_at = ?
+		WHERE id = ? AND status IN ('active', 'suppressed')`
+
+	return c.updateAlert(alertID, query, time.Now(), alertID)
+}
+
+// AcknowledgeAlert records that someone is looking at an open alert
+func (c *Collector) AcknowledgeAlert(alertID int64) error {
+	query := `
+		UPDATE alerts
+		SET acknowledged_at = COALESCE(acknowledged_at, ?)
+		WHERE id = ? AND status IN ('active', 'suppressed')`
+
+	return c.updateAlert(alertID, query, time.Now(), alertID)
+}
+
+// SilenceAlerts suppresses an alert type of an app for a duration. Open
+// alerts of that type are suppressed, and new ones are recorded without
+// firing until the silence ends. It returns when the silence ends.
+func (c *Collector) SilenceAlerts(appName, alertType string, duration time.Duration) (time.Time, error) {
+	until := time.Now().Add(duration)
+
+	if !isAlertType(alertType) {
+		return until, fmt.Errorf("unknown alert type: %s (use %s)", alertType, strings.Join(AlertTypes, ", "))
+	}
+	if duration <= 0 {
+		return until, fmt.Errorf("silence duration must be positive")
+	}
+
+	appID, err := c.getAppID(appName)
+	if err != nil {
+		return until, err
+	}
+
+	tx, err := c.db.BeginTx()
+	if err != nil {
+		return until, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// A new silence replaces the previous one of the alert type
+	_, err = tx.Exec(`
+		INSERT INTO alert_silences (app_id, alert_type, silenced_until, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (app_id, alert_type) DO UPDATE SET silenced_until = excluded.silenced_until, created_at = excluded.created_at`,
+		appID, alertType, until, time.Now())
+	if err != nil {
+		return until, fmt.Errorf("failed to record silence: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE alerts
+		SET status = 'suppressed', suppressed_until = ?
+		WHERE app_id = ? AND alert_type = ? AND status IN ('active', 'suppressed')`,
+		until, appID, alertType)
+	if err != nil {
+		return until, fmt.Errorf("failed to silence alerts: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return until, fmt.Errorf("failed to commit silence: %w", err)
+	}
+	return until, nil
+}
+
+// silencedUntil returns when the silence of an alert type of an app ends, or
+// nil when it is not silenced
+func (c *Collector) silencedUntil(appID int64, alertType string) (*time.Time, error) {
+	var until time.Time
+	err := c.db.GetConnection().QueryRow(`
+		SELECT silenced_until FROM alert_silences
+		WHERE app_id = ? AND alert_type = ? AND silenced_until > ?`,
+		appID, alertType, time.Now()).Scan(&until)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get silence: %w", err)
+	}
+	return &until, nil
+}
+
+// expireSilences deletes the silences that have ended and resolves the
+// alerts they suppressed
+func (c *Collector) expireSilences() error {
+	now := time.Now()
+	if _, err := c.db.GetConnection().Exec("DELETE FROM alert_silences WHERE silenced_until <= ?", now); err != nil {
+		return fmt.Errorf("failed to expire silences: %w", err)
+	}
+
+	_, err := c.db.GetConnection().Exec(`
+		UPDATE alerts
+		SET status = 'resolved', resolved_at = suppressed_until
+		WHERE status = 'suppressed' AND suppressed_until IS NOT NULL AND suppressed_until <= ?`,
+		now)
+	if err != nil {
+		return fmt.Errorf("failed to expire silences: %w", err)
+	}
+	return nil
+}
+
+// isAlertType reports whether the collector fires alerts of a type
+func isAlertType(alertType string) bool {
+	for _, known := range AlertTypes {
+		if alertType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// updateAlert runs an update on a single alert and reports missing alerts
+func (c *Collector) updateAlert(alertID int64, query string, args ...interface{}) error {
+	result, err := c.db.GetConnection().Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update alert: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		var status string
+		err := c.db.GetConnection().QueryRow("SELECT status FROM alerts WHERE id = ?", alertID).Scan(&status)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("alert %d not found", alertID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get alert %d: %w", alertID, err)
+		}
+		return fmt.Errorf("alert %d is %s", alertID, status)
+	}
+
+	return nil
+}
+
+// alertsBySeverity orders alerts with the most severe and most recent first
+const alertsBySeverity = `
+	CASE al.severity
+		WHEN 'critical' THEN 1
+		WHEN 'warning' THEN 2
+		WHEN 'info' THEN 3
+	END,
+	al.created_at DESC`
+
+// queryAlerts returns the alerts matching a condition in the given order
+func (c *Collector) queryAlerts(where, appName string, args []interface{}, orderBy string) ([]AppAlert, error) {
+	query := `
+		SELECT al.id, al.app_id, a.name, al.alert_type, al.threshold, al.current_value,
+		       al.severity, al.status, al.message, al.created_at, al.resolved_at,
+		       al.acknowledged_at, al.suppressed_until
+		FROM alerts al
+		JOIN apps a ON a.id = al.app_id
+		WHERE ` + where
+
+	if appName != "" {
+		query += " AND a.name = ?"
+		args = append(args, appName)
+	}
+	query += " ORDER BY " + orderBy
+
+	rows, err := c.db.GetConnection().Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []AppAlert
+	for rows.Next() {
+		var alert AppAlert
+		var severity, status string
+
+		err := rows.Scan(
+			&alert.ID,
+			&alert.AppID,
+			&alert.AppName,
+			&alert.Type,
+			&alert.Threshold,
+			&alert.CurrentValue,
+			&severity,
+			&status,
+			&alert.Message,
+			&alert.CreatedAt,
+			&alert.ResolvedAt,
+			&alert.AcknowledgedAt,
+			&alert.SuppressedUntil,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert row: %w", err)
+		}
+
+		alert.Severity = AlertSeverity(severity)
+		alert.Status = AlertStatus(status)
+		alerts = append(alerts, alert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alert rows: %w", err)
+	}
+
+	return alerts, nil
+}
@@ -0,0 +1,169 @@
+package monitoring
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestCollector returns a collector on a database of its own, without a
+// Kubernetes client
+func newTestCollector(t *testing.T) *Collector {
+	t.Helper()
+	db := newTestDB(t)
+	collector := &Collector{db: db, notifier: NewNotifier(db), scrapes: make(map[int64]map[string]podScrape)}
+	t.Cleanup(collector.notifier.Close)
+	return collector
+}
+
+// countAlerts returns the number of alerts of an app with a status
+func countAlerts(t *testing.T, c *Collector, appID int64, status AlertStatus) int {
+	t.Helper()
+	var count int
+	err := c.db.GetConnection().QueryRow(
+		"SELECT COUNT(*) FROM alerts WHERE app_id = ? AND status = ?", appID, string(status)).Scan(&count)
+	if err != nil {
+		t.Fatalf("failed to count alerts: %v", err)
+	}
+	return count
+}
+
+func TestSilenceAlertsValidatesType(t *testing.T) {
+	c := newTestCollector(t)
+	if _, err := c.db.GetOrCreateApp("web"); err != nil {
+		t.Fatalf("GetOrCreateApp() failed: %v", err)
+	}
+
+	for _, alertType := range []string{"cpu", "disk_high", "CPU_HIGH", ""} {
+		_, err := c.SilenceAlerts("web", alertType, time.Hour)
+		if err == nil || !strings.Contains(err.Error(), "unknown alert type") {
+			t.Errorf("SilenceAlerts(%q) error = %v, want an unknown alert type", alertType, err)
+		}
+	}
+
+	for _, alertType := range AlertTypes {
+		if _, err := c.SilenceAlerts("web", alertType, time.Hour); err != nil {
+			t.Errorf("SilenceAlerts(%q) failed: %v", alertType, err)
+		}
+	}
+}
+
+func TestSilenceAlertsBeforeFiring(t *testing.T) {
+	c := newTestCollector(t)
+	appID, err := c.db.GetOrCreateApp("web")
+	if err != nil {
+		t.Fatalf("GetOrCreateApp() failed: %v", err)
+	}
+
+	until, err := c.SilenceAlerts("web", AlertTypeCPUHigh, time.Hour)
+	if err != nil {
+		t.Fatalf("SilenceAlerts() failed: %v", err)
+	}
+
+	// Nothing is firing: no alert is recorded for the silence
+	alerts, err := c.ListAlerts("web", false)
+	if err != nil {
+		t.Fatalf("ListAlerts() failed: %v", err)
+	}
+	if len(alerts) != 0 {
+		t.Errorf("ListAlerts() = %+v, want no alert", alerts)
+	}
+
+	// The silenced type is recorded without firing, others fire
+	alert := Alert{AppID: appID, Type: AlertTypeCPUHigh, Threshold: 80, CurrentValue: 95,
+		Severity: AlertSeverityWarning, Status: AlertStatusActive, Message: "CPU", CreatedAt: time.Now()}
+	if err := c.createOrUpdateAlert(alert); err != nil {
+		t.Fatalf("createOrUpdateAlert() failed: %v", err)
+	}
+	alert.Type = AlertTypeMemoryHigh
+	if err := c.createOrUpdateAlert(alert); err != nil {
+		t.Fatalf("createOrUpdateAlert() failed: %v", err)
+	}
+
+	alerts, err = c.ListAlerts("web", false)
+	if err != nil {
+		t.Fatalf("ListAlerts() failed: %v", err)
+	}
+	statuses := make(map[string]AlertStatus)
+	for _, alert := range alerts {
+		statuses[alert.Type] = alert.Status
+		if alert.Type == AlertTypeCPUHigh && (alert.SuppressedUntil == nil || !alert.SuppressedUntil.Equal(until)) {
+			t.Errorf("suppressed until %v, want %v", alert.SuppressedUntil, until)
+		}
+	}
+	if statuses[AlertTypeCPUHigh] != AlertStatusSuppressed || statuses[AlertTypeMemoryHigh] != AlertStatusActive {
+		t.Errorf("statuses = %v, want cpu_high suppressed and memory_high active", statuses)
+	}
+}
+
+func TestSilenceAlertsSuppressesOpenAlerts(t *testing.T) {
+	c := newTestCollector(t)
+	appID, err := c.db.GetOrCreateApp("web")
+	if err != nil {
+		t.Fatalf("GetOrCreateApp() failed: %v", err)
+	}
+
+	alert := Alert{AppID: appID, Type: AlertTypeErrorRateHigh, Threshold: 5, CurrentValue: 9,
+		Severity: AlertSeverityWarning, Status: AlertStatusActive, Message: "errors", CreatedAt: time.Now()}
+	if err := c.createOrUpdateAlert(alert); err != nil {
+		t.Fatalf("createOrUpdateAlert() failed: %v", err)
+	}
+
+	if _, err := c.SilenceAlerts("web", AlertTypeErrorRateHigh, time.Hour); err != nil {
+		t.Fatalf("SilenceAlerts() failed: %v", err)
+	}
+	if got := countAlerts(t, c, appID, AlertStatusSuppressed); got != 1 {
+		t.Errorf("%d suppressed alerts, want 1", got)
+	}
+	if got := countAlerts(t, c, appID, AlertStatusActive); got != 0 {
+		t.Errorf("%d active alerts, want 0", got)
+	}
+}
+
+func TestExpiredSilences(t *testing.T) {
+	c := newTestCollector(t)
+	appID, err := c.db.GetOrCreateApp("web")
+	if err != nil {
+		t.Fatalf("GetOrCreateApp() failed: %v", err)
+	}
+
+	if _, err := c.SilenceAlerts("web", AlertTypeCPUHigh, time.Hour); err != nil {
+		t.Fatalf("SilenceAlerts() failed: %v", err)
+	}
+	alert := Alert{AppID: appID, Type: AlertTypeCPUHigh, Threshold: 80, CurrentValue: 95,
+		Severity: AlertSeverityWarning, Status: AlertStatusActive, Message: "CPU", CreatedAt: time.Now()}
+	if err := c.createOrUpdateAlert(alert); err != nil {
+		t.Fatalf("createOrUpdateAlert() failed: %v", err)
+	}
+
+	// End the silence
+	past := time.Now().Add(-time.Minute)
+	if _, err := c.db.GetConnection().Exec("UPDATE alert_silences SET silenced_until = ?", past); err != nil {
+		t.Fatalf("failed to end silence: %v", err)
+	}
+	if _, err := c.db.GetConnection().Exec("UPDATE alerts SET suppressed_until = ?", past); err != nil {
+		t.Fatalf("failed to end silence: %v", err)
+	}
+
+	if err := c.expireSilences(); err != nil {
+		t.Fatalf("expireSilences() failed: %v", err)
+	}
+	var silences int
+	if err := c.db.GetConnection().QueryRow("SELECT COUNT(*) FROM alert_silences").Scan(&silences); err != nil {
+		t.Fatalf("failed to count silences: %v", err)
+	}
+	if silences != 0 {
+		t.Errorf("%d silences left, want 0", silences)
+	}
+	if got := countAlerts(t, c, appID, AlertStatusResolved); got != 1 {
+		t.Errorf("%d resolved alerts, want 1", got)
+	}
+
+	// The alert fires again
+	if err := c.createOrUpdateAlert(alert); err != nil {
+		t.Fatalf("createOrUpdateAlert() failed: %v", err)
+	}
+	if got := countAlerts(t, c, appID, AlertStatusActive); got != 1 {
+		t.Errorf("%d active alerts, want 1", got)
+	}
+}
@@ -3,6 +3,7 @@ package monitoring
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 
@@ -13,10 +14,12 @@ import (
 
 // Collector handles metrics collection from Kubernetes
 type Collector struct {
-	db     *database.DB
-	k8s    *k8s.Client
-	ctx    context.Context
-	cancel context.CancelFunc
+	db       *database.DB
+	k8s      *k8s.Client
+	notifier *Notifier
+	scrapes  map[int64]map[string]podScrape // last scrape by app and pod
+	ctx      context.Context
+	cancel   context.CancelFunc
 }
 
 // NewCollector creates a new metrics collector
@@ -34,19 +37,27 @@ func NewCollector() (*Collector, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &Collector{
-		db:     db,
-		k8s:    k8sClient,
-		ctx:    ctx,
-		cancel: cancel,
+		db:       db,
+		k8s:      k8sClient,
+		notifier: NewNotifier(db),
+		scrapes:  make(map[int64]map[string]podScrape),
+		ctx:      ctx,
+		cancel:   cancel,
 	}, nil
 }
 
 // Close stops the collector and closes connections
 func (c *Collector) Close() error {
 	c.cancel()
+	c.notifier.Close()
 	return c.db.Close()
 }
 
+// Stop cancels the collector context, ending running watches
+func (c *Collector) Stop() {
+	c.cancel()
+}
+
 // CollectMetrics collects metrics for all or specific applications
 func (c *Collector) CollectMetrics(appName string) error {
 	// Get applications to monitor
@@ -76,6 +87,11 @@ func (c *Collector) collectAppMetrics(app App) error {
 		return fmt.Errorf("failed to collect deployment metrics: %w", err)
 	}
 
+	// Scrape request metrics exported by the app
+	if err := c.collectRequestMetrics(app); err != nil {
+		fmt.Printf("Warning: request metrics unavailable for %s: %v\n", app.Name, err)
+	}
+
 	// Perform health checks
 	if err := c.performHealthCheck(app); err != nil {
 		fmt.Printf("Warning: health check failed for %s: %v\n", app.Name, err)
@@ -92,13 +108,13 @@ func (c *Collector) collectAppMetrics(app App) error {
 // collectPodMetrics collects CPU and memory metrics from pods
 func (c *Collector) collectPodMetrics(app App) error {
 	// Get pods for the application
-	pods, err := c.k8s.GetPods(app.Name)
+	pods, err := c.k8s.GetPods(app.Name, app.Namespace)
 	if err != nil {
 		return fmt.Errorf("failed to get pods: %w", err)
 	}
 
 	// Get pod metrics from metrics-server
-	podMetrics, err := c.k8s.GetPodMetrics(app.Name)
+	podMetrics, err := c.k8s.GetPodMetrics(app.Name, app.Namespace)
 	if err != nil {
 		return fmt.Errorf("failed to get pod metrics: %w", err)
 	}
@@ -125,33 +141,25 @@ func (c *Collector) collectPodMetrics(app App) error {
 	}
 
 	// Store metrics for each pod
-	for _, podMetric := range podMetrics {
-		podName := podMetric.Name
-		
-		// CPU metrics
-		cpuUsage := podMetric.Containers[0].Usage.Cpu().MilliValue()
-		if err := c.storeMetric(Metric{
-			AppID:     app.ID,
-			Type:      MetricTypeCPU,
-			Value:     float64(cpuUsage),
-			Unit:      "millicores",
-			PodName:   podName,
-			Timestamp: now,
-		}); err != nil {
-			continue
+	for _, usage := range GetPodUsage(pods, podMetrics) {
+		metrics := []Metric{
+			{Type: MetricTypeCPU, Value: float64(usage.CPUMillicores), Unit: "millicores"},
+			{Type: MetricTypeMemory, Value: float64(usage.MemoryBytes), Unit: "bytes"},
+		}
+		if usage.HasCPUBound {
+			metrics = append(metrics, Metric{Type: MetricTypeCPUPercent, Value: usage.CPUPercent, Unit: "percent"})
+		}
+		if usage.HasMemoryBound {
+			metrics = append(metrics, Metric{Type: MetricTypeMemoryPercent, Value: usage.MemoryPercent, Unit: "percent"})
 		}
 
-		// Memory metrics
-		memoryUsage := podMetric.Containers[0].Usage.Memory().Value()
-		if err := c.storeMetric(Metric{
-			AppID:     app.ID,
-			Type:      MetricTypeMemory,
-			Value:     float64(memoryUsage),
-			Unit:      "bytes",
-			PodName:   podName,
-			Timestamp: now,
-		}); err != nil {
-			continue
+		for _, metric := range metrics {
+			metric.AppID = app.ID
+			metric.PodName = usage.PodName
+			metric.Timestamp = now
+			if err := c.storeMetric(metric); err != nil {
+				return fmt.Errorf("failed to store %s metric: %w", metric.Type, err)
+			}
 		}
 	}
 
@@ -160,7 +168,7 @@ func (c *Collector) collectPodMetrics(app App) error {
 
 // collectDeploymentMetrics collects deployment-level metrics
 func (c *Collector) collectDeploymentMetrics(app App) error {
-	deployment, err := c.k8s.GetDeployment(app.Name)
+	deployment, err := c.k8s.GetDeployment(app.Name, app.Namespace)
 	if err != nil {
 		return fmt.Errorf("failed to get deployment: %w", err)
 	}
@@ -203,7 +211,7 @@ func (c *Collector) performHealthCheck(app App) error {
 	}
 
 	// Get service endpoint
-	service, err := c.k8s.GetService(app.Name)
+	service, err := c.k8s.GetService(app.Name, app.Namespace)
 	if err != nil {
 		return fmt.Errorf("failed to get service: %w", err)
 	}
@@ -213,8 +221,9 @@ func (c *Collector) performHealthCheck(app App) error {
 	url := fmt.Sprintf("http://%s:%d%s", service.Spec.ClusterIP, port, config.HealthCheckPath)
 
 	// Perform health check
+	client := &http.Client{Timeout: time.Duration(config.HealthCheckTimeout) * time.Second}
 	start := time.Now()
-	resp, err := http.Get(url)
+	resp, err := client.Get(url)
 	responseTime := int(time.Since(start).Milliseconds())
 
 	healthCheck := HealthCheck{
@@ -227,6 +236,9 @@ func (c *Collector) performHealthCheck(app App) error {
 
 	if err != nil {
 		healthCheck.Status = HealthStatusError
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			healthCheck.Status = HealthStatusTimeout
+		}
 		healthCheck.ErrorMessage = err.Error()
 	} else {
 		defer resp.Body.Close()
@@ -255,53 +267,72 @@ func (c *Collector) checkAlerts(app App) error {
 		return err
 	}
 
-	// Check CPU threshold
-	if cpuMetric := c.getLatestMetric(metrics, MetricTypeCPU); cpuMetric != nil {
-		cpuPercent := (cpuMetric.Value / 1000) * 100 // Convert millicores to percentage
-		if cpuPercent > config.CPUThreshold {
-			alert := Alert{
-				AppID:        app.ID,
-				Type:         "cpu_high",
-				Threshold:    config.CPUThreshold,
-				CurrentValue: cpuPercent,
-				Severity:     AlertSeverityWarning,
-				Status:       AlertStatusActive,
-				Message:      fmt.Sprintf("CPU usage %.1f%% exceeds threshold %.1f%%", cpuPercent, config.CPUThreshold),
-				CreatedAt:    time.Now(),
-			}
-			if cpuPercent > config.CPUThreshold*1.2 {
-				alert.Severity = AlertSeverityCritical
-			}
-			c.createOrUpdateAlert(alert)
-		} else {
-			c.resolveAlert(app.ID, "cpu_high")
-		}
+	// Check CPU and memory thresholds against the busiest pod. Pods whose
+	// containers set no limits or requests have no percentage to check.
+	if cpuMetric := c.getHighestLatestMetric(metrics, MetricTypeCPUPercent); cpuMetric != nil {
+		c.checkUsageThreshold(app, AlertTypeCPUHigh, "CPU", *cpuMetric, config.CPUThreshold)
+	}
+	if memMetric := c.getHighestLatestMetric(metrics, MetricTypeMemoryPercent); memMetric != nil {
+		c.checkUsageThreshold(app, AlertTypeMemoryHigh, "Memory", *memMetric, config.MemoryThreshold)
 	}
 
-	// Check memory threshold
-	if memMetric := c.getLatestMetric(metrics, MetricTypeMemory); memMetric != nil {
-		// Note: This is a simplified calculation - in production you'd need pod resource limits
-		memoryMB := memMetric.Value / (1024 * 1024)
-		if memoryMB > 500 { // Example threshold
-			alert := Alert{
-				AppID:        app.ID,
-				Type:         "memory_high",
-				Threshold:    config.MemoryThreshold,
-				CurrentValue: memoryMB,
-				Severity:     AlertSeverityWarning,
-				Status:       AlertStatusActive,
-				Message:      fmt.Sprintf("Memory usage %.1fMB exceeds threshold", memoryMB),
-				CreatedAt:    time.Now(),
-			}
-			c.createOrUpdateAlert(alert)
-		} else {
-			c.resolveAlert(app.ID, "memory_high")
-		}
+	// Check request metrics scraped from the app
+	if errMetric := c.getLatestMetric(metrics, MetricTypeErrors); errMetric != nil {
+		c.checkThreshold(app, AlertTypeErrorRateHigh, errMetric.Value, config.ErrorRateThreshold,
+			errMetric.Value > config.ErrorRateThreshold*1.2,
+			fmt.Sprintf("Error rate %.1f%% exceeds threshold %.1f%%", errMetric.Value, config.ErrorRateThreshold))
+	}
+
+	// Response time is the 95th percentile, or the average without a histogram
+	latencyName := "P95 response time"
+	latencyMetric := c.getLatestMetric(metrics, MetricTypeLatencyP95)
+	if latencyMetric == nil {
+		latencyName = "Average response time"
+		latencyMetric = c.getLatestMetric(metrics, MetricTypeLatency)
+	}
+	if latencyMetric != nil {
+		threshold := float64(config.ResponseTimeThreshold)
+		c.checkThreshold(app, AlertTypeResponseTimeHigh, latencyMetric.Value, threshold,
+			latencyMetric.Value > threshold*1.2,
+			fmt.Sprintf("%s %.0fms exceeds %.0fms", latencyName, latencyMetric.Value, threshold))
 	}
 
 	return nil
 }
 
+// checkUsageThreshold fires or resolves a usage alert for a percentage of the
+// pod's limits. The alert is critical when usage is 20% above the threshold
+// or reaches the limit itself.
+func (c *Collector) checkUsageThreshold(app App, alertType, resourceName string, metric Metric, threshold float64) {
+	message := fmt.Sprintf("%s usage %.1f%% of limit exceeds threshold %.1f%% (pod %s)",
+		resourceName, metric.Value, threshold, metric.PodName)
+	critical := metric.Value > threshold*1.2 || metric.Value >= 100
+	c.checkThreshold(app, alertType, metric.Value, threshold, critical, message)
+}
+
+// checkThreshold fires an alert when value exceeds threshold, and resolves it otherwise
+func (c *Collector) checkThreshold(app App, alertType string, value, threshold float64, critical bool, message string) {
+	if value <= threshold {
+		c.resolveAlert(app.ID, alertType)
+		return
+	}
+
+	alert := Alert{
+		AppID:        app.ID,
+		Type:         alertType,
+		Threshold:    threshold,
+		CurrentValue: value,
+		Severity:     AlertSeverityWarning,
+		Status:       AlertStatusActive,
+		Message:      message,
+		CreatedAt:    time.Now(),
+	}
+	if critical {
+		alert.Severity = AlertSeverityCritical
+	}
+	c.createOrUpdateAlert(alert)
+}
+
 // Helper methods
 
 func (c *Collector) isPodReady(pod corev1.Pod) bool {
@@ -322,6 +353,23 @@ func (c *Collector) getLatestMetric(metrics []Metric, metricType MetricType) *Me
 	return nil
 }
 
+// getHighestLatestMetric returns the highest value of a metric type among the
+// pods of the latest collection
+func (c *Collector) getHighestLatestMetric(metrics []Metric, metricType MetricType) *Metric {
+	latest := c.getLatestMetric(metrics, metricType)
+	if latest == nil {
+		return nil
+	}
+
+	highest := *latest
+	for _, metric := range metrics {
+		if metric.Type == metricType && metric.Timestamp.Equal(latest.Timestamp) && metric.Value > highest.Value {
+			highest = metric
+		}
+	}
+	return &highest
+}
+
 // Database operations
 
 func (c *Collector) storeMetric(metric Metric) error {
@@ -359,10 +407,20 @@ func (c *Collector) storeHealthCheck(hc HealthCheck) error {
 }
 
 func (c *Collector) createOrUpdateAlert(alert Alert) error {
-	// Check if alert already exists
+	// End silences that have expired so the alert can fire again
+	if err := c.expireSilences(); err != nil {
+		return err
+	}
+
+	// Check if alert already exists (a silenced alert is only updated)
 	var existingID int64
-	query := `SELECT id FROM alerts WHERE app_id = ? AND alert_type = ? AND status = 'active'`
-	err := c.db.GetConnection().QueryRow(query, alert.AppID, alert.Type).Scan(&existingID)
+	var existingSeverity, existingStatus string
+	var existingCreatedAt time.Time
+	query := `SELECT id, severity, status, created_at FROM alerts
+		WHERE app_id = ? AND alert_type = ? AND status IN ('active', 'suppressed')
+		ORDER BY CASE status WHEN 'suppressed' THEN 0 ELSE 1 END LIMIT 1`
+	err := c.db.GetConnection().QueryRow(query, alert.AppID, alert.Type).Scan(
+		&existingID, &existingSeverity, &existingStatus, &existingCreatedAt)
 
 	if err == nil {
 		// Update existing alert
@@ -376,15 +434,42 @@ func (c *Collector) createOrUpdateAlert(alert Alert) error {
 			string(alert.Severity),
 			existingID,
 		)
+		if err != nil {
+			return err
+		}
+
+		// A change of severity of an active alert is news, such as a warning
+		// becoming critical
+		previous := AlertSeverity(existingSeverity)
+		if existingStatus == string(AlertStatusActive) && previous != alert.Severity {
+			alert.ID = existingID
+			alert.Status = AlertStatusActive
+			alert.CreatedAt = existingCreatedAt
+			event := NotificationEscalated
+			if severityRank(alert.Severity) < severityRank(previous) {
+				event = NotificationDeescalated
+			}
+			c.notifyAlert(event, alert)
+		}
+		return nil
+	}
+
+	// A new alert of a silenced type is recorded without firing
+	silencedUntil, err := c.silencedUntil(alert.AppID, alert.Type)
+	if err != nil {
 		return err
 	}
+	if silencedUntil != nil {
+		alert.Status = AlertStatusSuppressed
+		alert.SuppressedUntil = silencedUntil
+	}
 
 	// Create new alert
 	insertQuery := `
-		INSERT INTO alerts (app_id, alert_type, threshold, current_value, severity, status, message, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+		INSERT INTO alerts (app_id, alert_type, threshold, current_value, severity, status, message, created_at, suppressed_until)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	_, err = c.db.GetConnection().Exec(insertQuery,
+	result, err := c.db.GetConnection().Exec(insertQuery,
 		alert.AppID,
 		alert.Type,
 		alert.Threshold,
@@ -393,18 +478,61 @@ func (c *Collector) createOrUpdateAlert(alert Alert) error {
 		string(alert.Status),
 		alert.Message,
 		alert.CreatedAt,
+		alert.SuppressedUntil,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	if alert.Status == AlertStatusActive {
+		alert.ID, _ = result.LastInsertId()
+		c.notifyAlert(NotificationOpened, alert)
+	}
+	return nil
 }
 
 func (c *Collector) resolveAlert(appID int64, alertType string) error {
+	// Find the alerts to resolve so they can be notified
+	open, err := c.queryAlerts("al.app_id = ? AND al.alert_type = ? AND al.status = 'active'",
+		"", []interface{}{appID, alertType}, alertsBySeverity)
+	if err != nil {
+		return err
+	}
+	if len(open) == 0 {
+		return nil
+	}
+
+	now := time.Now()
 	query := `
 		UPDATE alerts 
-		SET status = 'resolved', resolved_at = CURRENT_TIMESTAMP
+		SET status = 'resolved', resolved_at = ?
 		WHERE app_id = ? AND alert_type = ? AND status = 'active'`
 
-	_, err := c.db.GetConnection().Exec(query, appID, alertType)
-	return err
+	if _, err := c.db.GetConnection().Exec(query, now, appID, alertType); err != nil {
+		return err
+	}
+
+	for _, alert := range open {
+		alert.Status = AlertStatusResolved
+		alert.ResolvedAt = &now
+		c.notifyAlert(NotificationResolved, alert.Alert)
+	}
+	return nil
+}
+
+// notifyAlert sends a notification about an alert of one of the monitored apps
+func (c *Collector) notifyAlert(event NotificationEvent, alert Alert) {
+	var appName string
+	if err := c.db.GetConnection().QueryRow("SELECT name FROM apps WHERE id = ?", alert.AppID).Scan(&appName); err != nil {
+		fmt.Printf("Warning: failed to notify alert %d: %v\n", alert.ID, err)
+		return
+	}
+	c.notifier.Notify(event, appName, alert)
+}
+
+// Notifier returns the alert notifier (exported method)
+func (c *Collector) Notifier() *Notifier {
+	return c.notifier
 }
 
 func (c *Collector) getRecentMetrics(appID int64, duration time.Duration) ([]Metric, error) {
@@ -526,8 +654,9 @@ func (c *Collector) createMonitoringConfig(config *MonitoringConfig) error {
 
 // App represents an application for monitoring
 type App struct {
-	ID   int64  `db:"id"`
-	Name string `db:"name"`
+	ID        int64  `db:"id"`
+	Name      string `db:"name"`
+	Namespace string // namespace of its latest deployment
 }
 
 // GetAppsToMonitor returns apps to monitor (exported method)
@@ -556,30 +685,19 @@ func (c *Collector) GetTotalActiveAlerts() (int, error) {
 	return count, err
 }
 
+// getAppsToMonitor returns every app, or the app of that name, with the
+// namespace it is deployed to
 func (c *Collector) getAppsToMonitor(appName string) ([]App, error) {
-	var query string
-	var args []interface{}
-
-	if appName != "" {
-		query = "SELECT id, name FROM apps WHERE name = ?"
-		args = []interface{}{appName}
-	} else {
-		query = "SELECT id, name FROM apps"
-	}
-
-	rows, err := c.db.GetConnection().Query(query, args...)
+	deployed, err := loadDeployedApps(c.db)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
 	var apps []App
-	for rows.Next() {
-		var app App
-		if err := rows.Scan(&app.ID, &app.Name); err != nil {
-			continue
+	for _, app := range deployed {
+		if appName == "" || app.Name == appName {
+			apps = append(apps, App{ID: app.ID, Name: app.Name, Namespace: app.Namespace})
 		}
-		apps = append(apps, app)
 	}
 
 	return apps, nil
@@ -0,0 +1,217 @@
+package monitoring
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shipyard/cli/pkg/manifests"
+)
+
+// MonitoringConfigUpdate holds the monitoring settings to change; nil fields are left unchanged
+type MonitoringConfigUpdate struct {
+	Enabled               *bool
+	HealthCheckPath       *string
+	HealthCheckInterval   *int // seconds
+	HealthCheckTimeout    *int // seconds
+	CPUThreshold          *float64
+	MemoryThreshold       *float64
+	ErrorRateThreshold    *float64
+	ResponseTimeThreshold *int // milliseconds
+	MetricsEnabled        *bool
+	MetricsPath           *string
+	MetricsPort           *int
+}
+
+// NewMonitoringConfigUpdate converts the monitoring block of paas.yaml to an update
+func NewMonitoringConfigUpdate(config *manifests.MonitoringConfig) (MonitoringConfigUpdate, error) {
+	var update MonitoringConfigUpdate
+	if config == nil {
+		return update, nil
+	}
+
+	update.Enabled = config.Enabled
+	if config.HealthPath != "" {
+		update.HealthCheckPath = &config.HealthPath
+	}
+	if config.Interval != "" {
+		seconds, err := parseSeconds(config.Interval)
+		if err != nil {
+			return update, fmt.Errorf("invalid monitoring.interval: %w", err)
+		}
+		update.HealthCheckInterval = &seconds
+	}
+	if config.Timeout != "" {
+		seconds, err := parseSeconds(config.Timeout)
+		if err != nil {
+			return update, fmt.Errorf("invalid monitoring.timeout: %w", err)
+		}
+		update.HealthCheckTimeout = &seconds
+	}
+	if config.CPU != 0 {
+		update.CPUThreshold = &config.CPU
+	}
+	if config.Memory != 0 {
+		update.MemoryThreshold = &config.Memory
+	}
+	if config.ErrorRate != 0 {
+		update.ErrorRateThreshold = &config.ErrorRate
+	}
+	if config.ResponseTime != 0 {
+		update.ResponseTimeThreshold = &config.ResponseTime
+	}
+	update.MetricsEnabled = config.Metrics
+	if config.MetricsPath != "" {
+		update.MetricsPath = &config.MetricsPath
+	}
+	if config.MetricsPort != 0 {
+		update.MetricsPort = &config.MetricsPort
+	}
+
+	return update, nil
+}
+
+// GetMonitoringConfig returns the monitoring configuration of an app,
+// creating the default one if needed
+func (c *Collector) GetMonitoringConfig(appName string) (*MonitoringConfig, error) {
+	appID, err := c.getAppID(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.getMonitoringConfig(appID)
+}
+
+// UpdateMonitoringConfig applies an update to the monitoring configuration of an app
+func (c *Collector) UpdateMonitoringConfig(appName string, update MonitoringConfigUpdate) (*MonitoringConfig, error) {
+	appID, err := c.getAppID(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := c.getMonitoringConfig(appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get monitoring config: %w", err)
+	}
+
+	if update.Enabled != nil {
+		config.Enabled = *update.Enabled
+	}
+	if update.HealthCheckPath != nil {
+		config.HealthCheckPath = *update.HealthCheckPath
+	}
+	if update.HealthCheckInterval != nil {
+		config.HealthCheckInterval = *update.HealthCheckInterval
+	}
+	if update.HealthCheckTimeout != nil {
+		config.HealthCheckTimeout = *update.HealthCheckTimeout
+	}
+	if update.CPUThreshold != nil {
+		config.CPUThreshold = *update.CPUThreshold
+	}
+	if update.MemoryThreshold != nil {
+		config.MemoryThreshold = *update.MemoryThreshold
+	}
+	if update.ErrorRateThreshold != nil {
+		config.ErrorRateThreshold = *update.ErrorRateThreshold
+	}
+	if update.ResponseTimeThreshold != nil {
+		config.ResponseTimeThreshold = *update.ResponseTimeThreshold
+	}
+	if update.MetricsEnabled != nil {
+		config.MetricsEnabled = *update.MetricsEnabled
+	}
+	if update.MetricsPath != nil {
+		config.MetricsPath = *update.MetricsPath
+	}
+	if update.MetricsPort != nil {
+		config.MetricsPort = *update.MetricsPort
+	}
+
+	if err := validateMonitoringConfig(config); err != nil {
+		return nil, err
+	}
+
+	config.UpdatedAt = time.Now()
+	query := `
+		UPDATE monitoring_config
+		SET enabled = ?, health_check_path = ?, health_check_interval = ?, health_check_timeout = ?,
+		    cpu_threshold = ?, memory_threshold = ?, error_rate_threshold = ?, response_time_threshold = ?,
+		    metrics_enabled = ?, metrics_path = ?, metrics_port = ?, updated_at = ?
+		WHERE app_id = ?`
+
+	_, err = c.db.GetConnection().Exec(query,
+		config.Enabled,
+		config.HealthCheckPath,
+		config.HealthCheckInterval,
+		config.HealthCheckTimeout,
+		config.CPUThreshold,
+		config.MemoryThreshold,
+		config.ErrorRateThreshold,
+		config.ResponseTimeThreshold,
+		config.MetricsEnabled,
+		config.MetricsPath,
+		config.MetricsPort,
+		config.UpdatedAt,
+		appID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update monitoring config: %w", err)
+	}
+
+	return config, nil
+}
+
+func (c *Collector) getAppID(appName string) (int64, error) {
+	apps, err := c.getAppsToMonitor(appName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get app: %w", err)
+	}
+	if len(apps) == 0 {
+		return 0, fmt.Errorf("app %s not found", appName)
+	}
+	return apps[0].ID, nil
+}
+
+func validateMonitoringConfig(config *MonitoringConfig) error {
+	if !strings.HasPrefix(config.HealthCheckPath, "/") {
+		return fmt.Errorf("health check path must start with /: %s", config.HealthCheckPath)
+	}
+	if config.HealthCheckInterval < 1 {
+		return fmt.Errorf("health check interval must be at least 1s")
+	}
+	if config.HealthCheckTimeout < 1 {
+		return fmt.Errorf("health check timeout must be at least 1s")
+	}
+	if config.CPUThreshold <= 0 || config.CPUThreshold > 100 {
+		return fmt.Errorf("CPU threshold must be between 0 and 100%%: %.1f", config.CPUThreshold)
+	}
+	if config.MemoryThreshold <= 0 || config.MemoryThreshold > 100 {
+		return fmt.Errorf("memory threshold must be between 0 and 100%%: %.1f", config.MemoryThreshold)
+	}
+	if config.ErrorRateThreshold <= 0 || config.ErrorRateThreshold > 100 {
+		return fmt.Errorf("error rate threshold must be between 0 and 100%%: %.1f", config.ErrorRateThreshold)
+	}
+	if config.ResponseTimeThreshold <= 0 {
+		return fmt.Errorf("response time threshold must be positive: %dms", config.ResponseTimeThreshold)
+	}
+	if !strings.HasPrefix(config.MetricsPath, "/") {
+		return fmt.Errorf("metrics path must start with /: %s", config.MetricsPath)
+	}
+	if config.MetricsPort < 1 || config.MetricsPort > 65535 {
+		return fmt.Errorf("metrics port must be between 1 and 65535: %d", config.MetricsPort)
+	}
+	return nil
+}
+
+// parseSeconds parses a duration such as 15s or 1m into whole seconds
+func parseSeconds(value string) (int, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if duration < time.Second {
+		return 0, fmt.Errorf("%s is shorter than 1s", value)
+	}
+	return int(duration / time.Second), nil
+}
@@ -0,0 +1,436 @@
+package monitoring
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shipyard/cli/pkg/database"
+	"github.com/shipyard/cli/pkg/manifests"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	// eventRetryDelay is how long to wait before watching events again after an error
+	eventRetryDelay = 5 * time.Second
+	// deployedAppsReload is how often unknown namespaces may trigger a reload of the apps
+	deployedAppsReload = 30 * time.Second
+)
+
+// AppEvent is a stored event together with the name of its app
+type AppEvent struct {
+	AppName string `json:"app_name"` // empty for cluster-wide events
+	Event
+}
+
+// deployedApp is an app and the namespace it is deployed to
+type deployedApp struct {
+	ID        int64
+	Name      string
+	DNSName   string
+	Namespace string
+}
+
+// eventIngester maps Kubernetes events to apps and stores them in the events table
+type eventIngester struct {
+	c        *Collector
+	apps     map[string][]deployedApp // by namespace
+	loadedAt time.Time
+}
+
+// IngestEvents stores the current Kubernetes events of all namespaces and
+// returns how many new occurrences were stored
+func (c *Collector) IngestEvents() (int, error) {
+	ingester, err := c.newEventIngester()
+	if err != nil {
+		return 0, err
+	}
+
+	_, stored, err := ingester.ingestList()
+	return stored, err
+}
+
+// WatchEvents stores Kubernetes events as they happen and calls handler with
+// each new occurrence, until the collector is stopped
+func (c *Collector) WatchEvents(handler func(AppEvent)) error {
+	ingester, err := c.newEventIngester()
+	if err != nil {
+		return err
+	}
+
+	// Events that happened before the watch are stored but not handled
+	resourceVersion, _, err := ingester.ingestList()
+	if err != nil {
+		return err
+	}
+
+	for c.ctx.Err() == nil {
+		if resourceVersion == "" {
+			resourceVersion, _, err = ingester.ingestList()
+			if err != nil {
+				fmt.Printf("Warning: %v\n", err)
+				c.sleep(eventRetryDelay)
+				continue
+			}
+		}
+
+		w, err := c.k8s.WatchEvents(c.ctx, "", resourceVersion)
+		if err != nil {
+			if c.ctx.Err() != nil {
+				break
+			}
+			fmt.Printf("Warning: failed to watch events: %v\n", err)
+			resourceVersion = ""
+			c.sleep(eventRetryDelay)
+			continue
+		}
+
+		resourceVersion = ingester.consume(w, resourceVersion, handler)
+	}
+
+	return nil
+}
+
+// GetStoredEvents returns the stored events seen during the last period,
+// most recent first. eventType (normal or warning) is optional.
+func (c *Collector) GetStoredEvents(appName string, since time.Duration, eventType string) ([]AppEvent, error) {
+	query := `
+		SELECT e.id, e.app_id, COALESCE(a.name, ''), e.event_type, e.reason, e.message,
+		       COALESCE(e.object_kind, ''), COALESCE(e.object_name, ''),
+		       e.first_timestamp, e.last_timestamp, e.count, e.created_at
+		FROM events e
+		LEFT JOIN apps a ON a.id = e.app_id
+		WHERE e.last_timestamp > ?`
+
+	args := []interface{}{time.Now().Add(-since)}
+	if appName != "" {
+		query += " AND a.name = ?"
+		args = append(args, appName)
+	}
+	if eventType != "" {
+		query += " AND LOWER(e.event_type) = ?"
+		args = append(args, strings.ToLower(eventType))
+	}
+	query += " ORDER BY e.last_timestamp DESC, e.id DESC"
+
+	rows, err := c.db.GetConnection().Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []AppEvent
+	for rows.Next() {
+		var event AppEvent
+
+		err := rows.Scan(
+			&event.ID,
+			&event.AppID,
+			&event.AppName,
+			&event.Type,
+			&event.Reason,
+			&event.Message,
+			&event.ObjectKind,
+			&event.ObjectName,
+			&event.FirstTimestamp,
+			&event.LastTimestamp,
+			&event.Count,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event row: %w", err)
+		}
+
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating event rows: %w", err)
+	}
+
+	return events, nil
+}
+
+func (c *Collector) newEventIngester() (*eventIngester, error) {
+	ingester := &eventIngester{c: c}
+	if err := ingester.loadApps(); err != nil {
+		return nil, err
+	}
+	return ingester, nil
+}
+
+// loadApps loads the namespace of every app
+func (i *eventIngester) loadApps() error {
+	deployed, err := loadDeployedApps(i.c.db)
+	if err != nil {
+		return err
+	}
+
+	apps := make(map[string][]deployedApp)
+	for _, app := range deployed {
+		apps[app.Namespace] = append(apps[app.Namespace], app)
+	}
+
+	i.apps = apps
+	i.loadedAt = time.Now()
+	return nil
+}
+
+// loadDeployedApps loads the namespace of every app from its latest
+// deployment, or for apps discovered in the cluster by the agent, from the
+// cluster
+func loadDeployedApps(db *database.DB) ([]deployedApp, error) {
+	query := `
+		SELECT a.id, a.name, COALESCE((
+			SELECT json_extract(d.config_json, '$.App.Namespace')
+			FROM deployments d
+			WHERE d.app_id = a.id
+			ORDER BY d.deployed_at DESC, d.id DESC
+			LIMIT 1
+		), a.namespace)
+		FROM apps a`
+
+	rows, err := db.GetConnection().Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query apps: %w", err)
+	}
+	defer rows.Close()
+
+	var apps []deployedApp
+	for rows.Next() {
+		var app deployedApp
+		var namespace *string
+		if err := rows.Scan(&app.ID, &app.Name, &namespace); err != nil {
+			return nil, fmt.Errorf("failed to scan app row: %w", err)
+		}
+
+		appConfig := manifests.AppConfig{Name: app.Name}
+		if namespace != nil {
+			appConfig.Namespace = *namespace
+		}
+		app.DNSName = appConfig.GetDNSName()
+		app.Namespace = appConfig.GetNamespace()
+
+		apps = append(apps, app)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating app rows: %w", err)
+	}
+
+	return apps, nil
+}
+
+// appFor returns the app an event belongs to. Node events are cluster-wide
+// (nil app); events of other namespaces are not relevant.
+func (i *eventIngester) appFor(event *corev1.Event) (*deployedApp, bool) {
+	if event.InvolvedObject.Kind == "Node" {
+		return nil, true
+	}
+
+	apps := i.apps[event.Namespace]
+	if len(apps) == 0 && time.Since(i.loadedAt) > deployedAppsReload {
+		// The app may have been deployed after the apps were loaded
+		if err := i.loadApps(); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+		apps = i.apps[event.Namespace]
+	}
+
+	switch len(apps) {
+	case 0:
+		return nil, false
+	case 1:
+		return &apps[0], true
+	}
+
+	// Several apps share the namespace: match the object name (web, web-7d4b9c8f5c-xyz12)
+	var match *deployedApp
+	name := event.InvolvedObject.Name
+	for j := range apps {
+		app := &apps[j]
+		if name != app.DNSName && !strings.HasPrefix(name, app.DNSName+"-") {
+			continue
+		}
+		if match == nil || len(app.DNSName) > len(match.DNSName) {
+			match = app
+		}
+	}
+
+	return match, match != nil
+}
+
+// ingestList stores the current events and returns the resource version to
+// watch from, with how many new occurrences were stored
+func (i *eventIngester) ingestList() (string, int, error) {
+	list, err := i.c.k8s.GetEvents("")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	stored := 0
+	for j := range list.Items {
+		_, isNew, err := i.store(&list.Items[j])
+		if err != nil {
+			return "", stored, err
+		}
+		if isNew {
+			stored++
+		}
+	}
+
+	return list.ResourceVersion, stored, nil
+}
+
+// consume stores the events of a watch until it ends and returns the
+// resource version to watch from next ("" when a new list is needed)
+func (i *eventIngester) consume(w watch.Interface, resourceVersion string, handler func(AppEvent)) string {
+	defer w.Stop()
+
+	for {
+		select {
+		case <-i.c.ctx.Done():
+			return resourceVersion
+		case result, ok := <-w.ResultChan():
+			if !ok {
+				return resourceVersion
+			}
+
+			switch result.Type {
+			case watch.Added, watch.Modified:
+				event, ok := result.Object.(*corev1.Event)
+				if !ok {
+					continue
+				}
+				resourceVersion = event.ResourceVersion
+
+				stored, isNew, err := i.store(event)
+				if err != nil {
+					fmt.Printf("Warning: %v\n", err)
+					continue
+				}
+				if isNew && handler != nil {
+					handler(stored)
+				}
+			case watch.Bookmark:
+				if event, ok := result.Object.(*corev1.Event); ok {
+					resourceVersion = event.ResourceVersion
+				}
+			case watch.Error:
+				// Usually an expired resource version: list again
+				return ""
+			}
+		}
+	}
+}
+
+// store saves a Kubernetes event. Events are deduplicated by involved object,
+// reason and count: a higher count updates the stored occurrence, the same
+// count is a duplicate. It reports whether a new occurrence was stored.
+func (i *eventIngester) store(event *corev1.Event) (AppEvent, bool, error) {
+	app, relevant := i.appFor(event)
+	if !relevant {
+		return AppEvent{}, false, nil
+	}
+
+	stored := AppEvent{
+		Event: Event{
+			Type:       event.Type,
+			Reason:     event.Reason,
+			Message:    event.Message,
+			ObjectKind: event.InvolvedObject.Kind,
+			ObjectName: event.InvolvedObject.Name,
+			Count:      eventCount(event),
+			CreatedAt:  time.Now(),
+		},
+	}
+	if app != nil {
+		stored.AppID = &app.ID
+		stored.AppName = app.Name
+	}
+	stored.FirstTimestamp, stored.LastTimestamp = eventTimestamps(event)
+
+	var existingID int64
+	var existingCount int
+	err := i.c.db.GetConnection().QueryRow(`
+		SELECT id, count FROM events
+		WHERE app_id IS ? AND object_kind = ? AND object_name = ? AND reason = ?
+		ORDER BY id DESC LIMIT 1`,
+		stored.AppID, stored.ObjectKind, stored.ObjectName, stored.Reason,
+	).Scan(&existingID, &existingCount)
+
+	switch {
+	case err != nil && err != sql.ErrNoRows:
+		return stored, false, fmt.Errorf("failed to check existing event: %w", err)
+	case err == nil && existingCount == stored.Count:
+		return stored, false, nil
+	case err == nil && existingCount < stored.Count:
+		// The same event happened again
+		_, err = i.c.db.GetConnection().Exec(`
+			UPDATE events SET count = ?, message = ?, last_timestamp = ? WHERE id = ?`,
+			stored.Count, stored.Message, stored.LastTimestamp, existingID)
+		if err != nil {
+			return stored, false, fmt.Errorf("failed to update event: %w", err)
+		}
+		stored.ID = existingID
+		return stored, true, nil
+	}
+
+	// New event, or a new series of an event that had expired
+	result, err := i.c.db.GetConnection().Exec(`
+		INSERT INTO events (app_id, event_type, reason, message, object_kind, object_name,
+		                    first_timestamp, last_timestamp, count, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		stored.AppID, stored.Type, stored.Reason, stored.Message, stored.ObjectKind, stored.ObjectName,
+		stored.FirstTimestamp, stored.LastTimestamp, stored.Count, stored.CreatedAt)
+	if err != nil {
+		return stored, false, fmt.Errorf("failed to store event: %w", err)
+	}
+	stored.ID, _ = result.LastInsertId()
+
+	return stored, true, nil
+}
+
+// eventCount returns how many times an event happened
+func eventCount(event *corev1.Event) int {
+	count := int(event.Count)
+	if event.Series != nil && int(event.Series.Count) > count {
+		count = int(event.Series.Count)
+	}
+	if count < 1 {
+		count = 1
+	}
+	return count
+}
+
+// eventTimestamps returns when an event happened first and last. Events
+// created through the events.k8s.io API only set the event time.
+func eventTimestamps(event *corev1.Event) (time.Time, time.Time) {
+	first := event.FirstTimestamp.Time
+	if first.IsZero() {
+		first = event.EventTime.Time
+	}
+	if first.IsZero() {
+		first = event.CreationTimestamp.Time
+	}
+
+	last := event.LastTimestamp.Time
+	if last.IsZero() && event.Series != nil {
+		last = event.Series.LastObservedTime.Time
+	}
+	if last.IsZero() {
+		last = first
+	}
+
+	return first.Local(), last.Local()
+}
+
+// sleep waits for d or until the collector is stopped
+func (c *Collector) sleep(d time.Duration) {
+	select {
+	case <-c.ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -0,0 +1,59 @@
+package monitoring
+
+import (
+	"testing"
+)
+
+func TestLoadDeployedAppsNamespace(t *testing.T) {
+	db := newTestDB(t)
+	conn := db.GetConnection()
+
+	deployedID, err := db.GetOrCreateApp("Shop_App")
+	if err != nil {
+		t.Fatalf("GetOrCreateApp() failed: %v", err)
+	}
+	_, err = conn.Exec(`INSERT INTO deployments
+		(app_id, version, image, image_tag, image_hash, config_json, config_hash, status)
+		VALUES (?, 'v1', 'shop:1', '1', 'h', '{"App": {"Name": "Shop_App", "Namespace": "store"}}', 'c', 'success')`,
+		deployedID)
+	if err != nil {
+		t.Fatalf("failed to insert deployment: %v", err)
+	}
+	// The namespace of the latest deployment wins over the one of the cluster
+	if _, err := conn.Exec("UPDATE apps SET namespace = 'elsewhere' WHERE id = ?", deployedID); err != nil {
+		t.Fatalf("failed to set namespace: %v", err)
+	}
+
+	discoveredID, err := db.GetOrCreateApp("blog")
+	if err != nil {
+		t.Fatalf("GetOrCreateApp() failed: %v", err)
+	}
+	if _, err := conn.Exec("UPDATE apps SET namespace = 'content' WHERE id = ?", discoveredID); err != nil {
+		t.Fatalf("failed to set namespace: %v", err)
+	}
+
+	if _, err := db.GetOrCreateApp("api"); err != nil {
+		t.Fatalf("GetOrCreateApp() failed: %v", err)
+	}
+
+	apps, err := loadDeployedApps(db)
+	if err != nil {
+		t.Fatalf("loadDeployedApps() failed: %v", err)
+	}
+
+	want := map[string]deployedApp{
+		"Shop_App": {ID: deployedID, Name: "Shop_App", DNSName: "shop-app", Namespace: "store"},
+		"blog":     {ID: discoveredID, Name: "blog", DNSName: "blog", Namespace: "content"},
+		"api":      {Name: "api", DNSName: "api", Namespace: "api"},
+	}
+	if len(apps) != len(want) {
+		t.Fatalf("loaded %d apps, want %d: %+v", len(apps), len(want), apps)
+	}
+	for _, app := range apps {
+		expected := want[app.Name]
+		expected.ID = app.ID
+		if app != expected {
+			t.Errorf("loaded %+v, want %+v", app, expected)
+		}
+	}
+}
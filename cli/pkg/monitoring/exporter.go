@@ -0,0 +1,351 @@
+package monitoring
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shipyard/cli/pkg/database"
+)
+
+// exportedMetrics maps stored metric types to Prometheus metric names. Latency
+// percentiles are exported as a quantile label of shipyard_latency_milliseconds.
+var exportedMetrics = map[MetricType]struct {
+	Name string
+	Help string
+}{
+	MetricTypeCPU:           {"shipyard_cpu_millicores", "CPU usage of the pod in millicores."},
+	MetricTypeMemory:        {"shipyard_memory_bytes", "Memory usage of the pod in bytes."},
+	MetricTypeCPUPercent:    {"shipyard_cpu_limit_percent", "CPU usage of the pod as a percentage of its limits (or requests)."},
+	MetricTypeMemoryPercent: {"shipyard_memory_limit_percent", "Memory usage of the pod as a percentage of its limits (or requests)."},
+	MetricTypePods:          {"shipyard_pods", "Number of pods of the app."},
+	MetricTypeRequests:      {"shipyard_requests_per_second", "HTTP requests handled per second."},
+	MetricTypeErrors:        {"shipyard_error_rate_percent", "Percentage of HTTP requests answered with a 5xx status."},
+	MetricTypeLatency:       {"shipyard_latency_average_milliseconds", "Average HTTP request latency in milliseconds."},
+	"replicas_desired":      {"shipyard_replicas_desired", "Replicas requested by the deployment."},
+	"replicas_ready":        {"shipyard_replicas_ready", "Ready replicas of the deployment."},
+}
+
+// latencyQuantiles are the quantiles of the stored latency percentiles
+var latencyQuantiles = map[MetricType]string{
+	MetricTypeLatencyP50: "0.5",
+	MetricTypeLatencyP95: "0.95",
+	MetricTypeLatencyP99: "0.99",
+}
+
+// Exporter exposes the collected data in the Prometheus text format
+type Exporter struct {
+	db *database.DB
+}
+
+// NewExporter creates an exporter reading the data stored in db
+func NewExporter(db *database.DB) *Exporter {
+	return &Exporter{db: db}
+}
+
+// Exporter returns an exporter of the collected data (exported method)
+func (c *Collector) Exporter() *Exporter {
+	return NewExporter(c.db)
+}
+
+// promFamily is a Prometheus metric family being written
+type promFamily struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []promSample
+}
+
+// ServeHTTP writes the exposition on GET /metrics
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if err := e.Write(&buf); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// Write writes the latest metrics, deployment counts, active alerts and
+// health check results of every app in the Prometheus text format
+func (e *Exporter) Write(w io.Writer) error {
+	apps, err := loadDeployedApps(e.db)
+	if err != nil {
+		return err
+	}
+	namespaces := make(map[string]string, len(apps))
+	for _, app := range apps {
+		namespaces[app.Name] = app.Namespace
+	}
+
+	var families []*promFamily
+	for _, collect := range []func(map[string]string) ([]*promFamily, error){
+		e.latestMetrics,
+		e.deploymentCounts,
+		e.activeAlerts,
+		e.healthChecks,
+	} {
+		collected, err := collect(namespaces)
+		if err != nil {
+			return err
+		}
+		families = append(families, collected...)
+	}
+
+	for _, family := range families {
+		if err := writeFamily(w, family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// latestMetrics exports the metrics of the latest collection of each app
+func (e *Exporter) latestMetrics(namespaces map[string]string) ([]*promFamily, error) {
+	rows, err := e.db.GetConnection().Query(`
+		SELECT app_name, metric_type, value, COALESCE(pod_name, ''), timestamp
+		FROM latest_metrics`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query latest metrics: %w", err)
+	}
+	defer rows.Close()
+
+	type row struct {
+		App, Type, Pod string
+		Value          float64
+		Timestamp      time.Time
+	}
+
+	// Only the rows of the latest collection of each app and type are kept,
+	// so that pods that are gone are not exported
+	latest := make(map[string]time.Time)
+	var collected []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.App, &r.Type, &r.Value, &r.Pod, &r.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan metric row: %w", err)
+		}
+		key := r.App + "/" + r.Type
+		if r.Timestamp.After(latest[key]) {
+			latest[key] = r.Timestamp
+		}
+		collected = append(collected, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating metric rows: %w", err)
+	}
+
+	families := make(map[string]*promFamily)
+	family := func(name, help string) *promFamily {
+		if families[name] == nil {
+			families[name] = &promFamily{Name: name, Help: help, Type: "gauge"}
+		}
+		return families[name]
+	}
+
+	for _, r := range collected {
+		if !r.Timestamp.Equal(latest[r.App+"/"+r.Type]) {
+			continue
+		}
+
+		labels := appLabels(r.App, namespaces)
+		if r.Pod != "" {
+			labels["pod"] = r.Pod
+		}
+
+		metricType := MetricType(r.Type)
+		if quantile, ok := latencyQuantiles[metricType]; ok {
+			labels["quantile"] = quantile
+			f := family("shipyard_latency_milliseconds", "HTTP request latency percentiles in milliseconds.")
+			f.Samples = append(f.Samples, promSample{Labels: labels, Value: r.Value})
+			continue
+		}
+
+		exported, ok := exportedMetrics[metricType]
+		if !ok {
+			exported.Name = "shipyard_" + sanitizeMetricName(r.Type)
+			exported.Help = fmt.Sprintf("Latest %s metric collected by shipyard.", r.Type)
+		}
+		f := family(exported.Name, exported.Help)
+		f.Samples = append(f.Samples, promSample{Labels: labels, Value: r.Value})
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]*promFamily, 0, len(names))
+	for _, name := range names {
+		result = append(result, families[name])
+	}
+	return result, nil
+}
+
+// deploymentCounts exports the number of deployments of each app by status
+func (e *Exporter) deploymentCounts(namespaces map[string]string) ([]*promFamily, error) {
+	rows, err := e.db.GetConnection().Query(`
+		SELECT a.name, d.status, COUNT(*)
+		FROM deployments d
+		JOIN apps a ON a.id = d.app_id
+		GROUP BY a.name, d.status
+		ORDER BY a.name, d.status`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deployments: %w", err)
+	}
+	defer rows.Close()
+
+	family := &promFamily{Name: "shipyard_deployments", Help: "Number of deployments by status.", Type: "gauge"}
+	for rows.Next() {
+		var app, status string
+		var count int
+		if err := rows.Scan(&app, &status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan deployment row: %w", err)
+		}
+		labels := appLabels(app, namespaces)
+		labels["status"] = status
+		family.Samples = append(family.Samples, promSample{Labels: labels, Value: float64(count)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deployment rows: %w", err)
+	}
+
+	return []*promFamily{family}, nil
+}
+
+// activeAlerts exports the number of active alerts of each app by type and severity
+func (e *Exporter) activeAlerts(namespaces map[string]string) ([]*promFamily, error) {
+	rows, err := e.db.GetConnection().Query(`
+		SELECT app_name, alert_type, severity, COUNT(*)
+		FROM active_alerts
+		GROUP BY app_name, alert_type, severity
+		ORDER BY app_name, alert_type, severity`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active alerts: %w", err)
+	}
+	defer rows.Close()
+
+	family := &promFamily{Name: "shipyard_alerts_active", Help: "Number of active alerts by type and severity.", Type: "gauge"}
+	for rows.Next() {
+		var app, alertType, severity string
+		var count int
+		if err := rows.Scan(&app, &alertType, &severity, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan alert row: %w", err)
+		}
+		labels := appLabels(app, namespaces)
+		labels["alert_type"] = alertType
+		labels["severity"] = severity
+		family.Samples = append(family.Samples, promSample{Labels: labels, Value: float64(count)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alert rows: %w", err)
+	}
+
+	return []*promFamily{family}, nil
+}
+
+// healthChecks exports the result of the latest health check of each app
+func (e *Exporter) healthChecks(namespaces map[string]string) ([]*promFamily, error) {
+	rows, err := e.db.GetConnection().Query(`
+		SELECT a.name, hc.endpoint, hc.status, COALESCE(hc.response_time, 0), hc.checked_at
+		FROM health_checks hc
+		JOIN apps a ON a.id = hc.app_id
+		WHERE hc.id = (SELECT MAX(id) FROM health_checks WHERE app_id = hc.app_id)
+		ORDER BY a.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query health checks: %w", err)
+	}
+	defer rows.Close()
+
+	up := &promFamily{Name: "shipyard_health_check_up", Help: "Whether the latest health check succeeded (1) or not (0).", Type: "gauge"}
+	responseTime := &promFamily{Name: "shipyard_health_check_response_time_milliseconds", Help: "Response time of the latest health check in milliseconds.", Type: "gauge"}
+	checkedAt := &promFamily{Name: "shipyard_health_check_timestamp_seconds", Help: "Unix time of the latest health check.", Type: "gauge"}
+
+	for rows.Next() {
+		var app, endpoint, status string
+		var ms int
+		var at time.Time
+		if err := rows.Scan(&app, &endpoint, &status, &ms, &at); err != nil {
+			return nil, fmt.Errorf("failed to scan health check row: %w", err)
+		}
+
+		labels := appLabels(app, namespaces)
+		labels["endpoint"] = endpoint
+		value := 0.0
+		if HealthStatus(status) == HealthStatusHealthy {
+			value = 1
+		}
+
+		up.Samples = append(up.Samples, promSample{Labels: labels, Value: value})
+		responseTime.Samples = append(responseTime.Samples, promSample{Labels: labels, Value: float64(ms)})
+		checkedAt.Samples = append(checkedAt.Samples, promSample{Labels: labels, Value: float64(at.Unix())})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating health check rows: %w", err)
+	}
+
+	return []*promFamily{up, responseTime, checkedAt}, nil
+}
+
+// appLabels returns the app and namespace labels of an app
+func appLabels(app string, namespaces map[string]string) map[string]string {
+	return map[string]string{"app": app, "namespace": namespaces[app]}
+}
+
+func writeFamily(w io.Writer, family *promFamily) error {
+	if len(family.Samples) == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", family.Name, family.Help, family.Name, family.Type); err != nil {
+		return err
+	}
+	for _, sample := range family.Samples {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", family.Name, formatLabels(sample.Labels), formatValue(sample.Value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatLabels formats labels in name order, e.g. {app="web",namespace="web"}
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, escaper.Replace(labels[name])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// sanitizeMetricName replaces the characters not allowed in metric names
+func sanitizeMetricName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
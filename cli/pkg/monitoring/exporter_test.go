@@ -0,0 +1,348 @@
+package monitoring
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// exportedFamily is a metric family of an exposition, its samples sorted
+type exportedFamily struct {
+	help    string
+	typ     string
+	samples []string
+}
+
+// parseExposition splits a text-format exposition into its families, in
+// order, failing on samples outside of the family their HELP/TYPE lines open
+func parseExposition(t *testing.T, text string) ([]string, map[string]exportedFamily) {
+	t.Helper()
+	var names []string
+	families := make(map[string]exportedFamily)
+
+	var current string
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "# HELP "):
+			fields := strings.SplitN(strings.TrimPrefix(line, "# HELP "), " ", 2)
+			current = fields[0]
+			if _, ok := families[current]; ok {
+				t.Errorf("family %s written twice", current)
+			}
+			names = append(names, current)
+			families[current] = exportedFamily{help: fields[1]}
+		case strings.HasPrefix(line, "# TYPE "):
+			fields := strings.SplitN(strings.TrimPrefix(line, "# TYPE "), " ", 2)
+			family := families[fields[0]]
+			if fields[0] != current || family.typ != "" {
+				t.Errorf("TYPE line %q does not follow the HELP line of its family", line)
+			}
+			family.typ = fields[1]
+			families[fields[0]] = family
+		default:
+			name := line
+			if i := strings.IndexAny(line, "{ "); i >= 0 {
+				name = line[:i]
+			}
+			if name != current {
+				t.Errorf("sample %q is not in its family %s", line, name)
+				continue
+			}
+			family := families[current]
+			family.samples = append(family.samples, line)
+			families[current] = family
+		}
+	}
+
+	for name, family := range families {
+		sort.Strings(family.samples)
+		families[name] = family
+	}
+	return names, families
+}
+
+func TestExporterWrite(t *testing.T) {
+	c := newTestCollector(t)
+	db := c.db.GetConnection()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	shopID, err := c.db.GetOrCreateApp("shop")
+	if err != nil {
+		t.Fatalf("GetOrCreateApp() failed: %v", err)
+	}
+	blogID, err := c.db.GetOrCreateApp("blog")
+	if err != nil {
+		t.Fatalf("GetOrCreateApp() failed: %v", err)
+	}
+	// An app found in the cluster by the agent, without deployments
+	toolsID, err := c.db.GetOrCreateApp("tools")
+	if err != nil {
+		t.Fatalf("GetOrCreateApp() failed: %v", err)
+	}
+	if _, err := db.Exec("UPDATE apps SET namespace = ? WHERE id = ?", "ops", toolsID); err != nil {
+		t.Fatal(err)
+	}
+	// An app without any data is left out
+	if _, err := c.db.GetOrCreateApp("idle"); err != nil {
+		t.Fatalf("GetOrCreateApp() failed: %v", err)
+	}
+
+	// shop is deployed in its own namespace, blog in the default one
+	for i, status := range []string{"success", "failed", "success"} {
+		if _, err := db.Exec(`
+			INSERT INTO deployments (app_id, version, image, image_tag, image_hash, config_json, config_hash, status, deployed_at)
+			VALUES (?, ?, 'shop:v1', 'v1', 'hash', ?, 'hash', ?, ?)`,
+			shopID, fmt.Sprintf("v%d", i), `{"App":{"Name":"shop","Namespace":"shop-prod"}}`, status, now.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("failed to insert deployment: %v", err)
+		}
+	}
+
+	metrics := []Metric{
+		// The previous collection of shop, whose pod is gone
+		{AppID: shopID, Type: MetricTypeCPU, Value: 900, PodName: "shop-old", Timestamp: now.Add(-2 * time.Minute)},
+		// The latest collection of shop
+		{AppID: shopID, Type: MetricTypeCPU, Value: 120, PodName: "shop-1", Timestamp: now},
+		{AppID: shopID, Type: MetricTypeCPU, Value: 80.5, PodName: "shop-2", Timestamp: now},
+		{AppID: shopID, Type: MetricTypeMemory, Value: 268435456, PodName: "shop-1", Timestamp: now},
+		{AppID: shopID, Type: MetricTypeCPUPercent, Value: 48, PodName: "shop-1", Timestamp: now},
+		{AppID: shopID, Type: MetricTypePods, Value: 2, Timestamp: now},
+		{AppID: shopID, Type: "replicas_desired", Value: 2, Timestamp: now},
+		{AppID: shopID, Type: MetricTypeLatencyP50, Value: 12, Timestamp: now},
+		{AppID: shopID, Type: MetricTypeLatencyP99, Value: 250, Timestamp: now},
+		{AppID: shopID, Type: "queue-depth.jobs", Value: 7, Timestamp: now},
+		// Metrics older than an hour are not exported
+		{AppID: blogID, Type: MetricTypePods, Value: 3, Timestamp: now.Add(-2 * time.Hour)},
+		{AppID: toolsID, Type: MetricTypePods, Value: 1, Timestamp: now},
+	}
+	for _, metric := range metrics {
+		if err := c.storeMetric(metric); err != nil {
+			t.Fatalf("storeMetric() failed: %v", err)
+		}
+	}
+
+	checks := []HealthCheck{
+		{AppID: shopID, Endpoint: "/health", Status: HealthStatusHealthy, ResponseTime: 30, CheckedAt: now.Add(-time.Minute)},
+		// Only the latest check is exported; its endpoint needs escaping
+		{AppID: shopID, Endpoint: "/health?probe=\"deep\"\\full", Status: HealthStatusUnhealthy, ResponseTime: 1500, CheckedAt: now},
+		{AppID: blogID, Endpoint: "/up", Status: HealthStatusHealthy, ResponseTime: 12, CheckedAt: now},
+	}
+	for _, check := range checks {
+		check.Method = "GET"
+		if err := c.storeHealthCheck(check); err != nil {
+			t.Fatalf("storeHealthCheck() failed: %v", err)
+		}
+	}
+
+	alerts := []struct {
+		appID    int64
+		typ      string
+		severity AlertSeverity
+		status   AlertStatus
+	}{
+		{shopID, AlertTypeCPUHigh, AlertSeverityWarning, AlertStatusActive},
+		{shopID, AlertTypeResponseTimeHigh, AlertSeverityCritical, AlertStatusActive},
+		{shopID, AlertTypeResponseTimeHigh, AlertSeverityCritical, AlertStatusActive},
+		{shopID, AlertTypeMemoryHigh, AlertSeverityWarning, AlertStatusResolved},
+		{blogID, AlertTypeCPUHigh, AlertSeverityWarning, AlertStatusSuppressed},
+	}
+	for _, alert := range alerts {
+		if _, err := db.Exec(`
+			INSERT INTO alerts (app_id, alert_type, threshold, current_value, severity, status, message, created_at)
+			VALUES (?, ?, 80, 90, ?, ?, 'alert', ?)`,
+			alert.appID, alert.typ, string(alert.severity), string(alert.status), now); err != nil {
+			t.Fatalf("failed to insert alert: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := c.Exporter().Write(&buf); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	names, families := parseExposition(t, buf.String())
+
+	shop := `app="shop",namespace="shop-prod"`
+	tools := `app="tools",namespace="ops"`
+	// Labels are sorted by name, the endpoint between app and namespace
+	blogCheck := `app="blog",endpoint="/up",namespace="blog"`
+	shopCheck := `app="shop",endpoint="/health?probe=\"deep\"\\full",namespace="shop-prod"`
+	unix := now.Unix()
+	want := map[string]exportedFamily{
+		"shipyard_cpu_limit_percent": {
+			help:    "CPU usage of the pod as a percentage of its limits (or requests).",
+			samples: []string{`shipyard_cpu_limit_percent{` + shop + `,pod="shop-1"} 48`},
+		},
+		"shipyard_cpu_millicores": {
+			help: "CPU usage of the pod in millicores.",
+			samples: []string{
+				`shipyard_cpu_millicores{` + shop + `,pod="shop-1"} 120`,
+				`shipyard_cpu_millicores{` + shop + `,pod="shop-2"} 80.5`,
+			},
+		},
+		"shipyard_latency_milliseconds": {
+			help: "HTTP request latency percentiles in milliseconds.",
+			samples: []string{
+				`shipyard_latency_milliseconds{` + shop + `,quantile="0.5"} 12`,
+				`shipyard_latency_milliseconds{` + shop + `,quantile="0.99"} 250`,
+			},
+		},
+		"shipyard_memory_bytes": {
+			help:    "Memory usage of the pod in bytes.",
+			samples: []string{`shipyard_memory_bytes{` + shop + `,pod="shop-1"} 268435456`},
+		},
+		"shipyard_pods": {
+			help: "Number of pods of the app.",
+			samples: []string{
+				`shipyard_pods{` + shop + `} 2`,
+				`shipyard_pods{` + tools + `} 1`,
+			},
+		},
+		"shipyard_queue_depth_jobs": {
+			help:    "Latest queue-depth.jobs metric collected by shipyard.",
+			samples: []string{`shipyard_queue_depth_jobs{` + shop + `} 7`},
+		},
+		"shipyard_replicas_desired": {
+			help:    "Replicas requested by the deployment.",
+			samples: []string{`shipyard_replicas_desired{` + shop + `} 2`},
+		},
+		"shipyard_deployments": {
+			help: "Number of deployments by status.",
+			samples: []string{
+				`shipyard_deployments{` + shop + `,status="failed"} 1`,
+				`shipyard_deployments{` + shop + `,status="success"} 2`,
+			},
+		},
+		"shipyard_alerts_active": {
+			help: "Number of active alerts by type and severity.",
+			samples: []string{
+				`shipyard_alerts_active{alert_type="cpu_high",` + shop + `,severity="warning"} 1`,
+				`shipyard_alerts_active{alert_type="response_time_high",` + shop + `,severity="critical"} 2`,
+			},
+		},
+		"shipyard_health_check_up": {
+			help: "Whether the latest health check succeeded (1) or not (0).",
+			samples: []string{
+				`shipyard_health_check_up{` + blogCheck + `} 1`,
+				`shipyard_health_check_up{` + shopCheck + `} 0`,
+			},
+		},
+		"shipyard_health_check_response_time_milliseconds": {
+			help: "Response time of the latest health check in milliseconds.",
+			samples: []string{
+				`shipyard_health_check_response_time_milliseconds{` + blogCheck + `} 12`,
+				`shipyard_health_check_response_time_milliseconds{` + shopCheck + `} 1500`,
+			},
+		},
+		"shipyard_health_check_timestamp_seconds": {
+			help: "Unix time of the latest health check.",
+			samples: []string{
+				fmt.Sprintf(`shipyard_health_check_timestamp_seconds{%s} %d`, blogCheck, unix),
+				fmt.Sprintf(`shipyard_health_check_timestamp_seconds{%s} %d`, shopCheck, unix),
+			},
+		},
+	}
+
+	// Metrics first, sorted by name, then deployments, alerts and health checks
+	wantNames := []string{
+		"shipyard_cpu_limit_percent",
+		"shipyard_cpu_millicores",
+		"shipyard_latency_milliseconds",
+		"shipyard_memory_bytes",
+		"shipyard_pods",
+		"shipyard_queue_depth_jobs",
+		"shipyard_replicas_desired",
+		"shipyard_deployments",
+		"shipyard_alerts_active",
+		"shipyard_health_check_up",
+		"shipyard_health_check_response_time_milliseconds",
+		"shipyard_health_check_timestamp_seconds",
+	}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("families = %v, want %v", names, wantNames)
+	}
+
+	for name, w := range want {
+		got, ok := families[name]
+		if !ok {
+			t.Errorf("family %s not exported", name)
+			continue
+		}
+		w.typ = "gauge"
+		sort.Strings(w.samples)
+		if !reflect.DeepEqual(got, w) {
+			t.Errorf("family %s:\ngot  %+v\nwant %+v", name, got, w)
+		}
+	}
+
+	if strings.Contains(buf.String(), `app="idle"`) {
+		t.Error("app without data exported")
+	}
+}
+
+func TestExporterWriteEmpty(t *testing.T) {
+	c := newTestCollector(t)
+	if _, err := c.db.GetOrCreateApp("shop"); err != nil {
+		t.Fatalf("GetOrCreateApp() failed: %v", err)
+	}
+
+	// Families without samples are left out, HELP and TYPE lines included
+	var buf bytes.Buffer
+	if err := c.Exporter().Write(&buf); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Write() = %q, want nothing", buf.String())
+	}
+}
+
+func TestExporterServeHTTP(t *testing.T) {
+	c := newTestCollector(t)
+	appID, err := c.db.GetOrCreateApp("shop")
+	if err != nil {
+		t.Fatalf("GetOrCreateApp() failed: %v", err)
+	}
+	if err := c.storeMetric(Metric{AppID: appID, Type: MetricTypePods, Value: 2, Timestamp: time.Now().UTC()}); err != nil {
+		t.Fatalf("storeMetric() failed: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	c.Exporter().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", recorder.Code)
+	}
+	if got := recorder.Header().Get("Content-Type"); got != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want the text format", got)
+	}
+	want := `# HELP shipyard_pods Number of pods of the app.
+# TYPE shipyard_pods gauge
+shipyard_pods{app="shop",namespace="shop"} 2
+`
+	if got := recorder.Body.String(); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
+
+func TestFormatLabels(t *testing.T) {
+	tests := []struct {
+		labels map[string]string
+		want   string
+	}{
+		{labels: nil, want: ""},
+		{labels: map[string]string{"namespace": "shop", "app": "shop"}, want: `{app="shop",namespace="shop"}`},
+		{labels: map[string]string{"endpoint": `/a"b"`}, want: `{endpoint="/a\"b\""}`},
+		{labels: map[string]string{"endpoint": `C:\health`}, want: `{endpoint="C:\\health"}`},
+		{labels: map[string]string{"message": "line one\nline two"}, want: `{message="line one\nline two"}`},
+	}
+
+	for _, test := range tests {
+		if got := formatLabels(test.labels); got != test.want {
+			t.Errorf("formatLabels(%q) = %s, want %s", test.labels, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package monitoring
+
+import (
+	"fmt"
+	"time"
+)
+
+// AppHealthCheck is a stored health check together with the name of its app
+type AppHealthCheck struct {
+	AppName string `json:"app_name"`
+	HealthCheck
+}
+
+// HealthSummary represents the latest health check of an app and its uptime
+type HealthSummary struct {
+	AppName         string      `json:"app_name"`
+	Latest          HealthCheck `json:"latest"`
+	HealthyChecks   int         `json:"healthy_checks"`
+	UnhealthyChecks int         `json:"unhealthy_checks"`
+	AvgResponseTime float64     `json:"avg_response_time"`
+	Uptime          float64     `json:"uptime"` // percentage of healthy checks
+}
+
+// GetHealthSummaries returns the latest health check of all or a specific
+// app, listed by the app_health_summary view, and its uptime over the last
+// period
+func (c *Collector) GetHealthSummaries(appName string, period time.Duration) ([]HealthSummary, error) {
+	if period <= 0 {
+		return nil, fmt.Errorf("period must be positive, got %v", period)
+	}
+
+	query := `
+		SELECT s.app_name,
+		       hc.endpoint, hc.method, hc.status, hc.status_code, hc.response_time,
+		       hc.error_message, hc.checked_at
+		FROM app_health_summary s
+		JOIN apps a ON a.name = s.app_name
+		JOIN health_checks hc ON hc.id = (
+			SELECT id FROM health_checks
+			WHERE app_id = a.id
+			ORDER BY checked_at DESC, id DESC
+			LIMIT 1
+		)`
+
+	var args []interface{}
+	if appName != "" {
+		query += " WHERE s.app_name = ?"
+		args = append(args, appName)
+	}
+	query += " ORDER BY s.app_name"
+
+	rows, err := c.db.GetConnection().Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query health summary: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []HealthSummary
+	for rows.Next() {
+		var summary HealthSummary
+		var status string
+		var errorMessage *string
+
+		err := rows.Scan(
+			&summary.AppName,
+			&summary.Latest.Endpoint,
+			&summary.Latest.Method,
+			&status,
+			&summary.Latest.StatusCode,
+			&summary.Latest.ResponseTime,
+			&errorMessage,
+			&summary.Latest.CheckedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan health summary row: %w", err)
+		}
+
+		summary.Latest.Status = HealthStatus(status)
+		if errorMessage != nil {
+			summary.Latest.ErrorMessage = *errorMessage
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating health summary rows: %w", err)
+	}
+
+	windows, err := c.getHealthWindows(appName, time.Now().Add(-period))
+	if err != nil {
+		return nil, err
+	}
+	for i := range summaries {
+		window := windows[summaries[i].AppName]
+		summaries[i].HealthyChecks = window.HealthyChecks
+		summaries[i].UnhealthyChecks = window.UnhealthyChecks
+		summaries[i].AvgResponseTime = window.AvgResponseTime
+		summaries[i].Uptime = uptimePercent(window.HealthyChecks, window.HealthyChecks+window.UnhealthyChecks)
+	}
+
+	return summaries, nil
+}
+
+// getHealthWindows counts the health checks of all or a specific app since a
+// time, by app name
+func (c *Collector) getHealthWindows(appName string, since time.Time) (map[string]HealthSummary, error) {
+	query := `
+		SELECT a.name,
+		       COUNT(CASE WHEN hc.status = 'healthy' THEN 1 END),
+		       COUNT(CASE WHEN hc.status != 'healthy' THEN 1 END),
+		       AVG(hc.response_time)
+		FROM health_checks hc
+		JOIN apps a ON a.id = hc.app_id
+		WHERE hc.checked_at > ?`
+
+	args := []interface{}{since}
+	if appName != "" {
+		query += " AND a.name = ?"
+		args = append(args, appName)
+	}
+	query += " GROUP BY a.id, a.name"
+
+	rows, err := c.db.GetConnection().Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query health checks: %w", err)
+	}
+	defer rows.Close()
+
+	windows := make(map[string]HealthSummary)
+	for rows.Next() {
+		var window HealthSummary
+		var avgResponseTime *float64
+
+		if err := rows.Scan(&window.AppName, &window.HealthyChecks, &window.UnhealthyChecks, &avgResponseTime); err != nil {
+			return nil, fmt.Errorf("failed to scan health check counts: %w", err)
+		}
+		if avgResponseTime != nil {
+			window.AvgResponseTime = *avgResponseTime
+		}
+		windows[window.AppName] = window
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating health check counts: %w", err)
+	}
+
+	return windows, nil
+}
+
+// GetHealthHistory returns the stored health checks of the last period, most recent first
+func (c *Collector) GetHealthHistory(appName string, period time.Duration) ([]AppHealthCheck, error) {
+	query := `
+		SELECT hc.id, hc.app_id, a.name, hc.endpoint, hc.method, hc.status, hc.status_code,
+		       hc.response_time, hc.error_message, hc.checked_at
+		FROM health_checks hc
+		JOIN apps a ON a.id = hc.app_id
+		WHERE hc.checked_at > ?`
+
+	args := []interface{}{time.Now().Add(-period)}
+	if appName != "" {
+		query += " AND a.name = ?"
+		args = append(args, appName)
+	}
+	query += " ORDER BY hc.checked_at DESC, hc.id DESC"
+
+	rows, err := c.db.GetConnection().Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query health history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []AppHealthCheck
+	for rows.Next() {
+		var check AppHealthCheck
+		var status string
+		var errorMessage *string
+
+		err := rows.Scan(
+			&check.ID,
+			&check.AppID,
+			&check.AppName,
+			&check.Endpoint,
+			&check.Method,
+			&status,
+			&check.StatusCode,
+			&check.ResponseTime,
+			&errorMessage,
+			&check.CheckedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan health check row: %w", err)
+		}
+
+		check.Status = HealthStatus(status)
+		if errorMessage != nil {
+			check.ErrorMessage = *errorMessage
+		}
+
+		history = append(history, check)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating health check rows: %w", err)
+	}
+
+	return history, nil
+}
+
+// RunHealthChecks performs and stores a health check for all or a specific app
+func (c *Collector) RunHealthChecks(appName string) error {
+	apps, err := c.getAppsToMonitor(appName)
+	if err != nil {
+		return fmt.Errorf("failed to get apps: %w", err)
+	}
+
+	for _, app := range apps {
+		if err := c.performHealthCheck(app); err != nil {
+			fmt.Printf("Warning: health check failed for %s: %v\n", app.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// GetHealthCheckInterval returns the shortest health_check_interval configured
+// for all or a specific app
+func (c *Collector) GetHealthCheckInterval(appName string) (time.Duration, error) {
+	apps, err := c.getAppsToMonitor(appName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get apps: %w", err)
+	}
+
+	var interval time.Duration
+	for _, app := range apps {
+		config, err := c.getMonitoringConfig(app.ID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get monitoring config for %s: %w", app.Name, err)
+		}
+		appInterval := time.Duration(config.HealthCheckInterval) * time.Second
+		if appInterval > 0 && (interval == 0 || appInterval < interval) {
+			interval = appInterval
+		}
+	}
+
+	if interval == 0 {
+		interval = 30 * time.Second
+	}
+
+	return interval, nil
+}
+
+func uptimePercent(healthy, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(healthy) / float64(total) * 100
+}
@@ -0,0 +1,87 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetHealthSummariesWindow(t *testing.T) {
+	c := newTestCollector(t)
+	now := time.Now()
+
+	checks := map[string][]struct {
+		status HealthStatus
+		age    time.Duration
+	}{
+		"web": {
+			{HealthStatusHealthy, 10 * time.Minute},
+			{HealthStatusUnhealthy, 50 * time.Minute},
+			{HealthStatusHealthy, 3 * time.Hour},
+			{HealthStatusHealthy, 5 * time.Hour},
+		},
+		// Only checked long ago: listed without uptime
+		"worker": {
+			{HealthStatusUnhealthy, 2 * time.Hour},
+		},
+	}
+	for name, appChecks := range checks {
+		appID, err := c.db.GetOrCreateApp(name)
+		if err != nil {
+			t.Fatalf("GetOrCreateApp() failed: %v", err)
+		}
+		for _, check := range appChecks {
+			if err := c.storeHealthCheck(HealthCheck{AppID: appID, Endpoint: "/health", Method: "GET",
+				Status: check.status, StatusCode: 200, ResponseTime: 10, CheckedAt: now.Add(-check.age)}); err != nil {
+				t.Fatalf("storeHealthCheck() failed: %v", err)
+			}
+		}
+	}
+
+	tests := []struct {
+		period          time.Duration
+		webHealthy      int
+		webUnhealthy    int
+		webUptime       float64
+		workerUnhealthy int
+	}{
+		{time.Hour, 1, 1, 50, 0},
+		{4 * time.Hour, 2, 1, 200.0 / 3, 1},
+		{24 * time.Hour, 3, 1, 75, 1},
+	}
+
+	for _, test := range tests {
+		summaries, err := c.GetHealthSummaries("", test.period)
+		if err != nil {
+			t.Fatalf("GetHealthSummaries(%v) failed: %v", test.period, err)
+		}
+		if len(summaries) != 2 || summaries[0].AppName != "web" || summaries[1].AppName != "worker" {
+			t.Fatalf("GetHealthSummaries(%v) = %+v, want web and worker", test.period, summaries)
+		}
+
+		web, worker := summaries[0], summaries[1]
+		if web.HealthyChecks != test.webHealthy || web.UnhealthyChecks != test.webUnhealthy {
+			t.Errorf("%v: web checks = %d/%d, want %d/%d", test.period,
+				web.HealthyChecks, web.UnhealthyChecks, test.webHealthy, test.webUnhealthy)
+		}
+		if diff := web.Uptime - test.webUptime; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("%v: web uptime = %v, want %v", test.period, web.Uptime, test.webUptime)
+		}
+		if web.Latest.Status != HealthStatusHealthy || !web.Latest.CheckedAt.Equal(now.Add(-10*time.Minute)) {
+			t.Errorf("%v: web latest = %+v, want the check of 10 minutes ago", test.period, web.Latest)
+		}
+		if worker.UnhealthyChecks != test.workerUnhealthy || worker.Latest.Status != HealthStatusUnhealthy {
+			t.Errorf("%v: worker = %+v, want %d unhealthy checks", test.period, worker, test.workerUnhealthy)
+		}
+	}
+
+	// The view stays available to other readers of the database
+	var view string
+	if err := c.db.GetConnection().QueryRow(
+		"SELECT name FROM sqlite_master WHERE type = 'view' AND name = 'app_health_summary'").Scan(&view); err != nil {
+		t.Errorf("app_health_summary view is missing: %v", err)
+	}
+
+	if _, err := c.GetHealthSummaries("web", 0); err == nil {
+		t.Error("GetHealthSummaries(0) succeeded, want an error")
+	}
+}
@@ -0,0 +1,562 @@
+package monitoring
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shipyard/cli/pkg/database"
+	"github.com/shipyard/cli/pkg/registry"
+)
+
+// ChannelType represents the kind of a notification channel
+type ChannelType string
+
+const (
+	ChannelTypeWebhook ChannelType = "webhook" // generic JSON payload
+	ChannelTypeSlack   ChannelType = "slack"   // Slack incoming webhook payload
+	ChannelTypeEmail   ChannelType = "email"   // SMTP
+)
+
+// NotificationEvent represents what happened to an alert
+type NotificationEvent string
+
+const (
+	NotificationOpened      NotificationEvent = "opened"
+	NotificationEscalated   NotificationEvent = "escalated"    // the severity of an active alert rose
+	NotificationDeescalated NotificationEvent = "de-escalated" // the severity of an active alert fell
+	NotificationResolved    NotificationEvent = "resolved"
+	NotificationTest        NotificationEvent = "test"
+)
+
+const (
+	// notifyAttempts is how many times a notification is sent before giving up
+	notifyAttempts = 3
+	// notifyBackoff is the delay before the first retry, doubled for each retry
+	notifyBackoff = 500 * time.Millisecond
+	// notifyQueueSize is how many notifications wait for delivery before new
+	// ones are dropped
+	notifyQueueSize = 100
+	// notifyDrainTimeout is how long Close waits for queued notifications
+	notifyDrainTimeout = 30 * time.Second
+)
+
+// NotificationChannel represents a destination for alert notifications
+type NotificationChannel struct {
+	ID           int64         `json:"id"`
+	Name         string        `json:"name"`
+	Type         ChannelType   `json:"channel_type"`
+	Target       string        `json:"target"`   // webhook URL, or comma-separated recipients for email
+	AppName      string        `json:"app_name"` // empty for all apps
+	MinSeverity  AlertSeverity `json:"min_severity"`
+	SMTPHost     string        `json:"smtp_host,omitempty"`
+	SMTPPort     int           `json:"smtp_port,omitempty"`
+	SMTPUsername string        `json:"smtp_username,omitempty"`
+	SMTPPassword string        `json:"-"` // stored encrypted
+	SMTPFrom     string        `json:"smtp_from,omitempty"`
+	Enabled      bool          `json:"enabled"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+// Notification is an alert change sent to notification channels
+type Notification struct {
+	Event   NotificationEvent `json:"event"`
+	AppName string            `json:"app_name"`
+	Alert   Alert             `json:"alert"`
+	SentAt  time.Time         `json:"sent_at"`
+}
+
+// Notifier sends alert notifications to the configured channels
+type Notifier struct {
+	db         *database.DB
+	httpClient *http.Client
+	sendMail   func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+	backoff    time.Duration // delay before the first retry
+
+	mu     sync.Mutex
+	queue  chan Notification // notifications waiting for the worker, nil until the first one
+	done   chan struct{}     // closed when the worker has delivered the queue
+	closed bool
+}
+
+// NewNotifier creates a notifier using the channels stored in db
+func NewNotifier(db *database.DB) *Notifier {
+	return &Notifier{
+		db:         db,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		sendMail:   smtp.SendMail,
+		backoff:    notifyBackoff,
+	}
+}
+
+// Notify queues a notification for every enabled channel matching the app
+// and severity of the alert. Notifications are delivered in the background,
+// so that slow channels and their retries do not hold up the caller; when the
+// queue is full, the notification is dropped with a warning.
+func (n *Notifier) Notify(event NotificationEvent, appName string, alert Alert) {
+	notification := Notification{Event: event, AppName: appName, Alert: alert, SentAt: time.Now()}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return
+	}
+	if n.queue == nil {
+		n.queue = make(chan Notification, notifyQueueSize)
+		n.done = make(chan struct{})
+		go n.deliverQueue()
+	}
+
+	select {
+	case n.queue <- notification:
+	default:
+		fmt.Printf("Warning: notification queue full, dropping %s\n", notificationTitle(notification))
+	}
+}
+
+// Close stops accepting notifications and waits for the queued ones to be
+// delivered, for at most notifyDrainTimeout
+func (n *Notifier) Close() {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return
+	}
+	n.closed = true
+	queue, done := n.queue, n.done
+	n.mu.Unlock()
+
+	if queue == nil {
+		return
+	}
+	close(queue)
+
+	select {
+	case <-done:
+	case <-time.After(notifyDrainTimeout):
+		fmt.Printf("Warning: gave up on %d queued notifications\n", len(queue))
+	}
+}
+
+// deliverQueue delivers queued notifications until the queue is closed
+func (n *Notifier) deliverQueue() {
+	defer close(n.done)
+	for notification := range n.queue {
+		n.deliver(notification)
+	}
+}
+
+// deliver sends a notification to every enabled channel matching its app and
+// severity. Failures are reported but do not stop other channels.
+func (n *Notifier) deliver(notification Notification) {
+	channels, err := n.ListChannels()
+	if err != nil {
+		fmt.Printf("Warning: failed to get notification channels: %v\n", err)
+		return
+	}
+
+	appName, alert := notification.AppName, notification.Alert
+	for _, channel := range channels {
+		if !channel.Enabled || !channel.accepts(appName, alert.Severity) {
+			continue
+		}
+		if err := n.Send(channel, notification); err != nil {
+			fmt.Printf("Warning: failed to notify %s: %v\n", channel.Name, err)
+		}
+	}
+}
+
+// Send delivers a notification to a channel, retrying with backoff
+func (n *Notifier) Send(channel NotificationChannel, notification Notification) error {
+	var err error
+	backoff := n.backoff
+
+	for attempt := 1; attempt <= notifyAttempts; attempt++ {
+		var retry bool
+		retry, err = n.send(channel, notification)
+		if err == nil || !retry {
+			return err
+		}
+		if attempt < notifyAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+
+	return fmt.Errorf("giving up after %d attempts: %w", notifyAttempts, err)
+}
+
+// send delivers a notification once and reports whether a failure is worth retrying
+func (n *Notifier) send(channel NotificationChannel, notification Notification) (bool, error) {
+	switch channel.Type {
+	case ChannelTypeWebhook:
+		return n.postJSON(channel.Target, notification)
+	case ChannelTypeSlack:
+		return n.postJSON(channel.Target, slackPayload(notification))
+	case ChannelTypeEmail:
+		return n.sendEmail(channel, notification)
+	default:
+		return false, fmt.Errorf("unknown channel type: %s", channel.Type)
+	}
+}
+
+func (n *Notifier) postJSON(target string, payload interface{}) (bool, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	resp, err := n.httpClient.Post(target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// The URL of a webhook is a secret, keep it out of logs
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = redactURL(urlErr.URL)
+		}
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	// Server errors and rate limiting are temporary
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("webhook returned %s", resp.Status)
+}
+
+func (n *Notifier) sendEmail(channel NotificationChannel, notification Notification) (bool, error) {
+	recipients := splitRecipients(channel.Target)
+	if len(recipients) == 0 {
+		return false, fmt.Errorf("no recipients configured")
+	}
+
+	from := channel.SMTPFrom
+	if from == "" {
+		from = channel.SMTPUsername
+	}
+
+	var auth smtp.Auth
+	if channel.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", channel.SMTPUsername, channel.SMTPPassword, channel.SMTPHost)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(recipients, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", notificationTitle(notification))
+	fmt.Fprintf(&msg, "Date: %s\r\n", notification.SentAt.Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	fmt.Fprintf(&msg, "%s\r\n", strings.ReplaceAll(notificationText(notification), "\n", "\r\n"))
+
+	addr := net.JoinHostPort(channel.SMTPHost, strconv.Itoa(channel.SMTPPort))
+	if err := n.sendMail(addr, auth, from, recipients, msg.Bytes()); err != nil {
+		// Permanent SMTP failures (5xx) will not succeed on retry
+		var smtpErr *textproto.Error
+		retry := !errors.As(err, &smtpErr) || smtpErr.Code < 500
+		return retry, err
+	}
+
+	return false, nil
+}
+
+// ListChannels returns the configured notification channels
+func (n *Notifier) ListChannels() ([]NotificationChannel, error) {
+	query := `
+		SELECT nc.id, nc.name, nc.channel_type, nc.target, COALESCE(a.name, ''), nc.min_severity,
+		       COALESCE(nc.smtp_host, ''), COALESCE(nc.smtp_port, 587), COALESCE(nc.smtp_username, ''),
+		       COALESCE(nc.smtp_password, ''), COALESCE(nc.smtp_from, ''), nc.enabled, nc.created_at
+		FROM notification_channels nc
+		LEFT JOIN apps a ON a.id = nc.app_id
+		ORDER BY nc.name`
+
+	rows, err := n.db.GetConnection().Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification channels: %w", err)
+	}
+	defer rows.Close()
+
+	var channels []NotificationChannel
+	for rows.Next() {
+		var channel NotificationChannel
+		var channelType, minSeverity string
+
+		err := rows.Scan(
+			&channel.ID,
+			&channel.Name,
+			&channelType,
+			&channel.Target,
+			&channel.AppName,
+			&minSeverity,
+			&channel.SMTPHost,
+			&channel.SMTPPort,
+			&channel.SMTPUsername,
+			&channel.SMTPPassword,
+			&channel.SMTPFrom,
+			&channel.Enabled,
+			&channel.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification channel row: %w", err)
+		}
+
+		if channel.SMTPPassword != "" {
+			channel.SMTPPassword, err = registry.DecryptSecret(channel.SMTPPassword)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt SMTP password of channel %s: %w", channel.Name, err)
+			}
+		}
+
+		channel.Type = ChannelType(channelType)
+		channel.MinSeverity = AlertSeverity(minSeverity)
+		channels = append(channels, channel)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notification channel rows: %w", err)
+	}
+
+	return channels, nil
+}
+
+// GetChannel returns a notification channel by name
+func (n *Notifier) GetChannel(name string) (*NotificationChannel, error) {
+	channels, err := n.ListChannels()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, channel := range channels {
+		if channel.Name == name {
+			return &channel, nil
+		}
+	}
+
+	return nil, fmt.Errorf("notification channel %s not found", name)
+}
+
+// AddChannel stores a new notification channel
+func (n *Notifier) AddChannel(channel NotificationChannel) error {
+	if err := validateChannel(&channel); err != nil {
+		return err
+	}
+
+	var appID interface{}
+	if channel.AppName != "" {
+		var id int64
+		err := n.db.GetConnection().QueryRow("SELECT id FROM apps WHERE name = ?", channel.AppName).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("app %s not found", channel.AppName)
+		}
+		appID = id
+	}
+
+	// SMTP passwords are encrypted like registry credentials
+	var password interface{}
+	if channel.SMTPPassword != "" {
+		encrypted, err := registry.EncryptSecret(channel.SMTPPassword)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt SMTP password: %w", err)
+		}
+		password = encrypted
+	}
+
+	query := `
+		INSERT INTO notification_channels
+		(name, channel_type, target, app_id, min_severity, smtp_host, smtp_port, smtp_username, smtp_password, smtp_from, enabled)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := n.db.GetConnection().Exec(query,
+		channel.Name,
+		string(channel.Type),
+		channel.Target,
+		appID,
+		string(channel.MinSeverity),
+		channel.SMTPHost,
+		channel.SMTPPort,
+		channel.SMTPUsername,
+		password,
+		channel.SMTPFrom,
+		true,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return fmt.Errorf("notification channel %s already exists", channel.Name)
+		}
+		return fmt.Errorf("failed to add notification channel: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveChannel deletes a notification channel by name
+func (n *Notifier) RemoveChannel(name string) error {
+	result, err := n.db.GetConnection().Exec("DELETE FROM notification_channels WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("failed to remove notification channel: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("notification channel %s not found", name)
+	}
+
+	return nil
+}
+
+// accepts reports whether the channel wants notifications for an app and severity
+func (ch NotificationChannel) accepts(appName string, severity AlertSeverity) bool {
+	if ch.AppName != "" && ch.AppName != appName {
+		return false
+	}
+	return severityRank(severity) >= severityRank(ch.MinSeverity)
+}
+
+// RedactedTarget returns the target of a channel for display. The path and
+// query of webhook URLs, which often hold a token as Slack webhooks do, are
+// hidden.
+func (ch NotificationChannel) RedactedTarget() string {
+	if ch.Type == ChannelTypeEmail {
+		return ch.Target
+	}
+	return redactURL(ch.Target)
+}
+
+// redactURL hides the path and query of a URL
+func redactURL(rawURL string) string {
+	target, err := url.Parse(rawURL)
+	if err != nil || target.Host == "" {
+		return "***"
+	}
+	if target.Path == "" && target.RawQuery == "" {
+		return target.Scheme + "://" + target.Host
+	}
+	return target.Scheme + "://" + target.Host + "/***"
+}
+
+func validateChannel(channel *NotificationChannel) error {
+	if channel.Name == "" {
+		return fmt.Errorf("channel name required")
+	}
+	if channel.MinSeverity == "" {
+		channel.MinSeverity = AlertSeverityWarning
+	}
+	if severityRank(channel.MinSeverity) == 0 {
+		return fmt.Errorf("unknown severity: %s (use info, warning or critical)", channel.MinSeverity)
+	}
+
+	switch channel.Type {
+	case ChannelTypeWebhook, ChannelTypeSlack:
+		if !strings.HasPrefix(channel.Target, "http://") && !strings.HasPrefix(channel.Target, "https://") {
+			return fmt.Errorf("%s channels need an http(s) URL", channel.Type)
+		}
+	case ChannelTypeEmail:
+		if len(splitRecipients(channel.Target)) == 0 {
+			return fmt.Errorf("email channels need at least one recipient")
+		}
+		if channel.SMTPHost == "" {
+			return fmt.Errorf("email channels need an SMTP host")
+		}
+		if channel.SMTPPort == 0 {
+			channel.SMTPPort = 587
+		}
+		if channel.SMTPFrom == "" && channel.SMTPUsername == "" {
+			return fmt.Errorf("email channels need a sender address")
+		}
+	default:
+		return fmt.Errorf("unknown channel type: %s (use webhook, slack or email)", channel.Type)
+	}
+
+	return nil
+}
+
+func severityRank(severity AlertSeverity) int {
+	switch severity {
+	case AlertSeverityInfo:
+		return 1
+	case AlertSeverityWarning:
+		return 2
+	case AlertSeverityCritical:
+		return 3
+	default:
+		return 0
+	}
+}
+
+func splitRecipients(target string) []string {
+	var recipients []string
+	for _, recipient := range strings.Split(target, ",") {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			recipients = append(recipients, recipient)
+		}
+	}
+	return recipients
+}
+
+// notificationTitle returns a one-line summary, e.g. "[CRITICAL] web: cpu_high opened"
+func notificationTitle(notification Notification) string {
+	return fmt.Sprintf("[%s] %s: %s %s",
+		strings.ToUpper(string(notification.Alert.Severity)),
+		notification.AppName,
+		notification.Alert.Type,
+		notification.Event,
+	)
+}
+
+// notificationText returns the plain text body of a notification
+func notificationText(notification Notification) string {
+	alert := notification.Alert
+	lines := []string{
+		alert.Message,
+		"",
+		fmt.Sprintf("App:           %s", notification.AppName),
+		fmt.Sprintf("Alert:         %s (#%d)", alert.Type, alert.ID),
+		fmt.Sprintf("Severity:      %s", alert.Severity),
+		fmt.Sprintf("Current value: %.1f (threshold %.1f)", alert.CurrentValue, alert.Threshold),
+		fmt.Sprintf("Opened at:     %s", alert.CreatedAt.Format("2006-01-02 15:04:05")),
+	}
+	if alert.ResolvedAt != nil {
+		lines = append(lines, fmt.Sprintf("Resolved at:   %s", alert.ResolvedAt.Format("2006-01-02 15:04:05")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// slackPayload formats a notification for a Slack incoming webhook
+func slackPayload(notification Notification) map[string]interface{} {
+	color := "warning"
+	switch {
+	case notification.Event == NotificationResolved:
+		color = "good"
+	case notification.Alert.Severity == AlertSeverityCritical:
+		color = "danger"
+	case notification.Alert.Severity == AlertSeverityInfo:
+		color = "#439FE0"
+	}
+
+	return map[string]interface{}{
+		"text": notificationTitle(notification),
+		"attachments": []map[string]interface{}{
+			{
+				"color":  color,
+				"title":  notification.Alert.Message,
+				"text":   notificationText(notification),
+				"footer": "shipyard",
+				"ts":     notification.SentAt.Unix(),
+			},
+		},
+	}
+}
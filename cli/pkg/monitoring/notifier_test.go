@@ -0,0 +1,470 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shipyard/cli/pkg/database"
+)
+
+// newTestDB returns an empty database in a temporary home directory
+func newTestDB(t *testing.T) *database.DB {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	db, err := database.NewDB()
+	if err != nil {
+		t.Fatalf("NewDB() failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// newTestNotifier returns a notifier on a database of its own, retrying
+// without waiting
+func newTestNotifier(t *testing.T) *Notifier {
+	t.Helper()
+	notifier := NewNotifier(newTestDB(t))
+	notifier.backoff = time.Millisecond
+	return notifier
+}
+
+// testNotification returns an alert notification of an app
+func testNotification(event NotificationEvent, severity AlertSeverity) Notification {
+	return Notification{
+		Event:   event,
+		AppName: "web",
+		Alert: Alert{
+			ID:           7,
+			Type:         "cpu_high",
+			Threshold:    80,
+			CurrentValue: 93.5,
+			Severity:     severity,
+			Status:       AlertStatusActive,
+			Message:      "CPU usage 93.5% above 80.0%",
+			CreatedAt:    time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+		SentAt: time.Date(2026, 3, 1, 12, 0, 5, 0, time.UTC),
+	}
+}
+
+// recordingServer is a webhook endpoint answering with the given statuses in
+// turn, then 200, and recording the bodies it receives
+type recordingServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	bodies   [][]byte
+}
+
+func newRecordingServer(t *testing.T, statuses ...int) *recordingServer {
+	t.Helper()
+	server := &recordingServer{statuses: statuses}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		server.bodies = append(server.bodies, body)
+		if len(server.statuses) > 0 {
+			w.WriteHeader(server.statuses[0])
+			server.statuses = server.statuses[1:]
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *recordingServer) received() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]byte(nil), s.bodies...)
+}
+
+func TestSendWebhookPayload(t *testing.T) {
+	notifier := newTestNotifier(t)
+	server := newRecordingServer(t)
+
+	channel := NotificationChannel{Name: "hook", Type: ChannelTypeWebhook, Target: server.URL + "/alerts"}
+	if err := notifier.Send(channel, testNotification(NotificationOpened, AlertSeverityCritical)); err != nil {
+		t.Fatalf("Send() failed: %v", err)
+	}
+
+	bodies := server.received()
+	if len(bodies) != 1 {
+		t.Fatalf("received %d requests, want 1", len(bodies))
+	}
+	var payload Notification
+	if err := json.Unmarshal(bodies[0], &payload); err != nil {
+		t.Fatalf("invalid payload %s: %v", bodies[0], err)
+	}
+	if payload.Event != NotificationOpened || payload.AppName != "web" {
+		t.Errorf("payload event %q app %q, want opened web", payload.Event, payload.AppName)
+	}
+	if payload.Alert.Type != "cpu_high" || payload.Alert.Severity != AlertSeverityCritical || payload.Alert.CurrentValue != 93.5 {
+		t.Errorf("payload alert = %+v", payload.Alert)
+	}
+}
+
+func TestSendSlackPayload(t *testing.T) {
+	tests := []struct {
+		event    NotificationEvent
+		severity AlertSeverity
+		color    string
+	}{
+		{NotificationOpened, AlertSeverityCritical, "danger"},
+		{NotificationEscalated, AlertSeverityCritical, "danger"},
+		{NotificationOpened, AlertSeverityWarning, "warning"},
+		{NotificationOpened, AlertSeverityInfo, "#439FE0"},
+		{NotificationResolved, AlertSeverityCritical, "good"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s %s", test.event, test.severity), func(t *testing.T) {
+			notifier := newTestNotifier(t)
+			server := newRecordingServer(t)
+
+			channel := NotificationChannel{Name: "ops", Type: ChannelTypeSlack, Target: server.URL}
+			if err := notifier.Send(channel, testNotification(test.event, test.severity)); err != nil {
+				t.Fatalf("Send() failed: %v", err)
+			}
+
+			bodies := server.received()
+			if len(bodies) != 1 {
+				t.Fatalf("received %d requests, want 1", len(bodies))
+			}
+			var payload struct {
+				Text        string `json:"text"`
+				Attachments []struct {
+					Color string `json:"color"`
+					Title string `json:"title"`
+					Text  string `json:"text"`
+					TS    int64  `json:"ts"`
+				} `json:"attachments"`
+			}
+			if err := json.Unmarshal(bodies[0], &payload); err != nil {
+				t.Fatalf("invalid payload %s: %v", bodies[0], err)
+			}
+
+			wantText := fmt.Sprintf("[%s] web: cpu_high %s", strings.ToUpper(string(test.severity)), test.event)
+			if payload.Text != wantText {
+				t.Errorf("text = %q, want %q", payload.Text, wantText)
+			}
+			if len(payload.Attachments) != 1 {
+				t.Fatalf("got %d attachments, want 1", len(payload.Attachments))
+			}
+			attachment := payload.Attachments[0]
+			if attachment.Color != test.color {
+				t.Errorf("color = %q, want %q", attachment.Color, test.color)
+			}
+			if attachment.Title != "CPU usage 93.5% above 80.0%" || !strings.Contains(attachment.Text, "Current value: 93.5 (threshold 80.0)") {
+				t.Errorf("attachment = %+v", attachment)
+			}
+			if attachment.TS != time.Date(2026, 3, 1, 12, 0, 5, 0, time.UTC).Unix() {
+				t.Errorf("ts = %d", attachment.TS)
+			}
+		})
+	}
+}
+
+func TestSendRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		requests int
+		wantErr  bool
+	}{
+		{"success", nil, 1, false},
+		{"retried after 5xx", []int{http.StatusBadGateway, http.StatusServiceUnavailable}, 3, false},
+		{"retried after 429", []int{http.StatusTooManyRequests}, 2, false},
+		{"gives up after 5xx", []int{500, 500, 500, 500}, notifyAttempts, true},
+		{"4xx not retried", []int{http.StatusNotFound}, 1, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			notifier := newTestNotifier(t)
+			server := newRecordingServer(t, test.statuses...)
+
+			channel := NotificationChannel{Name: "hook", Type: ChannelTypeWebhook, Target: server.URL}
+			err := notifier.Send(channel, testNotification(NotificationOpened, AlertSeverityWarning))
+			if (err != nil) != test.wantErr {
+				t.Errorf("Send() error = %v, want error %v", err, test.wantErr)
+			}
+			if got := len(server.received()); got != test.requests {
+				t.Errorf("received %d requests, want %d", got, test.requests)
+			}
+		})
+	}
+}
+
+func TestSendBackoff(t *testing.T) {
+	notifier := newTestNotifier(t)
+	notifier.backoff = 20 * time.Millisecond
+	server := newRecordingServer(t, 500, 500, 500)
+
+	start := time.Now()
+	channel := NotificationChannel{Name: "hook", Type: ChannelTypeWebhook, Target: server.URL}
+	if err := notifier.Send(channel, testNotification(NotificationOpened, AlertSeverityWarning)); err == nil {
+		t.Fatal("Send() succeeded, want an error")
+	}
+
+	// Two retries, after 20ms then 40ms
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("retried after %s, want at least 60ms of backoff", elapsed)
+	}
+}
+
+func TestSendHidesWebhookURL(t *testing.T) {
+	notifier := newTestNotifier(t)
+	server := newRecordingServer(t)
+	server.Close()
+
+	channel := NotificationChannel{Name: "ops", Type: ChannelTypeSlack, Target: server.URL + "/services/T0/B0/token"}
+	err := notifier.Send(channel, testNotification(NotificationOpened, AlertSeverityWarning))
+	if err == nil {
+		t.Fatal("Send() succeeded, want an error")
+	}
+	if strings.Contains(err.Error(), "token") {
+		t.Errorf("error %q shows the webhook URL", err)
+	}
+}
+
+func TestSendEmail(t *testing.T) {
+	notifier := newTestNotifier(t)
+
+	var addr, from string
+	var to []string
+	var msg []byte
+	var auth smtp.Auth
+	notifier.sendMail = func(a string, au smtp.Auth, f string, recipients []string, m []byte) error {
+		addr, auth, from, to, msg = a, au, f, recipients, m
+		return nil
+	}
+
+	channel := NotificationChannel{
+		Name:         "oncall",
+		Type:         ChannelTypeEmail,
+		Target:       "ops@example.com, dev@example.com",
+		SMTPHost:     "smtp.example.com",
+		SMTPPort:     587,
+		SMTPUsername: "shipyard",
+		SMTPPassword: "secret",
+		SMTPFrom:     "alerts@example.com",
+	}
+	if err := notifier.Send(channel, testNotification(NotificationResolved, AlertSeverityCritical)); err != nil {
+		t.Fatalf("Send() failed: %v", err)
+	}
+
+	if addr != "smtp.example.com:587" {
+		t.Errorf("addr = %q", addr)
+	}
+	if auth == nil {
+		t.Error("no SMTP auth with a username")
+	}
+	if from != "alerts@example.com" {
+		t.Errorf("from = %q", from)
+	}
+	if strings.Join(to, ",") != "ops@example.com,dev@example.com" {
+		t.Errorf("to = %v", to)
+	}
+	for _, want := range []string{
+		"From: alerts@example.com\r\n",
+		"To: ops@example.com, dev@example.com\r\n",
+		"Subject: [CRITICAL] web: cpu_high resolved\r\n",
+		"\r\n\r\nCPU usage 93.5% above 80.0%\r\n",
+	} {
+		if !strings.Contains(string(msg), want) {
+			t.Errorf("message does not contain %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestSendEmailRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		attempts int
+	}{
+		{"temporary failure retried", &textproto.Error{Code: 421, Msg: "try again later"}, notifyAttempts},
+		{"network failure retried", fmt.Errorf("connection refused"), notifyAttempts},
+		{"permanent failure not retried", &textproto.Error{Code: 550, Msg: "mailbox unavailable"}, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			notifier := newTestNotifier(t)
+			attempts := 0
+			notifier.sendMail = func(string, smtp.Auth, string, []string, []byte) error {
+				attempts++
+				return test.err
+			}
+
+			channel := NotificationChannel{Name: "oncall", Type: ChannelTypeEmail, Target: "ops@example.com",
+				SMTPHost: "smtp.example.com", SMTPPort: 25, SMTPFrom: "alerts@example.com"}
+			if err := notifier.Send(channel, testNotification(NotificationOpened, AlertSeverityWarning)); err == nil {
+				t.Fatal("Send() succeeded, want an error")
+			}
+			if attempts != test.attempts {
+				t.Errorf("%d attempts, want %d", attempts, test.attempts)
+			}
+		})
+	}
+}
+
+func TestChannelAccepts(t *testing.T) {
+	tests := []struct {
+		appName     string
+		minSeverity AlertSeverity
+		alertApp    string
+		severity    AlertSeverity
+		want        bool
+	}{
+		{"", AlertSeverityWarning, "web", AlertSeverityCritical, true},
+		{"", AlertSeverityWarning, "web", AlertSeverityWarning, true},
+		{"", AlertSeverityWarning, "web", AlertSeverityInfo, false},
+		{"", AlertSeverityCritical, "web", AlertSeverityWarning, false},
+		{"", AlertSeverityInfo, "web", AlertSeverityInfo, true},
+		{"web", AlertSeverityInfo, "web", AlertSeverityWarning, true},
+		{"web", AlertSeverityInfo, "api", AlertSeverityCritical, false},
+	}
+
+	for _, test := range tests {
+		channel := NotificationChannel{AppName: test.appName, MinSeverity: test.minSeverity}
+		if got := channel.accepts(test.alertApp, test.severity); got != test.want {
+			t.Errorf("channel of %q from %s accepts(%s, %s) = %v, want %v",
+				test.appName, test.minSeverity, test.alertApp, test.severity, got, test.want)
+		}
+	}
+}
+
+func TestNotifyFiltersChannels(t *testing.T) {
+	notifier := newTestNotifier(t)
+	if _, err := notifier.db.GetOrCreateApp("web"); err != nil {
+		t.Fatalf("GetOrCreateApp() failed: %v", err)
+	}
+
+	all := newRecordingServer(t)
+	critical := newRecordingServer(t)
+	other := newRecordingServer(t)
+	for _, channel := range []NotificationChannel{
+		{Name: "all", Type: ChannelTypeWebhook, Target: all.URL, MinSeverity: AlertSeverityWarning},
+		{Name: "critical", Type: ChannelTypeWebhook, Target: critical.URL, MinSeverity: AlertSeverityCritical},
+		{Name: "other", Type: ChannelTypeWebhook, Target: other.URL, MinSeverity: AlertSeverityInfo, AppName: "api"},
+	} {
+		if channel.AppName != "" {
+			if _, err := notifier.db.GetOrCreateApp(channel.AppName); err != nil {
+				t.Fatalf("GetOrCreateApp() failed: %v", err)
+			}
+		}
+		if err := notifier.AddChannel(channel); err != nil {
+			t.Fatalf("AddChannel(%s) failed: %v", channel.Name, err)
+		}
+	}
+
+	warning := testNotification(NotificationOpened, AlertSeverityWarning)
+	escalated := testNotification(NotificationEscalated, AlertSeverityCritical)
+	notifier.Notify(warning.Event, "web", warning.Alert)
+	notifier.Notify(escalated.Event, "web", escalated.Alert)
+	notifier.Close()
+
+	if got := len(all.received()); got != 2 {
+		t.Errorf("channel all received %d notifications, want 2", got)
+	}
+	if got := len(critical.received()); got != 1 {
+		t.Errorf("channel critical received %d notifications, want 1", got)
+	}
+	if got := len(other.received()); got != 0 {
+		t.Errorf("channel of another app received %d notifications, want 0", got)
+	}
+}
+
+func TestNotifyDoesNotWait(t *testing.T) {
+	notifier := newTestNotifier(t)
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	if err := notifier.AddChannel(NotificationChannel{Name: "slow", Type: ChannelTypeWebhook, Target: server.URL}); err != nil {
+		t.Fatalf("AddChannel() failed: %v", err)
+	}
+
+	alert := testNotification(NotificationOpened, AlertSeverityCritical).Alert
+	done := make(chan struct{})
+	go func() {
+		notifier.Notify(NotificationOpened, "web", alert)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Notify() waited for the channel")
+	}
+	close(release)
+	notifier.Close()
+}
+
+func TestAddChannelEncryptsPassword(t *testing.T) {
+	notifier := newTestNotifier(t)
+
+	err := notifier.AddChannel(NotificationChannel{
+		Name:         "oncall",
+		Type:         ChannelTypeEmail,
+		Target:       "ops@example.com",
+		SMTPHost:     "smtp.example.com",
+		SMTPUsername: "shipyard",
+		SMTPPassword: "hunter2",
+	})
+	if err != nil {
+		t.Fatalf("AddChannel() failed: %v", err)
+	}
+
+	var stored string
+	if err := notifier.db.GetConnection().QueryRow(
+		"SELECT smtp_password FROM notification_channels WHERE name = 'oncall'").Scan(&stored); err != nil {
+		t.Fatalf("failed to read the stored password: %v", err)
+	}
+	if stored == "" || strings.Contains(stored, "hunter2") {
+		t.Errorf("stored password %q is not encrypted", stored)
+	}
+
+	channel, err := notifier.GetChannel("oncall")
+	if err != nil {
+		t.Fatalf("GetChannel() failed: %v", err)
+	}
+	if channel.SMTPPassword != "hunter2" {
+		t.Errorf("password = %q, want hunter2", channel.SMTPPassword)
+	}
+}
+
+func TestRedactedTarget(t *testing.T) {
+	tests := []struct {
+		channel NotificationChannel
+		want    string
+	}{
+		{NotificationChannel{Type: ChannelTypeSlack, Target: "https://hooks.slack.com/services/T0/B0/token"}, "https://hooks.slack.com/***"},
+		{NotificationChannel{Type: ChannelTypeWebhook, Target: "https://example.com/alerts?key=secret"}, "https://example.com/***"},
+		{NotificationChannel{Type: ChannelTypeWebhook, Target: "http://alerts.internal:8080"}, "http://alerts.internal:8080"},
+		{NotificationChannel{Type: ChannelTypeWebhook, Target: "not a url"}, "***"},
+		{NotificationChannel{Type: ChannelTypeEmail, Target: "ops@example.com"}, "ops@example.com"},
+	}
+
+	for _, test := range tests {
+		if got := test.channel.RedactedTarget(); got != test.want {
+			t.Errorf("RedactedTarget(%q) = %q, want %q", test.channel.Target, got, test.want)
+		}
+	}
+}
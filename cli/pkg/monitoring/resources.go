@@ -0,0 +1,96 @@
+package monitoring
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+// PodUsage is the resource usage of a pod, summed over its containers
+type PodUsage struct {
+	PodName       string
+	CPUMillicores int64
+	MemoryBytes   int64
+
+	// Usage as a percentage of the containers' limits, falling back to their
+	// requests. Only set when HasCPUBound/HasMemoryBound is true.
+	CPUPercent     float64
+	MemoryPercent  float64
+	HasCPUBound    bool
+	HasMemoryBound bool
+}
+
+// GetPodUsage matches pod metrics to their pods and computes how much of
+// their resource limits (or requests) the pods use. Containers that set
+// neither are left out of the percentages.
+func GetPodUsage(pods []corev1.Pod, podMetrics []metricsv1beta1.PodMetrics) []PodUsage {
+	podsByName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podsByName[pods[i].Name] = &pods[i]
+	}
+
+	usages := make([]PodUsage, 0, len(podMetrics))
+	for _, podMetric := range podMetrics {
+		usage := PodUsage{PodName: podMetric.Name}
+
+		var containers []corev1.Container
+		if pod, ok := podsByName[podMetric.Name]; ok {
+			containers = pod.Spec.Containers
+		}
+
+		var cpuUsed, cpuBound, memoryUsed, memoryBound int64
+		for _, container := range podMetric.Containers {
+			cpu := container.Usage.Cpu().MilliValue()
+			memory := container.Usage.Memory().Value()
+			usage.CPUMillicores += cpu
+			usage.MemoryBytes += memory
+
+			spec := findContainer(containers, container.Name)
+			if spec == nil {
+				continue
+			}
+			if bound, ok := resourceBound(spec, corev1.ResourceCPU); ok {
+				cpuUsed += cpu
+				cpuBound += bound.MilliValue()
+			}
+			if bound, ok := resourceBound(spec, corev1.ResourceMemory); ok {
+				memoryUsed += memory
+				memoryBound += bound.Value()
+			}
+		}
+
+		if cpuBound > 0 {
+			usage.CPUPercent = float64(cpuUsed) / float64(cpuBound) * 100
+			usage.HasCPUBound = true
+		}
+		if memoryBound > 0 {
+			usage.MemoryPercent = float64(memoryUsed) / float64(memoryBound) * 100
+			usage.HasMemoryBound = true
+		}
+
+		usages = append(usages, usage)
+	}
+
+	return usages
+}
+
+func findContainer(containers []corev1.Container, name string) *corev1.Container {
+	for i := range containers {
+		if containers[i].Name == name {
+			return &containers[i]
+		}
+	}
+	return nil
+}
+
+// resourceBound returns the limit of a container resource, or its request
+// when no limit is set
+func resourceBound(container *corev1.Container, name corev1.ResourceName) (resource.Quantity, bool) {
+	if limit, ok := container.Resources.Limits[name]; ok && !limit.IsZero() {
+		return limit, true
+	}
+	if request, ok := container.Resources.Requests[name]; ok && !request.IsZero() {
+		return request, true
+	}
+	return resource.Quantity{}, false
+}
@@ -0,0 +1,251 @@
+package monitoring
+
+import (
+	"math"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+// testContainer is a container spec with the given limits and requests,
+// written as cpu/memory quantities, empty when unset
+type testContainer struct {
+	name          string
+	cpuLimit      string
+	memoryLimit   string
+	cpuRequest    string
+	memoryRequest string
+}
+
+func (c testContainer) spec() corev1.Container {
+	resources := corev1.ResourceRequirements{Limits: corev1.ResourceList{}, Requests: corev1.ResourceList{}}
+	set := func(list corev1.ResourceList, name corev1.ResourceName, value string) {
+		if value != "" {
+			list[name] = resource.MustParse(value)
+		}
+	}
+	set(resources.Limits, corev1.ResourceCPU, c.cpuLimit)
+	set(resources.Limits, corev1.ResourceMemory, c.memoryLimit)
+	set(resources.Requests, corev1.ResourceCPU, c.cpuRequest)
+	set(resources.Requests, corev1.ResourceMemory, c.memoryRequest)
+	return corev1.Container{Name: c.name, Resources: resources}
+}
+
+// containerUsage is the cpu/memory usage of a container in the metrics API
+func containerUsage(name, cpu, memory string) metricsv1beta1.ContainerMetrics {
+	return metricsv1beta1.ContainerMetrics{
+		Name: name,
+		Usage: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		},
+	}
+}
+
+func TestGetPodUsage(t *testing.T) {
+	tests := []struct {
+		name       string
+		containers []testContainer // nil when the pod is not listed
+		usage      []metricsv1beta1.ContainerMetrics
+
+		cpuMillicores int64
+		memoryBytes   int64
+		cpuPercent    float64 // -1 when there is no CPU bound
+		memoryPercent float64 // -1 when there is no memory bound
+	}{
+		{
+			name:          "millicores and Mi",
+			containers:    []testContainer{{name: "web", cpuLimit: "500m", memoryLimit: "512Mi"}},
+			usage:         []metricsv1beta1.ContainerMetrics{containerUsage("web", "250m", "128Mi")},
+			cpuMillicores: 250,
+			memoryBytes:   128 << 20,
+			cpuPercent:    50,
+			memoryPercent: 25,
+		},
+		{
+			name:          "cores and Gi",
+			containers:    []testContainer{{name: "web", cpuLimit: "2", memoryLimit: "2Gi"}},
+			usage:         []metricsv1beta1.ContainerMetrics{containerUsage("web", "1500m", "1536Mi")},
+			cpuMillicores: 1500,
+			memoryBytes:   1536 << 20,
+			cpuPercent:    75,
+			memoryPercent: 75,
+		},
+		{
+			name:          "usage in nanocores and Ki",
+			containers:    []testContainer{{name: "web", cpuLimit: "1", memoryLimit: "1Gi"}},
+			usage:         []metricsv1beta1.ContainerMetrics{containerUsage("web", "100000000n", "262144Ki")},
+			cpuMillicores: 100,
+			memoryBytes:   256 << 20,
+			cpuPercent:    10,
+			memoryPercent: 25,
+		},
+		{
+			name:          "requests without limits",
+			containers:    []testContainer{{name: "web", cpuRequest: "200m", memoryRequest: "256Mi"}},
+			usage:         []metricsv1beta1.ContainerMetrics{containerUsage("web", "300m", "64Mi")},
+			cpuMillicores: 300,
+			memoryBytes:   64 << 20,
+			cpuPercent:    150,
+			memoryPercent: 25,
+		},
+		{
+			name:          "limit over request",
+			containers:    []testContainer{{name: "web", cpuLimit: "1", cpuRequest: "100m", memoryLimit: "1Gi", memoryRequest: "128Mi"}},
+			usage:         []metricsv1beta1.ContainerMetrics{containerUsage("web", "500m", "256Mi")},
+			cpuMillicores: 500,
+			memoryBytes:   256 << 20,
+			cpuPercent:    50,
+			memoryPercent: 25,
+		},
+		{
+			name:          "no limit",
+			containers:    []testContainer{{name: "web"}},
+			usage:         []metricsv1beta1.ContainerMetrics{containerUsage("web", "250m", "128Mi")},
+			cpuMillicores: 250,
+			memoryBytes:   128 << 20,
+			cpuPercent:    -1,
+			memoryPercent: -1,
+		},
+		{
+			name: "several containers",
+			containers: []testContainer{
+				{name: "web", cpuLimit: "500m", memoryLimit: "512Mi"},
+				{name: "proxy", cpuLimit: "500m", memoryLimit: "512Mi"},
+			},
+			usage: []metricsv1beta1.ContainerMetrics{
+				containerUsage("web", "400m", "384Mi"),
+				containerUsage("proxy", "100m", "128Mi"),
+			},
+			cpuMillicores: 500,
+			memoryBytes:   512 << 20,
+			cpuPercent:    50,
+			memoryPercent: 50,
+		},
+		{
+			// The sidecar without limits counts in the usage, not in the percentages
+			name: "several containers, one without limit",
+			containers: []testContainer{
+				{name: "web", cpuLimit: "1", memoryLimit: "1Gi"},
+				{name: "sidecar"},
+			},
+			usage: []metricsv1beta1.ContainerMetrics{
+				containerUsage("web", "250m", "256Mi"),
+				containerUsage("sidecar", "750m", "768Mi"),
+			},
+			cpuMillicores: 1000,
+			memoryBytes:   1 << 30,
+			cpuPercent:    25,
+			memoryPercent: 25,
+		},
+		{
+			name: "several containers, cpu and memory bound by different ones",
+			containers: []testContainer{
+				{name: "web", cpuLimit: "1"},
+				{name: "cache", memoryLimit: "1Gi"},
+			},
+			usage: []metricsv1beta1.ContainerMetrics{
+				containerUsage("web", "500m", "512Mi"),
+				containerUsage("cache", "100m", "256Mi"),
+			},
+			cpuMillicores: 600,
+			memoryBytes:   768 << 20,
+			cpuPercent:    50,
+			memoryPercent: 25,
+		},
+		{
+			name:          "pod not listed",
+			usage:         []metricsv1beta1.ContainerMetrics{containerUsage("web", "250m", "128Mi")},
+			cpuMillicores: 250,
+			memoryBytes:   128 << 20,
+			cpuPercent:    -1,
+			memoryPercent: -1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var pods []corev1.Pod
+			if test.containers != nil {
+				pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "shop-1"}}
+				for _, container := range test.containers {
+					pod.Spec.Containers = append(pod.Spec.Containers, container.spec())
+				}
+				pods = append(pods, pod)
+			}
+			podMetrics := []metricsv1beta1.PodMetrics{{
+				ObjectMeta: metav1.ObjectMeta{Name: "shop-1"},
+				Containers: test.usage,
+			}}
+
+			usages := GetPodUsage(pods, podMetrics)
+			if len(usages) != 1 {
+				t.Fatalf("GetPodUsage() returned %d usages, want 1", len(usages))
+			}
+			usage := usages[0]
+
+			if usage.PodName != "shop-1" {
+				t.Errorf("PodName = %s, want shop-1", usage.PodName)
+			}
+			if usage.CPUMillicores != test.cpuMillicores {
+				t.Errorf("CPUMillicores = %d, want %d", usage.CPUMillicores, test.cpuMillicores)
+			}
+			if usage.MemoryBytes != test.memoryBytes {
+				t.Errorf("MemoryBytes = %d, want %d", usage.MemoryBytes, test.memoryBytes)
+			}
+			checkPercent(t, "CPU", usage.CPUPercent, usage.HasCPUBound, test.cpuPercent)
+			checkPercent(t, "memory", usage.MemoryPercent, usage.HasMemoryBound, test.memoryPercent)
+		})
+	}
+}
+
+func checkPercent(t *testing.T, name string, got float64, bound bool, want float64) {
+	t.Helper()
+	if want < 0 {
+		if bound {
+			t.Errorf("%s percent = %.2f, want no bound", name, got)
+		}
+		return
+	}
+	if !bound {
+		t.Errorf("%s has no bound, want %.2f%%", name, want)
+		return
+	}
+	if math.Abs(got-want) > 0.001 {
+		t.Errorf("%s percent = %.4f, want %.2f", name, got, want)
+	}
+}
+
+func TestGetPodUsageMatchesPods(t *testing.T) {
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "shop-1"}, Spec: corev1.PodSpec{Containers: []corev1.Container{
+			testContainer{name: "web", cpuLimit: "1", memoryLimit: "1Gi"}.spec(),
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "shop-2"}, Spec: corev1.PodSpec{Containers: []corev1.Container{
+			testContainer{name: "web", cpuLimit: "500m", memoryLimit: "256Mi"}.spec(),
+		}}},
+	}
+	podMetrics := []metricsv1beta1.PodMetrics{
+		{ObjectMeta: metav1.ObjectMeta{Name: "shop-2"}, Containers: []metricsv1beta1.ContainerMetrics{containerUsage("web", "250m", "128Mi")}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "shop-1"}, Containers: []metricsv1beta1.ContainerMetrics{containerUsage("web", "250m", "128Mi")}},
+	}
+
+	usages := GetPodUsage(pods, podMetrics)
+	if len(usages) != 2 {
+		t.Fatalf("GetPodUsage() returned %d usages, want 2", len(usages))
+	}
+
+	// Each pod is measured against its own limits
+	want := map[string][2]float64{"shop-1": {25, 12.5}, "shop-2": {50, 50}}
+	for _, usage := range usages {
+		w := want[usage.PodName]
+		checkPercent(t, usage.PodName+" CPU", usage.CPUPercent, usage.HasCPUBound, w[0])
+		checkPercent(t, usage.PodName+" memory", usage.MemoryPercent, usage.HasMemoryBound, w[1])
+	}
+	if usages[0].PodName != "shop-2" || usages[1].PodName != "shop-1" {
+		t.Errorf("usages of %s and %s, want them in the order of the metrics", usages[0].PodName, usages[1].PodName)
+	}
+}
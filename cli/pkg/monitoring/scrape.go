@@ -0,0 +1,430 @@
+package monitoring
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// requestCounters are the counters of handled HTTP requests, in order of
+// preference. Histogram counts are used when the app exports no counter.
+var requestCounters = []string{
+	"http_requests_total",
+	"http_server_requests_total",
+	"http_request_duration_seconds_count",
+	"http_server_requests_seconds_count",
+	"http_server_request_duration_seconds_count",
+}
+
+// latencyHistograms are the histograms of HTTP request durations, in order of preference
+var latencyHistograms = []string{
+	"http_request_duration_seconds",
+	"http_server_requests_seconds",
+	"http_server_request_duration_seconds",
+	"http_request_duration_milliseconds",
+}
+
+// statusLabels are the labels holding the HTTP status code of a request
+var statusLabels = []string{"code", "status", "status_code", "http_status_code", "http_response_status_code"}
+
+// promSample is a sample of the Prometheus text exposition format
+type promSample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// podScrape holds the counters of the last scrape of a pod, used to compute rates
+type podScrape struct {
+	At       time.Time
+	Requests float64
+	Errors   float64
+	Buckets  map[float64]float64 // cumulative count by upper bound, in ms
+	Sum      float64             // total latency, in ms
+}
+
+// collectRequestMetrics scrapes the request metrics of an app when enabled
+func (c *Collector) collectRequestMetrics(app App) error {
+	config, err := c.getMonitoringConfig(app.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get monitoring config: %w", err)
+	}
+
+	if !config.MetricsEnabled {
+		return nil
+	}
+
+	pods, err := c.k8s.GetPods(app.Name, app.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get pods: %w", err)
+	}
+
+	// Only the process receiving the app's traffic serves HTTP requests: its
+	// pods are labeled with the app name
+	var webPods []corev1.Pod
+	for _, pod := range pods {
+		if pod.Labels["app"] == app.Name {
+			webPods = append(webPods, pod)
+		}
+	}
+
+	return c.scrapeAppMetrics(app, webPods, config)
+}
+
+// scrapeAppMetrics scrapes the Prometheus endpoint of every running pod of an
+// app and stores the request rate, error rate and latency since the previous
+// scrape. Nothing is stored on the first scrape of a pod.
+func (c *Collector) scrapeAppMetrics(app App, pods []corev1.Pod, config *MonitoringConfig) error {
+	var requests, serverErrors, latencySum float64
+	var elapsed time.Duration
+	buckets := make(map[float64]float64)
+	scraped := 0
+	var lastErr error
+
+	// Pods that are gone are forgotten
+	previousScrapes := c.scrapes[app.ID]
+	c.scrapes[app.ID] = make(map[string]podScrape)
+
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+
+		current, err := c.scrapePod(pod, config)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		scraped++
+
+		previous, ok := previousScrapes[pod.Name]
+		c.scrapes[app.ID][pod.Name] = current
+		if !ok || !current.At.After(previous.At) {
+			continue
+		}
+		if counterReset(previous, current) {
+			// The pod restarted: every counter starts again from zero
+			previous = podScrape{At: previous.At}
+		}
+
+		requests += counterDelta(previous.Requests, current.Requests)
+		serverErrors += counterDelta(previous.Errors, current.Errors)
+		latencySum += counterDelta(previous.Sum, current.Sum)
+		for le, count := range current.Buckets {
+			buckets[le] += counterDelta(previous.Buckets[le], count)
+		}
+		if d := current.At.Sub(previous.At); d > elapsed {
+			elapsed = d
+		}
+	}
+
+	if scraped == 0 && lastErr != nil {
+		return lastErr
+	}
+	if elapsed <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	metrics := []Metric{
+		{Type: MetricTypeRequests, Value: requests / elapsed.Seconds(), Unit: "req/s"},
+	}
+	if requests > 0 {
+		metrics = append(metrics, Metric{Type: MetricTypeErrors, Value: serverErrors / requests * 100, Unit: "percent"})
+	}
+	if count := buckets[math.Inf(1)]; count > 0 {
+		metrics = append(metrics, Metric{Type: MetricTypeLatency, Value: latencySum / count, Unit: "ms"})
+
+		// Percentiles need at least one finite bucket
+		for _, quantile := range []struct {
+			metricType MetricType
+			q          float64
+		}{
+			{MetricTypeLatencyP50, 0.50},
+			{MetricTypeLatencyP95, 0.95},
+			{MetricTypeLatencyP99, 0.99},
+		} {
+			if value := histogramQuantile(quantile.q, buckets); !math.IsNaN(value) {
+				metrics = append(metrics, Metric{Type: quantile.metricType, Value: value, Unit: "ms"})
+			}
+		}
+	}
+
+	for _, metric := range metrics {
+		metric.AppID = app.ID
+		metric.Timestamp = now
+		if err := c.storeMetric(metric); err != nil {
+			return fmt.Errorf("failed to store %s metric: %w", metric.Type, err)
+		}
+	}
+
+	return nil
+}
+
+// scrapePod reads the request counters and latency histogram exported by a pod
+func (c *Collector) scrapePod(pod corev1.Pod, config *MonitoringConfig) (podScrape, error) {
+	url := fmt.Sprintf("http://%s:%d%s", pod.Status.PodIP, config.MetricsPort, config.MetricsPath)
+
+	client := &http.Client{Timeout: time.Duration(config.HealthCheckTimeout) * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return podScrape{}, fmt.Errorf("failed to scrape %s: %w", pod.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return podScrape{}, fmt.Errorf("failed to scrape %s: %s returned %s", pod.Name, url, resp.Status)
+	}
+
+	samples, err := parsePrometheusText(resp.Body)
+	if err != nil {
+		return podScrape{}, fmt.Errorf("failed to parse metrics of %s: %w", pod.Name, err)
+	}
+
+	scrape := summarizeSamples(samples)
+	scrape.At = time.Now()
+	return scrape, nil
+}
+
+// summarizeSamples sums the request counters, 5xx responses and latency
+// histogram of a scrape over all label sets
+func summarizeSamples(samples []promSample) podScrape {
+	byName := make(map[string][]promSample)
+	for _, sample := range samples {
+		// NaN has no meaning in a counter and would poison the sums
+		if math.IsNaN(sample.Value) {
+			continue
+		}
+		byName[sample.Name] = append(byName[sample.Name], sample)
+	}
+
+	scrape := podScrape{Buckets: make(map[float64]float64)}
+
+	for _, name := range requestCounters {
+		counters, ok := byName[name]
+		if !ok {
+			continue
+		}
+		for _, sample := range counters {
+			scrape.Requests += sample.Value
+			if isServerError(sample.Labels) {
+				scrape.Errors += sample.Value
+			}
+		}
+		break
+	}
+
+	for _, name := range latencyHistograms {
+		bucketSamples, ok := byName[name+"_bucket"]
+		if !ok {
+			continue
+		}
+
+		// Durations are converted to milliseconds
+		scale := 1000.0
+		if strings.HasSuffix(name, "_milliseconds") {
+			scale = 1
+		}
+
+		for _, sample := range bucketSamples {
+			le, err := strconv.ParseFloat(sample.Labels["le"], 64)
+			if err != nil {
+				continue
+			}
+			if !math.IsInf(le, 1) {
+				le *= scale
+			}
+			scrape.Buckets[le] += sample.Value
+		}
+		for _, sample := range byName[name+"_sum"] {
+			scrape.Sum += sample.Value * scale
+		}
+		break
+	}
+
+	return scrape
+}
+
+func isServerError(labels map[string]string) bool {
+	for _, label := range statusLabels {
+		if status, ok := labels[label]; ok {
+			return strings.HasPrefix(status, "5")
+		}
+	}
+	return false
+}
+
+// counterReset reports whether a counter of a pod went down since its
+// previous scrape, meaning the pod restarted. Counters are checked together so
+// histogram buckets stay cumulative when only some of them went down.
+func counterReset(previous, current podScrape) bool {
+	if current.Requests < previous.Requests || current.Errors < previous.Errors || current.Sum < previous.Sum {
+		return true
+	}
+	for le, count := range previous.Buckets {
+		if current.Buckets[le] < count {
+			return true
+		}
+	}
+	return false
+}
+
+// counterDelta returns how much a counter increased, treating a decrease as
+// a restart of the pod
+func counterDelta(previous, current float64) float64 {
+	if current < previous {
+		return current
+	}
+	return current - previous
+}
+
+// histogramQuantile estimates a quantile from cumulative histogram buckets by
+// linear interpolation within the bucket it falls in, like PromQL's
+// histogram_quantile. It returns NaN without a +Inf bucket and a finite
+// bucket to interpolate in.
+func histogramQuantile(q float64, buckets map[float64]float64) float64 {
+	bounds := make([]float64, 0, len(buckets))
+	for le := range buckets {
+		bounds = append(bounds, le)
+	}
+	sort.Float64s(bounds)
+	if len(bounds) < 2 || !math.IsInf(bounds[len(bounds)-1], 1) {
+		return math.NaN()
+	}
+
+	total := buckets[bounds[len(bounds)-1]]
+	if total <= 0 {
+		return 0
+	}
+	rank := q * total
+
+	lowerBound, lowerCount := 0.0, 0.0
+	for _, le := range bounds {
+		count := buckets[le]
+		if count >= rank {
+			if math.IsInf(le, 1) {
+				// Above the highest finite bucket: that bound is the best estimate
+				return lowerBound
+			}
+			if count == lowerCount {
+				return le
+			}
+			return lowerBound + (le-lowerBound)*(rank-lowerCount)/(count-lowerCount)
+		}
+		lowerBound, lowerCount = le, count
+	}
+
+	return lowerBound
+}
+
+// parsePrometheusText parses the samples of the Prometheus text exposition format
+func parsePrometheusText(r io.Reader) ([]promSample, error) {
+	var samples []promSample
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		sample, err := parsePrometheusLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		samples = append(samples, sample)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+// parsePrometheusLine parses a line such as
+// http_requests_total{method="GET",code="200"} 1027 1395066363000
+func parsePrometheusLine(line string) (promSample, error) {
+	sample := promSample{Labels: make(map[string]string)}
+
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return sample, fmt.Errorf("missing value: %q", line)
+	}
+	sample.Name = line[:end]
+	rest := line[end:]
+
+	if strings.HasPrefix(rest, "{") {
+		var err error
+		rest, err = parsePrometheusLabels(rest[1:], sample.Labels)
+		if err != nil {
+			return sample, err
+		}
+	}
+
+	// The value may be followed by a timestamp
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return sample, fmt.Errorf("missing value: %q", line)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return sample, fmt.Errorf("invalid value %q", fields[0])
+	}
+	sample.Value = value
+
+	return sample, nil
+}
+
+// parsePrometheusLabels parses the labels following an opening brace and
+// returns the rest of the line after the closing brace
+func parsePrometheusLabels(s string, labels map[string]string) (string, error) {
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if strings.HasPrefix(s, "}") {
+			return s[1:], nil
+		}
+
+		eq := strings.Index(s, "=")
+		if eq <= 0 {
+			return "", fmt.Errorf("invalid label in %q", s)
+		}
+		name := strings.TrimSpace(s[:eq])
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		if !strings.HasPrefix(s, `"`) {
+			return "", fmt.Errorf("unquoted value for label %s", name)
+		}
+
+		var value strings.Builder
+		i := 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+				continue
+			}
+			value.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return "", fmt.Errorf("unterminated value for label %s", name)
+		}
+
+		labels[name] = value.String()
+		s = s[i+1:]
+	}
+}
@@ -0,0 +1,253 @@
+package monitoring
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePrometheusLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    promSample
+		wantNaN bool
+	}{
+		{
+			name: "no labels",
+			line: "http_requests_total 1027",
+			want: promSample{Name: "http_requests_total", Labels: map[string]string{}, Value: 1027},
+		},
+		{
+			name: "labels and timestamp",
+			line: `http_requests_total{method="GET",code="200"} 1027 1395066363000`,
+			want: promSample{Name: "http_requests_total", Labels: map[string]string{"method": "GET", "code": "200"}, Value: 1027},
+		},
+		{
+			name: "negative timestamp",
+			line: `http_requests_total{code="500"} 3 -1395066363000`,
+			want: promSample{Name: "http_requests_total", Labels: map[string]string{"code": "500"}, Value: 3},
+		},
+		{
+			name: "escaped label values",
+			line: `http_requests_total{path="C:\\dir\\\"file\"",msg="a\nb",code="200"} 4`,
+			want: promSample{Name: "http_requests_total", Labels: map[string]string{
+				"path": `C:\dir\"file"`, "msg": "a\nb", "code": "200",
+			}, Value: 4},
+		},
+		{
+			name: "braces, commas and spaces in label values",
+			line: `http_requests_total{path="/a,b} 7",code="200",} 5`,
+			want: promSample{Name: "http_requests_total", Labels: map[string]string{"path": "/a,b} 7", "code": "200"}, Value: 5},
+		},
+		{
+			name: "empty labels",
+			line: "up{} 1",
+			want: promSample{Name: "up", Labels: map[string]string{}, Value: 1},
+		},
+		{
+			name: "+Inf bucket",
+			line: `http_request_duration_seconds_bucket{le="+Inf"} 144320`,
+			want: promSample{Name: "http_request_duration_seconds_bucket", Labels: map[string]string{"le": "+Inf"}, Value: 144320},
+		},
+		{
+			name: "+Inf value",
+			line: "temperature +Inf",
+			want: promSample{Name: "temperature", Labels: map[string]string{}, Value: math.Inf(1)},
+		},
+		{
+			name: "-Inf value",
+			line: "temperature -Inf",
+			want: promSample{Name: "temperature", Labels: map[string]string{}, Value: math.Inf(-1)},
+		},
+		{
+			name:    "NaN value",
+			line:    `rpc_duration_seconds{quantile="0.99"} NaN`,
+			want:    promSample{Name: "rpc_duration_seconds", Labels: map[string]string{"quantile": "0.99"}},
+			wantNaN: true,
+		},
+		{
+			name: "exponent",
+			line: "http_request_duration_seconds_sum 1.7560473e+06",
+			want: promSample{Name: "http_request_duration_seconds_sum", Labels: map[string]string{}, Value: 1.7560473e+06},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parsePrometheusLine(test.line)
+			if err != nil {
+				t.Fatalf("parsePrometheusLine() failed: %v", err)
+			}
+			if test.wantNaN {
+				if !math.IsNaN(got.Value) {
+					t.Errorf("value = %v, want NaN", got.Value)
+				}
+				got.Value = 0
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parsePrometheusLine() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParsePrometheusLineErrors(t *testing.T) {
+	for _, line := range []string{
+		"http_requests_total",
+		`http_requests_total{code="200"}`,
+		`http_requests_total{code=200} 1`,
+		`http_requests_total{code="200} 1`,
+		"http_requests_total many",
+		"{} 1",
+	} {
+		if _, err := parsePrometheusLine(line); err == nil {
+			t.Errorf("parsePrometheusLine(%q) succeeded, want an error", line)
+		}
+	}
+}
+
+func TestParsePrometheusText(t *testing.T) {
+	text := "# HELP http_requests_total The total number of HTTP requests.\r\n" +
+		"# TYPE http_requests_total counter\r\n" +
+		"http_requests_total{code=\"200\"} 1027 1395066363000\r\n" +
+		"\r\n" +
+		"http_requests_total{code=\"503\"} 3\r\n"
+
+	samples, err := parsePrometheusText(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("parsePrometheusText() failed: %v", err)
+	}
+	if len(samples) != 2 {
+		t.Fatalf("parsed %d samples, want 2", len(samples))
+	}
+
+	_, err = parsePrometheusText(strings.NewReader("up 1\nup{job=\"api} 1\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("parsePrometheusText() error = %v, want an error on line 2", err)
+	}
+}
+
+func TestSummarizeSamples(t *testing.T) {
+	text := `http_requests_total{code="200"} 90
+http_requests_total{code="503"} 10
+http_requests_total{code="500"} NaN
+http_request_duration_seconds_bucket{le="0.1"} 60
+http_request_duration_seconds_bucket{le="0.5"} 95
+http_request_duration_seconds_bucket{le="+Inf"} 100
+http_request_duration_seconds_sum 12.5
+http_request_duration_seconds_count 100
+`
+	samples, err := parsePrometheusText(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("parsePrometheusText() failed: %v", err)
+	}
+
+	got := summarizeSamples(samples)
+	want := podScrape{
+		Requests: 100,
+		Errors:   10,
+		Buckets:  map[float64]float64{100: 60, 500: 95, math.Inf(1): 100},
+		Sum:      12500,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("summarizeSamples() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCounterDelta(t *testing.T) {
+	tests := []struct {
+		name              string
+		previous, current float64
+		want              float64
+	}{
+		{"increase", 100, 150, 50},
+		{"unchanged", 100, 100, 0},
+		{"reset", 100, 20, 20},
+		{"reset to zero", 100, 0, 0},
+		{"first value", 0, 42, 42},
+	}
+
+	for _, test := range tests {
+		if got := counterDelta(test.previous, test.current); got != test.want {
+			t.Errorf("%s: counterDelta(%v, %v) = %v, want %v", test.name, test.previous, test.current, got, test.want)
+		}
+	}
+}
+
+func TestCounterReset(t *testing.T) {
+	previous := podScrape{
+		Requests: 100,
+		Errors:   5,
+		Buckets:  map[float64]float64{100: 60, math.Inf(1): 100},
+		Sum:      9000,
+	}
+
+	tests := []struct {
+		name    string
+		current podScrape
+		want    bool
+	}{
+		{
+			name:    "all counters increased",
+			current: podScrape{Requests: 120, Errors: 5, Buckets: map[float64]float64{100: 70, math.Inf(1): 120}, Sum: 9500},
+		},
+		{
+			name:    "requests went down",
+			current: podScrape{Requests: 10, Errors: 5, Buckets: map[float64]float64{100: 70, math.Inf(1): 120}, Sum: 9500},
+			want:    true,
+		},
+		{
+			// The +Inf bucket grew past its previous value but a lower one did not
+			name:    "one bucket went down",
+			current: podScrape{Requests: 120, Errors: 5, Buckets: map[float64]float64{100: 30, math.Inf(1): 120}, Sum: 9500},
+			want:    true,
+		},
+		{
+			name:    "bucket disappeared",
+			current: podScrape{Requests: 120, Errors: 5, Buckets: map[float64]float64{math.Inf(1): 120}, Sum: 9500},
+			want:    true,
+		},
+	}
+
+	for _, test := range tests {
+		if got := counterReset(previous, test.current); got != test.want {
+			t.Errorf("%s: counterReset() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	buckets := map[float64]float64{100: 60, 500: 95, 1000: 95, math.Inf(1): 100}
+
+	tests := []struct {
+		name    string
+		q       float64
+		buckets map[float64]float64
+		want    float64
+	}{
+		{"first bucket", 0.5, buckets, 100 * 50 / 60.0},
+		{"interpolated", 0.8, buckets, 100 + 400*20/35.0},
+		{"bucket upper bound", 0.95, buckets, 500},
+		{"+Inf bucket", 0.99, buckets, 1000},
+		{"all in +Inf bucket", 0.5, map[float64]float64{100: 0, math.Inf(1): 10}, 100},
+		{"no request", 0.5, map[float64]float64{100: 0, math.Inf(1): 0}, 0},
+	}
+
+	for _, test := range tests {
+		if got := histogramQuantile(test.q, test.buckets); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("%s: histogramQuantile(%v) = %v, want %v", test.name, test.q, got, test.want)
+		}
+	}
+
+	for name, buckets := range map[string]map[float64]float64{
+		"no bucket": {},
+		"only +Inf": {math.Inf(1): 10},
+		"no +Inf":   {100: 5, 500: 10},
+	} {
+		if got := histogramQuantile(0.5, buckets); !math.IsNaN(got) {
+			t.Errorf("%s: histogramQuantile() = %v, want NaN", name, got)
+		}
+	}
+}
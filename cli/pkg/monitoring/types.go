@@ -16,6 +16,15 @@ const (
 	MetricTypeRequests  MetricType = "requests"
 	MetricTypeErrors    MetricType = "errors"
 	MetricTypeLatency   MetricType = "latency"
+
+	// Latency percentiles of the requests handled since the previous scrape
+	MetricTypeLatencyP50 MetricType = "latency_p50"
+	MetricTypeLatencyP95 MetricType = "latency_p95"
+	MetricTypeLatencyP99 MetricType = "latency_p99"
+
+	// Usage as a percentage of the containers' limits (or requests)
+	MetricTypeCPUPercent    MetricType = "cpu_percent"
+	MetricTypeMemoryPercent MetricType = "memory_percent"
 )
 
 // Metric represents a single metric data point
@@ -61,6 +70,17 @@ const (
 	AlertSeverityCritical AlertSeverity = "critical"
 )
 
+// Alert types fired by the collector
+const (
+	AlertTypeCPUHigh          = "cpu_high"
+	AlertTypeMemoryHigh       = "memory_high"
+	AlertTypeErrorRateHigh    = "error_rate_high"
+	AlertTypeResponseTimeHigh = "response_time_high"
+)
+
+// AlertTypes lists the alert types fired by the collector
+var AlertTypes = []string{AlertTypeCPUHigh, AlertTypeMemoryHigh, AlertTypeErrorRateHigh, AlertTypeResponseTimeHigh}
+
 // AlertStatus represents the current status of an alert
 type AlertStatus string
 
@@ -83,6 +103,7 @@ type Alert struct {
 	CreatedAt       time.Time     `json:"created_at" db:"created_at"`
 	ResolvedAt      *time.Time    `json:"resolved_at" db:"resolved_at"`
 	AcknowledgedAt  *time.Time    `json:"acknowledged_at" db:"acknowledged_at"`
+	SuppressedUntil *time.Time    `json:"suppressed_until" db:"suppressed_until"`
 }
 
 // Event represents a Kubernetes event
@@ -38,14 +38,29 @@ func NewManager() (*Manager, error) {
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
 
+	return &Manager{
+		db:  db,
+		key: secretKey(),
+	}, nil
+}
+
+// secretKey returns the AES key of the secrets stored in the database
+func secretKey() []byte {
 	// Simple encryption key (in production, use proper key management)
 	key := make([]byte, 32)
 	copy(key, []byte("shipyard-secret-key-32-bytes!!!"))
+	return key
+}
 
-	return &Manager{
-		db:  db,
-		key: key,
-	}, nil
+// EncryptSecret encrypts a secret to store in the database, as registry
+// passwords are
+func EncryptSecret(plaintext string) (string, error) {
+	return encrypt(secretKey(), plaintext)
+}
+
+// DecryptSecret decrypts a secret encrypted by EncryptSecret
+func DecryptSecret(ciphertext string) (string, error) {
+	return decrypt(secretKey(), ciphertext)
 }
 
 // AddRegistry adds a new registry credential (simplified)
@@ -250,7 +265,17 @@ func (m *Manager) Close() error {
 
 // encrypt encrypts a string using AES
 func (m *Manager) encrypt(plaintext string) (string, error) {
-	block, err := aes.NewCipher(m.key)
+	return encrypt(m.key, plaintext)
+}
+
+// decrypt decrypts a string using AES
+func (m *Manager) decrypt(ciphertext string) (string, error) {
+	return decrypt(m.key, ciphertext)
+}
+
+// encrypt encrypts a string using AES-GCM, the nonce first
+func encrypt(key []byte, plaintext string) (string, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", err
 	}
@@ -269,14 +294,14 @@ func (m *Manager) encrypt(plaintext string) (string, error) {
 	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
-// decrypt decrypts a string using AES
-func (m *Manager) decrypt(ciphertext string) (string, error) {
+// decrypt decrypts a string encrypted by encrypt
+func decrypt(key []byte, ciphertext string) (string, error) {
 	data, err := base64.StdEncoding.DecodeString(ciphertext)
 	if err != nil {
 		return "", err
 	}
 
-	block, err := aes.NewCipher(m.key)
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", err
 	}
@@ -41,6 +41,12 @@ export default defineConfig({
             { text: 'shipyard status', link: '/cli/status' },
             { text: 'shipyard logs', link: '/cli/logs' },
             { text: 'shipyard rollback', link: '/cli/rollback' },
+            { text: 'shipyard promote', link: '/cli/promote' },
+            { text: 'shipyard validate', link: '/cli/validate' },
+            { text: 'shipyard render', link: '/cli/render' },
+            { text: 'shipyard diff', link: '/cli/diff' },
+            { text: 'shipyard scale', link: '/cli/scale' },
+            { text: 'shipyard jobs', link: '/cli/jobs' },
             { text: 'shipyard registry', link: '/cli/registry' },
             { text: 'shipyard domain', link: '/cli/domain' }
           ]
@@ -52,7 +58,9 @@ export default defineConfig({
             { text: 'shipyard metrics', link: '/cli/metrics' },
             { text: 'shipyard health', link: '/cli/health' },
             { text: 'shipyard alerts', link: '/cli/alerts' },
-            { text: 'shipyard events', link: '/cli/events' }
+            { text: 'shipyard events', link: '/cli/events' },
+            { text: 'shipyard agent', link: '/cli/agent' },
+            { text: 'shipyard exporter', link: '/cli/exporter' }
           ]
         }
       ],